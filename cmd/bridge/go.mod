@@ -2,26 +2,71 @@ module github.com/xilverfang/syncora/cmd/bridge
 
 go 1.24.4
 
+replace github.com/xilverfang/syncora/internal/core/amount => ../../internal/core/amount
+
 replace github.com/xilverfang/syncora/internal/core/crypto => ../../internal/core/crypto
 
 replace github.com/xilverfang/syncora/internal/core/database => ../../internal/core/database
 
+replace github.com/xilverfang/syncora/internal/core/logging => ../../internal/core/logging
+
+replace github.com/xilverfang/syncora/internal/core/registry => ../../internal/core/registry
+
+replace github.com/xilverfang/syncora/shared => ../../shared
+
 replace github.com/xilverfang/syncora/internal/bridge-engine => ../../internal/bridge-engine
 
 require (
+	github.com/ethereum/go-ethereum v1.15.11
 	github.com/spf13/cobra v1.9.1
+	github.com/xilverfang/syncora/internal/bridge-engine v0.0.0-00010101000000-000000000000
+	github.com/xilverfang/syncora/internal/core/amount v0.0.0-00010101000000-000000000000
 	github.com/xilverfang/syncora/internal/core/crypto v0.0.0-00010101000000-000000000000
 	github.com/xilverfang/syncora/internal/core/database v0.0.0-00010101000000-000000000000
+	github.com/xilverfang/syncora/internal/core/logging v0.0.0-00010101000000-000000000000
+	github.com/xilverfang/syncora/internal/core/registry v0.0.0-00010101000000-000000000000
+	golang.org/x/sys v0.33.0
 	golang.org/x/term v0.32.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
+	github.com/Microsoft/go-winio v0.6.2 // indirect
+	github.com/StackExchange/wmi v1.2.1 // indirect
+	github.com/bits-and-blooms/bitset v1.20.0 // indirect
+	github.com/consensys/bavard v0.1.27 // indirect
+	github.com/consensys/gnark-crypto v0.16.0 // indirect
+	github.com/crate-crypto/go-eth-kzg v1.3.0 // indirect
+	github.com/crate-crypto/go-ipa v0.0.0-20240724233137-53bbb0ceb27a // indirect
+	github.com/deckarep/golang-set/v2 v2.6.0 // indirect
 	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.0.1 // indirect
-	github.com/ethereum/go-ethereum v1.15.11 // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/ethereum/c-kzg-4844/v2 v2.1.0 // indirect
+	github.com/ethereum/go-verkle v0.2.2 // indirect
+	github.com/fsnotify/fsnotify v1.6.0 // indirect
+	github.com/go-ole/go-ole v1.3.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/gorilla/websocket v1.4.2 // indirect
 	github.com/holiman/uint256 v1.3.2 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/lib/pq v1.10.9 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/mmcloughlin/addchain v0.4.0 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	github.com/shirou/gopsutil v3.21.4-0.20210419000835-c7a38de76ee5+incompatible // indirect
 	github.com/spf13/pflag v1.0.6 // indirect
+	github.com/supranational/blst v0.3.14 // indirect
+	github.com/tklauser/go-sysconf v0.3.12 // indirect
+	github.com/tklauser/numcpus v0.6.1 // indirect
+	github.com/tyler-smith/go-bip39 v1.1.0 // indirect
+	github.com/xilverfang/syncora/shared v0.0.0-00010101000000-000000000000 // indirect
 	golang.org/x/crypto v0.35.0 // indirect
-	golang.org/x/sys v0.33.0 // indirect
+	golang.org/x/exp v0.0.0-20250408133849-7e4ce0ab07d0 // indirect
+	golang.org/x/sync v0.14.0 // indirect
+	modernc.org/libc v1.65.10 // indirect
+	modernc.org/mathutil v1.7.1 // indirect
+	modernc.org/memory v1.11.0 // indirect
+	modernc.org/sqlite v1.38.0 // indirect
+	rsc.io/tmplfunc v0.0.3 // indirect
 )
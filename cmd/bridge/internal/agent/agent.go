@@ -0,0 +1,308 @@
+// Package agent implements a local signing agent, similar to ssh-agent, that holds decrypted
+// account keys for a limited time and serves sign-only requests over a Unix socket.
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/xilverfang/syncora/internal/core/crypto"
+)
+
+const (
+	// DefaultTTL is how long keys stay unlocked when no TTL is given.
+	DefaultTTL = 15 * time.Minute
+	// MaxTTL is the longest time a key can stay unlocked.
+	MaxTTL = 24 * time.Hour
+
+	// requestTimeout bounds a single request, including Argon2id key derivation on add.
+	requestTimeout = 30 * time.Second
+)
+
+// Server holds unlocked signers and serves requests over a Unix socket.
+type Server struct {
+	defaultTTL time.Duration
+	maxTTL     time.Duration
+
+	mu   sync.Mutex
+	keys map[common.Address]*entry
+}
+
+// entry is an unlocked key and its expiry.
+type entry struct {
+	signer  *crypto.LocalSigner
+	expires time.Time
+	timer   *time.Timer
+}
+
+// NewServer returns an agent server that unlocks keys for defaultTTL unless a request asks for
+// another TTL of at most maxTTL. Zero values select DefaultTTL and MaxTTL, and neither can exceed
+// MaxTTL.
+func NewServer(defaultTTL, maxTTL time.Duration) *Server {
+	if maxTTL <= 0 || maxTTL > MaxTTL {
+		maxTTL = MaxTTL
+	}
+	if defaultTTL <= 0 {
+		defaultTTL = DefaultTTL
+	}
+	return &Server{defaultTTL: min(defaultTTL, maxTTL), maxTTL: maxTTL, keys: make(map[common.Address]*entry)}
+}
+
+// ListenAndServe locks process memory, listens on the socket at path, and serves until ctx is
+// cancelled. All keys are locked and the socket is removed before it returns.
+func (s *Server) ListenAndServe(ctx context.Context, path string) error {
+	if err := protectMemory(); err != nil {
+		slog.Warn("failed to protect agent memory", "err", err)
+	}
+
+	l, err := listen(path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	return s.Serve(ctx, l)
+}
+
+// Serve accepts connections on l until ctx is cancelled, then closes l and locks all keys.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	defer s.LockAll()
+
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %v", err)
+		}
+		go s.handle(ctx, conn)
+	}
+}
+
+// LockAll zeroes and forgets every unlocked key.
+func (s *Server) LockAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for addr, e := range s.keys {
+		e.timer.Stop()
+		e.signer.Lock()
+		delete(s.keys, addr)
+	}
+}
+
+// handle serves the single request carried by conn.
+func (s *Server) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	if uc, ok := conn.(*net.UnixConn); ok {
+		if err := checkPeer(uc); err != nil {
+			slog.Warn("agent rejected connection", "err", err)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	var req request
+	line, err := bufio.NewReader(io.LimitReader(conn, maxRequestSize)).ReadBytes('\n')
+	if err == nil {
+		err = json.Unmarshal(line, &req)
+	}
+	zeroBytes(line)
+	var resp response
+	if err != nil {
+		resp.Error = fmt.Sprintf("invalid request: %v", err)
+	} else {
+		resp = s.dispatch(ctx, &req)
+	}
+	zeroBytes(req.Passphrase)
+
+	json.NewEncoder(conn).Encode(resp)
+}
+
+// dispatch executes a decoded request.
+func (s *Server) dispatch(ctx context.Context, req *request) response {
+	var resp response
+	var err error
+	switch req.Op {
+	case opAdd:
+		var expires time.Time
+		expires, err = s.add(ctx, req)
+		resp.Expires = &expires
+	case opRemove:
+		err = s.remove(req.Address)
+	case opList:
+		resp.Keys = s.list()
+	case opLock:
+		s.LockAll()
+	case opSignTx:
+		resp.RawTx, err = s.signTx(ctx, req)
+	case opSignMessage, opSignTypedData:
+		resp.Signature, err = s.signMessage(ctx, req)
+	default:
+		err = fmt.Errorf("unknown operation: %q", req.Op)
+	}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	return resp
+}
+
+// add decrypts a stored key with the supplied passphrase and keeps it unlocked until its TTL expires.
+func (s *Server) add(ctx context.Context, req *request) (time.Time, error) {
+	if req.Key == nil {
+		return time.Time{}, errors.New("missing key")
+	}
+	ttl, err := s.ttl(req.TTLSeconds)
+	if err != nil {
+		return time.Time{}, err
+	}
+	salt, err := hex.DecodeString(req.Key.Salt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid salt: %v", err)
+	}
+	params := crypto.KDFParams{Time: req.Key.KDFTime, Memory: req.Key.KDFMemory, Threads: req.Key.KDFThreads}
+
+	signer, err := crypto.Unlock(ctx, req.Key.EncryptedKey, req.Key.Address, req.Passphrase, salt, crypto.KeyVersion(req.Key.KeyVersion), params)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	addr := signer.Address()
+	expires := time.Now().Add(ttl)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.keys[addr]; ok {
+		old.timer.Stop()
+		old.signer.Lock()
+	}
+	e := &entry{signer: signer, expires: expires}
+	e.timer = time.AfterFunc(ttl, func() { s.expire(addr, e) })
+	s.keys[addr] = e
+	return expires, nil
+}
+
+// ttl returns the TTL requested in seconds, or the default TTL for zero. It is checked against the
+// maximum before it is converted, so that it cannot overflow.
+func (s *Server) ttl(seconds int64) (time.Duration, error) {
+	switch {
+	case seconds == 0:
+		return s.defaultTTL, nil
+	case seconds < 0:
+		return 0, fmt.Errorf("invalid TTL: %ds", seconds)
+	case seconds > int64(s.maxTTL/time.Second):
+		return 0, fmt.Errorf("TTL of %ds exceeds the agent's maximum of %s", seconds, s.maxTTL)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// expire locks e if it is still the entry held for addr.
+func (s *Server) expire(addr common.Address, e *entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys[addr] == e {
+		delete(s.keys, addr)
+	}
+	e.signer.Lock()
+}
+
+func (s *Server) remove(address string) error {
+	if !common.IsHexAddress(address) {
+		return fmt.Errorf("invalid address: %s", address)
+	}
+	addr := common.HexToAddress(address)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.keys[addr]
+	if !ok {
+		return fmt.Errorf("key not held by agent: %s", addr.Hex())
+	}
+	e.timer.Stop()
+	e.signer.Lock()
+	delete(s.keys, addr)
+	return nil
+}
+
+func (s *Server) list() []KeyInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]KeyInfo, 0, len(s.keys))
+	for addr, e := range s.keys {
+		keys = append(keys, KeyInfo{Address: addr.Hex(), Expires: e.expires})
+	}
+	return keys
+}
+
+// signer returns the unlocked signer for address.
+func (s *Server) signer(address string) (*crypto.LocalSigner, error) {
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("invalid address: %s", address)
+	}
+	addr := common.HexToAddress(address)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.keys[addr]
+	if !ok {
+		return nil, fmt.Errorf("key not held by agent: %s", addr.Hex())
+	}
+	return e.signer, nil
+}
+
+func (s *Server) signTx(ctx context.Context, req *request) ([]byte, error) {
+	signer, err := s.signer(req.Address)
+	if err != nil {
+		return nil, err
+	}
+	chainID, ok := new(big.Int).SetString(req.ChainID, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid chain ID: %q", req.ChainID)
+	}
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(req.Payload); err != nil {
+		return nil, fmt.Errorf("invalid transaction: %v", err)
+	}
+	_, raw, err := signer.SignTx(ctx, &tx, chainID)
+	return raw, err
+}
+
+func (s *Server) signMessage(ctx context.Context, req *request) ([]byte, error) {
+	signer, err := s.signer(req.Address)
+	if err != nil {
+		return nil, err
+	}
+	if req.Op == opSignTypedData {
+		return signer.SignTypedData(ctx, req.Payload)
+	}
+	return signer.SignPersonalMessage(ctx, req.Payload)
+}
+
+// zeroBytes overwrites sensitive data in place.
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
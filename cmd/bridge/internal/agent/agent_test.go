@@ -0,0 +1,174 @@
+package agent
+
+import (
+	"context"
+	"encoding/hex"
+	"math"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/xilverfang/syncora/internal/core/crypto"
+)
+
+const (
+	testPrivateKeyHex = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+	testAddress       = "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+)
+
+func startAgent(t *testing.T) *Client {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "syncora", "agent.sock")
+	l, err := listen(path)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewServer(time.Minute, time.Hour).Serve(ctx, l)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	client, err := NewClient(path)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+func storedTestKey(t *testing.T, passphrase []byte) StoredKey {
+	t.Helper()
+	encKey, address, salt, err := crypto.EncryptPrivateKey(context.Background(), testPrivateKeyHex, passphrase, crypto.CurrentKeyVersion, crypto.KDFTest)
+	if err != nil {
+		t.Fatalf("EncryptPrivateKey: %v", err)
+	}
+	return StoredKey{
+		Address:      address,
+		EncryptedKey: encKey,
+		Salt:         hex.EncodeToString(salt),
+		KeyVersion:   uint8(crypto.CurrentKeyVersion),
+		KDFTime:      crypto.KDFTest.Time,
+		KDFMemory:    crypto.KDFTest.Memory,
+		KDFThreads:   crypto.KDFTest.Threads,
+	}
+}
+
+func TestAgentSign(t *testing.T) {
+	ctx := context.Background()
+	client := startAgent(t)
+	key := storedTestKey(t, []byte("correct horse"))
+
+	if _, err := client.Signer(ctx, testAddress); err == nil {
+		t.Fatal("expected error for key not held by agent")
+	}
+	if _, err := client.Add(ctx, key, []byte("wrong passphrase"), 0); err == nil {
+		t.Fatal("expected error for wrong passphrase")
+	}
+	if _, err := client.Add(ctx, key, []byte("correct horse"), 0); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	signer, err := client.Signer(ctx, testAddress)
+	if err != nil {
+		t.Fatalf("Signer: %v", err)
+	}
+
+	msg := []byte("hello")
+	sig, err := signer.SignPersonalMessage(ctx, msg)
+	if err != nil {
+		t.Fatalf("SignPersonalMessage: %v", err)
+	}
+	recovered, err := crypto.RecoverAddress(crypto.PersonalMessageHash(msg), sig)
+	if err != nil || recovered != common.HexToAddress(testAddress) {
+		t.Fatalf("recovered %s, %v", recovered.Hex(), err)
+	}
+
+	chainID := big.NewInt(1)
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     1,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(2),
+		Gas:       21000,
+		To:        &common.Address{},
+		Value:     big.NewInt(1),
+	})
+	signed, _, err := signer.SignTx(ctx, tx, chainID)
+	if err != nil {
+		t.Fatalf("SignTx: %v", err)
+	}
+	if signed.Hash() == tx.Hash() {
+		t.Fatal("transaction was not signed")
+	}
+
+	if err := client.Remove(ctx, testAddress); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := signer.SignPersonalMessage(ctx, msg); err == nil {
+		t.Fatal("expected error after key was removed")
+	}
+}
+
+func TestAgentTTL(t *testing.T) {
+	ctx := context.Background()
+	client := startAgent(t)
+
+	if _, err := client.Add(ctx, storedTestKey(t, []byte("correct horse")), []byte("correct horse"), time.Second); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	keys, err := client.List(ctx)
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("List: %v, %v", keys, err)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+	keys, err = client.List(ctx)
+	if err != nil || len(keys) != 0 {
+		t.Fatalf("expected key to expire, got %v, %v", keys, err)
+	}
+}
+
+func TestAgentMaxTTL(t *testing.T) {
+	ctx := context.Background()
+	client := startAgent(t)
+	key := storedTestKey(t, []byte("correct horse"))
+
+	expires, err := client.Add(ctx, key, []byte("correct horse"), time.Hour)
+	if err != nil {
+		t.Fatalf("Add for the maximum TTL: %v", err)
+	}
+	if d := time.Until(expires); d <= 59*time.Minute || d > time.Hour {
+		t.Fatalf("key expires in %s, want an hour", d)
+	}
+
+	for _, seconds := range []int64{3601, -1, math.MaxInt64, math.MaxInt64/int64(time.Second) + 1} {
+		_, err := client.call(ctx, &request{Op: opAdd, Key: &key, Passphrase: []byte("correct horse"), TTLSeconds: seconds})
+		if err == nil || !strings.Contains(err.Error(), "TTL") {
+			t.Errorf("add with a TTL of %ds: %v, want a TTL error", seconds, err)
+		}
+	}
+}
+
+func TestNewServerTTLs(t *testing.T) {
+	for _, tt := range []struct {
+		defaultTTL, maxTTL, wantDefault, wantMax time.Duration
+	}{
+		{0, 0, DefaultTTL, MaxTTL},
+		{time.Minute, 48 * time.Hour, time.Minute, MaxTTL},
+		{time.Hour, time.Minute, time.Minute, time.Minute},
+	} {
+		s := NewServer(tt.defaultTTL, tt.maxTTL)
+		if s.defaultTTL != tt.wantDefault || s.maxTTL != tt.wantMax {
+			t.Errorf("NewServer(%s, %s) has TTLs %s, %s, want %s, %s", tt.defaultTTL, tt.maxTTL, s.defaultTTL, s.maxTTL, tt.wantDefault, tt.wantMax)
+		}
+	}
+}
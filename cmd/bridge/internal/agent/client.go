@@ -0,0 +1,200 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/xilverfang/syncora/internal/core/crypto"
+)
+
+// Client talks to a running agent.
+type Client struct {
+	path string
+}
+
+// NewClient returns a client for the agent listening at path after checking that the socket is
+// owned by the current user and not accessible to others.
+func NewClient(path string) (*Client, error) {
+	if err := checkSocket(path); err != nil {
+		return nil, err
+	}
+	return &Client{path: path}, nil
+}
+
+// FromEnv returns a client for the socket named by SYNCORA_AGENT_SOCK, or nil if it is unset.
+func FromEnv() (*Client, error) {
+	path := os.Getenv(SockEnv)
+	if path == "" {
+		return nil, nil
+	}
+	return NewClient(path)
+}
+
+// call sends a single request and decodes the reply.
+func (c *Client) call(ctx context.Context, req *request) (*response, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", c.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to agent: %v", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(requestTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	_, err = conn.Write(append(body, '\n'))
+	zeroBytes(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to agent: %v", err)
+	}
+
+	var resp response
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read agent response: %v", err)
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
+
+// Add asks the agent to decrypt key with passphrase and hold it for ttl, or the agent's default
+// TTL if ttl is zero. It returns when the key will be locked again.
+func (c *Client) Add(ctx context.Context, key StoredKey, passphrase []byte, ttl time.Duration) (time.Time, error) {
+	resp, err := c.call(ctx, &request{Op: opAdd, Key: &key, Passphrase: passphrase, TTLSeconds: int64(ttl / time.Second)})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if resp.Expires == nil {
+		return time.Time{}, errors.New("agent did not report an expiry")
+	}
+	return *resp.Expires, nil
+}
+
+// Remove locks and forgets the key for address.
+func (c *Client) Remove(ctx context.Context, address string) error {
+	_, err := c.call(ctx, &request{Op: opRemove, Address: address})
+	return err
+}
+
+// List returns the keys currently unlocked in the agent.
+func (c *Client) List(ctx context.Context) ([]KeyInfo, error) {
+	resp, err := c.call(ctx, &request{Op: opList})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Keys, nil
+}
+
+// LockAll locks every key held by the agent.
+func (c *Client) LockAll(ctx context.Context) error {
+	_, err := c.call(ctx, &request{Op: opLock})
+	return err
+}
+
+// Signer returns a crypto.Signer that signs through the agent, or an error if the agent does not
+// currently hold the key for address.
+func (c *Client) Signer(ctx context.Context, address string) (crypto.Signer, error) {
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("invalid address: %s", address)
+	}
+	addr := common.HexToAddress(address)
+
+	keys, err := c.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		if common.HexToAddress(k.Address) == addr {
+			return &remoteSigner{client: c, address: addr}, nil
+		}
+	}
+	return nil, fmt.Errorf("key not held by agent: %s", addr.Hex())
+}
+
+// remoteSigner is a crypto.Signer backed by a key held in the agent.
+type remoteSigner struct {
+	client  *Client
+	address common.Address
+}
+
+var _ crypto.Signer = (*remoteSigner)(nil)
+
+func (r *remoteSigner) Address() common.Address {
+	return r.address
+}
+
+func (r *remoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, []byte, error) {
+	if chainID == nil {
+		return nil, nil, errors.New("a positive chain ID is required for replay protection")
+	}
+	payload, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode transaction: %v", err)
+	}
+	resp, err := r.client.call(ctx, &request{Op: opSignTx, Address: r.address.Hex(), Payload: payload, ChainID: chainID.String()})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var signed types.Transaction
+	if err := signed.UnmarshalBinary(resp.RawTx); err != nil {
+		return nil, nil, fmt.Errorf("agent returned an invalid transaction: %v", err)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), &signed)
+	if err != nil || sender != r.address {
+		return nil, nil, errors.New("agent returned a transaction signed by the wrong account")
+	}
+	return &signed, resp.RawTx, nil
+}
+
+func (r *remoteSigner) SignPersonalMessage(ctx context.Context, msg []byte) ([]byte, error) {
+	resp, err := r.client.call(ctx, &request{Op: opSignMessage, Address: r.address.Hex(), Payload: msg})
+	if err != nil {
+		return nil, err
+	}
+	return r.verify(crypto.PersonalMessageHash(msg), resp.Signature)
+}
+
+func (r *remoteSigner) SignTypedData(ctx context.Context, typedDataJSON []byte) ([]byte, error) {
+	hash, err := crypto.TypedDataHash(typedDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.call(ctx, &request{Op: opSignTypedData, Address: r.address.Hex(), Payload: typedDataJSON})
+	if err != nil {
+		return nil, err
+	}
+	return r.verify(hash, resp.Signature)
+}
+
+// verify checks that a signature returned by the agent was made by the expected account.
+func (r *remoteSigner) verify(hash, sig []byte) ([]byte, error) {
+	signer, err := crypto.RecoverAddress(hash, sig)
+	if err != nil {
+		return nil, fmt.Errorf("agent returned an invalid signature: %v", err)
+	}
+	if signer != r.address {
+		return nil, errors.New("agent returned a signature from the wrong account")
+	}
+	return sig, nil
+}
+
+// Lock is a no-op; keys held by the agent are locked by their TTL or with Client.Remove.
+func (r *remoteSigner) Lock() {}
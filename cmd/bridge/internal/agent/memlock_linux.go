@@ -0,0 +1,21 @@
+//go:build linux
+
+package agent
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// protectMemory locks the process's memory so decrypted keys are never swapped to disk, and marks
+// the process non-dumpable so keys cannot be read through core dumps or ptrace by other processes.
+func protectMemory() error {
+	if err := unix.Prctl(unix.PR_SET_DUMPABLE, 0, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to disable core dumps: %v", err)
+	}
+	if err := unix.Mlockall(unix.MCL_CURRENT | unix.MCL_FUTURE); err != nil {
+		return fmt.Errorf("failed to lock memory (raise RLIMIT_MEMLOCK): %v", err)
+	}
+	return nil
+}
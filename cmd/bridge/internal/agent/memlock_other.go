@@ -0,0 +1,10 @@
+//go:build !linux
+
+package agent
+
+import "fmt"
+
+// protectMemory is unsupported on this platform; keys may be swapped to disk.
+func protectMemory() error {
+	return fmt.Errorf("memory locking is not supported on this platform")
+}
@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"time"
+)
+
+// SockEnv names the environment variable holding the agent socket path.
+const SockEnv = "SYNCORA_AGENT_SOCK"
+
+// Operations understood by the agent. Only add, remove, list, and lock touch key material;
+// everything else signs with a key that is already unlocked.
+const (
+	opAdd           = "add"
+	opRemove        = "remove"
+	opList          = "list"
+	opLock          = "lock"
+	opSignTx        = "sign_tx"
+	opSignMessage   = "sign_message"
+	opSignTypedData = "sign_typed_data"
+)
+
+// maxRequestSize bounds a single request read from a client.
+const maxRequestSize = 2 * 1024 * 1024
+
+// request is a single newline-terminated JSON request. Each connection carries one request.
+type request struct {
+	Op      string `json:"op"`
+	Address string `json:"address,omitempty"`
+
+	// add
+	Key        *StoredKey `json:"key,omitempty"`
+	Passphrase []byte     `json:"passphrase,omitempty"`
+	TTLSeconds int64      `json:"ttl_seconds,omitempty"`
+
+	// sign_*
+	Payload []byte `json:"payload,omitempty"`
+	ChainID string `json:"chain_id,omitempty"`
+}
+
+// response is the agent's reply to a request.
+type response struct {
+	Error     string     `json:"error,omitempty"`
+	Signature []byte     `json:"signature,omitempty"`
+	RawTx     []byte     `json:"raw_tx,omitempty"`
+	Keys      []KeyInfo  `json:"keys,omitempty"`
+	Expires   *time.Time `json:"expires,omitempty"`
+}
+
+// StoredKey is an encrypted account key as stored in the database, which the agent decrypts itself
+// so the plaintext key never crosses the socket.
+type StoredKey struct {
+	Address      string `json:"address"`
+	EncryptedKey string `json:"encrypted_key"`
+	Salt         string `json:"salt"`
+	KeyVersion   uint8  `json:"key_version"`
+	KDFTime      uint32 `json:"kdf_time"`
+	KDFMemory    uint32 `json:"kdf_memory"`
+	KDFThreads   uint8  `json:"kdf_threads"`
+}
+
+// KeyInfo describes an unlocked key held by the agent.
+type KeyInfo struct {
+	Address string    `json:"address"`
+	Expires time.Time `json:"expires"`
+}
@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// DefaultSocketPath returns the per-user socket path, preferring $XDG_RUNTIME_DIR.
+func DefaultSocketPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), fmt.Sprintf("syncora-%d", os.Getuid()))
+	} else {
+		dir = filepath.Join(dir, "syncora")
+	}
+	return filepath.Join(dir, "agent.sock")
+}
+
+// listen creates the socket's parent directory with 0700 permissions, removes a stale socket, and
+// listens on path with 0600 permissions.
+func listen(path string) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %v", err)
+	}
+	if err := checkOwnedPrivate(dir, 0077); err != nil {
+		return nil, err
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("refusing to replace non-socket file: %s", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("an agent is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %v", err)
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %v", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %v", err)
+	}
+	return l, nil
+}
+
+// checkSocket verifies that path is a socket owned by the current user and not accessible to others.
+func checkSocket(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fmt.Errorf("agent socket unavailable: %v", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("agent path is not a socket: %s", path)
+	}
+	return checkOwnedPrivate(path, 0077)
+}
+
+// checkOwnedPrivate fails unless path is owned by the current user and has none of the forbidden permission bits.
+func checkOwnedPrivate(path string, forbidden os.FileMode) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %v", path, err)
+	}
+	if uid, ok := fileOwner(info); ok && uid != os.Getuid() {
+		return fmt.Errorf("%s is owned by uid %d, expected %d", path, uid, os.Getuid())
+	}
+	if info.Mode().Perm()&forbidden != 0 {
+		return fmt.Errorf("%s permissions too open: %s", path, info.Mode().Perm())
+	}
+	return nil
+}
@@ -1,160 +1,694 @@
 package commands
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/xilverfang/syncora/internal/core/crypto"
 	"github.com/xilverfang/syncora/internal/core/database"
 
 	"github.com/spf13/cobra"
-	"golang.org/x/term"
 )
 
-func AccountCmd() *cobra.Command {
+func AccountCmd(app *App) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "account",
 		Short: "Manage user accounts for signing bridge transactions",
 		Long:  `Commands to import, list, and remove accounts, storing private keys securely for signing bridge transactions.`,
 	}
 
-	cmd.AddCommand(accountImportCmd())
-	cmd.AddCommand(accountListCmd())
-	cmd.AddCommand(accountRemoveCmd())
+	cmd.AddCommand(accountImportCmd(app))
+	cmd.AddCommand(accountNewCmd(app))
+	cmd.AddCommand(accountListCmd(app))
+	cmd.AddCommand(accountRemoveCmd(app))
+	cmd.AddCommand(accountCheckCmd(app))
+	cmd.AddCommand(accountRenameCmd(app))
+	cmd.AddCommand(accountTagCmd(app))
+	cmd.AddCommand(accountNoteCmd(app))
+	cmd.AddCommand(accountPasswdCmd(app))
+	cmd.AddCommand(accountExportCmd(app))
+	cmd.AddCommand(accountSignMessageCmd(app))
+	cmd.AddCommand(accountVerifyMessageCmd(app))
 
 	return cmd
 }
 
-func accountImportCmd() *cobra.Command {
-	var alias string
+func accountImportCmd(app *App) *cobra.Command {
+	var alias, kdfProfile, keystorePath, privateKeyFile string
+	var mnemonic bool
+	var index, count uint32
 	cmd := &cobra.Command{
-		Use:   "import [--alias <name>]",
-		Short: "Import a private key to create or update an account",
+		Use:   "import [--alias <name>] [--private-key-file <file> | --keystore <file> | --mnemonic [--index <n>] [--count <n>]]",
+		Short: "Import a private key, V3 keystore file, or BIP-39 mnemonic to create or update accounts",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Fprintln(os.Stderr, "Starting import process")
-			fmt.Fprint(os.Stdout, "Enter private key (input hidden): ")
-			privateKeyBytes, err := term.ReadPassword(int(syscall.Stdin))
-			fmt.Fprintln(os.Stdout)
+			kdfParams, err := crypto.KDFProfile(app.kdfProfile(kdfProfile))
 			if err != nil {
-				return fmt.Errorf("failed to read private key: %v", err)
+				return err
 			}
-			privateKey := strings.TrimSpace(string(privateKeyBytes))
 
-			fmt.Fprint(os.Stdout, "Enter passphrase for encryption (input hidden): ")
-			passphrase, err := term.ReadPassword(int(syscall.Stdin))
-			fmt.Fprintln(os.Stdout)
+			if err := checkAliasFlag(alias); err != nil {
+				return err
+			}
+			store, err := app.Store(cmd.Context())
 			if err != nil {
-				return fmt.Errorf("failed to read passphrase: %v", err)
+				return err
+			}
+			if keystorePath != "" {
+				acc, err := importKeystore(cmd.Context(), app, store, keystorePath, alias, kdfParams)
+				if err != nil {
+					return err
+				}
+				return app.renderAccount(acc, "Account imported")
 			}
-			if len(passphrase) < 8 {
-				return fmt.Errorf("passphrase too short, minimum 8 characters")
+			if mnemonic {
+				accounts, err := importMnemonic(cmd.Context(), app, store, alias, index, count, kdfParams)
+				if err != nil {
+					return err
+				}
+				return app.renderAccounts(accounts, "Account imported")
 			}
 
-			fmt.Fprint(os.Stdout, "Confirm passphrase: ")
-			passphraseConfirm, err := term.ReadPassword(int(syscall.Stdin))
-			fmt.Fprintln(os.Stdout)
+			slog.Debug("starting private key import")
+			var privateKeyBytes []byte
+			if privateKeyFile != "" {
+				privateKeyBytes, err = readSecretFile(privateKeyFile)
+			} else {
+				privateKeyBytes, err = readPassword("Enter private key (input hidden): ")
+			}
+			defer zeroBytes(privateKeyBytes)
 			if err != nil {
-				return fmt.Errorf("failed to read passphrase confirmation: %v", err)
+				return fmt.Errorf("failed to read private key: %v", err)
 			}
-			if string(passphrase) != string(passphraseConfirm) {
-				return fmt.Errorf("passphrases do not match")
+
+			passphrase, err := app.newPassphrase(cmd.Context(), "Enter passphrase for encryption (input hidden): ")
+			if err != nil {
+				return err
 			}
+			defer zeroBytes(passphrase)
 
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Second)
 			defer cancel()
-			fmt.Fprintln(os.Stderr, "Encrypting private key")
-			encryptedKey, address, salt, err := crypto.EncryptPrivateKey(ctx, privateKey, passphrase, crypto.KeyVersion1)
+			slog.Debug("encrypting private key")
+			encryptedKey, address, salt, err := crypto.EncryptPrivateKeyBytes(ctx, bytes.TrimSpace(privateKeyBytes), passphrase, crypto.CurrentKeyVersion, kdfParams)
 			if err != nil {
 				return fmt.Errorf("failed to encrypt private key: %v", err)
 			}
-			fmt.Fprintln(os.Stderr, "Private key encrypted, address:", address[:10]+"...")
 
-			if alias == "" {
-				alias = address
+			acc, err := saveAccount(cmd.Context(), store, alias, address, encryptedKey, salt, kdfParams)
+			if err != nil {
+				return err
 			}
+			return app.renderAccount(acc, "Account imported")
+		},
+	}
+
+	cmd.Flags().StringVarP(&alias, "alias", "a", "", "Optional alias for the account")
+	cmd.Flags().StringVar(&kdfProfile, "kdf", "", "Key derivation profile: interactive, sensitive or test (default: from the profile, else interactive)")
+	cmd.Flags().StringVar(&keystorePath, "keystore", "", "Import from an Ethereum V3 keystore JSON file (scrypt or pbkdf2)")
+	cmd.Flags().BoolVar(&mnemonic, "mnemonic", false, "Import a BIP-39 mnemonic and derive accounts at "+crypto.HDBasePath+"/i")
+	cmd.Flags().Uint32Var(&index, "index", 0, "First address index to derive with --mnemonic")
+	cmd.Flags().Uint32Var(&count, "count", 1, "Number of accounts to derive with --mnemonic")
+	cmd.Flags().StringVar(&privateKeyFile, "private-key-file", "", "Read the hex private key from a file instead of the terminal (mode 0600)")
+	cmd.MarkFlagsMutuallyExclusive("private-key-file", "keystore", "mnemonic")
+	return cmd
+}
+
+// importMnemonic derives accounts from a BIP-39 mnemonic and stores the seed once alongside the
+// derived accounts, each linked to it by fingerprint and derivation path. It returns the stored
+// accounts.
+func importMnemonic(ctx context.Context, app *App, store database.AccountStore, alias string, index, count uint32, kdfParams crypto.KDFParams) ([]database.Account, error) {
+	if count == 0 || count > crypto.MaxHDAccounts {
+		return nil, fmt.Errorf("invalid --count: %d (expected 1-%d)", count, crypto.MaxHDAccounts)
+	}
+
+	slog.Debug("starting mnemonic import")
+	mnemonic, err := readPassword("Enter mnemonic (input hidden): ")
+	defer zeroBytes(mnemonic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mnemonic: %v", err)
+	}
+
+	bip39Passphrase, err := readPassword("Enter BIP-39 passphrase, if any (input hidden): ")
+	defer zeroBytes(bip39Passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read BIP-39 passphrase: %v", err)
+	}
+
+	passphrase, err := app.newPassphrase(ctx, "Enter passphrase for encryption (input hidden): ")
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(passphrase)
+
+	return saveMnemonicAccounts(ctx, store, mnemonic, bip39Passphrase, passphrase, alias, index, count, kdfParams)
+}
 
-			fmt.Fprintln(os.Stderr, "Saving account")
-			if err := database.SaveAccount(alias, address, encryptedKey, hex.EncodeToString(salt), uint8(crypto.KeyVersion1)); err != nil {
-				return fmt.Errorf("failed to save account: %v", err)
+// saveMnemonicAccounts derives count accounts from a mnemonic starting at index and stores them
+// with the encrypted seed. With an alias and more than one account, each alias gets an index suffix.
+func saveMnemonicAccounts(ctx context.Context, store database.AccountStore, mnemonic, bip39Passphrase, passphrase []byte, alias string, index, count uint32, kdfParams crypto.KDFParams) ([]database.Account, error) {
+	// One Argon2id derivation for the seed plus one per account.
+	deriveCtx, cancel := context.WithTimeout(ctx, time.Duration(count+1)*5*time.Second)
+	defer cancel()
+	slog.Debug("deriving accounts", "count", count)
+	seed, derived, err := crypto.ImportMnemonic(deriveCtx, mnemonic, bip39Passphrase, passphrase, index, count, crypto.CurrentKeyVersion, kdfParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import mnemonic: %v", err)
+	}
+
+	accounts := make([]database.Account, 0, len(derived))
+	for i, d := range derived {
+		accAlias := database.DefaultAlias(d.Address)
+		if alias != "" {
+			accAlias = alias
+			if count > 1 {
+				accAlias = fmt.Sprintf("%s-%d", alias, index+uint32(i))
 			}
-			fmt.Fprintln(os.Stderr, "Account saved")
+		}
+		acc := newAccount(accAlias, d.Address, d.EncryptedKey, d.Salt, kdfParams)
+		acc.SeedFingerprint = seed.Fingerprint
+		acc.DerivationPath = d.Path
+		accounts = append(accounts, acc)
+	}
+
+	slog.Debug("saving seed and accounts")
+	err = store.SaveSeedAccounts(ctx, database.Seed{
+		Fingerprint:   seed.Fingerprint,
+		EncryptedSeed: seed.EncryptedKey,
+		Salt:          hex.EncodeToString(seed.Salt),
+		KeyVersion:    uint8(crypto.CurrentKeyVersion),
+		KDFTime:       kdfParams.Time,
+		KDFMemory:     kdfParams.Memory,
+		KDFThreads:    kdfParams.Threads,
+	}, accounts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save accounts: %w", err)
+	}
+	return reloadAccounts(ctx, store, accounts)
+}
+
+// saveAccount stores a newly encrypted key, defaulting the alias to database.DefaultAlias, and
+// returns the stored account.
+func saveAccount(ctx context.Context, store database.AccountStore, alias, address, encryptedKey string, salt []byte, kdfParams crypto.KDFParams) (*database.Account, error) {
+	if alias == "" {
+		alias = database.DefaultAlias(address)
+	}
+
+	acc := newAccount(alias, address, encryptedKey, salt, kdfParams)
+	if err := store.SaveAccount(ctx, acc); err != nil {
+		return nil, fmt.Errorf("failed to save account: %w", err)
+	}
+	accounts, err := reloadAccounts(ctx, store, []database.Account{acc})
+	if err != nil {
+		return nil, err
+	}
+	return &accounts[0], nil
+}
+
+// reloadAccounts reads saved accounts back from the store, so that results include the creation
+// time and any metadata kept from an earlier import.
+func reloadAccounts(ctx context.Context, store database.AccountStore, saved []database.Account) ([]database.Account, error) {
+	accounts := make([]database.Account, 0, len(saved))
+	for _, acc := range saved {
+		stored, err := store.GetAccount(ctx, acc.Address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read saved account: %w", err)
+		}
+		accounts = append(accounts, *stored)
+	}
+	return accounts, nil
+}
+
+// checkAliasFlag validates an --alias value before any passphrase is requested. An empty alias
+// selects the default.
+func checkAliasFlag(alias string) error {
+	if alias == "" {
+		return nil
+	}
+	return database.ValidateAlias(alias)
+}
+
+// importKeystore decrypts a V3 keystore file and stores its key re-encrypted under a new passphrase.
+// It returns the stored account.
+func importKeystore(ctx context.Context, app *App, store database.AccountStore, path, alias string, kdfParams crypto.KDFParams) (*database.Account, error) {
+	keyJSON, err := readLimitedFile(path, maxKeystoreSize)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("starting keystore import")
+	keystorePassphrase, err := readPassword("Enter keystore password (input hidden): ")
+	defer zeroBytes(keystorePassphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore password: %v", err)
+	}
+
+	passphrase, err := app.newPassphrase(ctx, "Enter passphrase for encryption (input hidden): ")
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(passphrase)
 
-			// Zero sensitive data
-			for i := range privateKeyBytes {
-				privateKeyBytes[i] = 0
+	// Standard scrypt keystores take a few seconds on their own, on top of Argon2id.
+	decryptCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	slog.Debug("decrypting keystore")
+	encryptedKey, address, salt, err := crypto.ImportKeystore(decryptCtx, keyJSON, keystorePassphrase, passphrase, crypto.CurrentKeyVersion, kdfParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import keystore: %v", err)
+	}
+
+	return saveAccount(ctx, store, alias, address, encryptedKey, salt, kdfParams)
+}
+
+// maxKeystoreSize bounds how much of a keystore file is read; real V3 files are under 1 KiB.
+const maxKeystoreSize = 64 * 1024
+
+func accountListCmd(app *App) *cobra.Command {
+	var tags []string
+	var order string
+	cmd := &cobra.Command{
+		Use:   "list [--tag <tag>]... [--sort alias|created|last-used]",
+		Short: "List all imported accounts",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter, err := database.NormalizeTags(tags)
+			if err != nil {
+				return err
+			}
+			store, err := app.Store(cmd.Context())
+			if err != nil {
+				return err
 			}
-			for i := range passphrase {
-				passphrase[i] = 0
+			accounts, err := store.ListAccounts(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to list accounts: %w", err)
 			}
-			for i := range passphraseConfirm {
-				passphraseConfirm[i] = 0
+			accounts = filterAccounts(accounts, filter)
+			if err := sortAccounts(accounts, order); err != nil {
+				return err
 			}
 
-			fmt.Fprintf(os.Stdout, "Account imported: alias=%s, address=%s\n", alias, address)
-			return nil
+			views := make([]accountView, 0, len(accounts))
+			for i := range accounts {
+				views = append(views, newAccountView(&accounts[i]))
+			}
+			return app.render(views, func(w io.Writer) {
+				if len(views) == 0 {
+					fmt.Fprintln(w, "No accounts found.")
+					return
+				}
+				fmt.Fprintln(w, "Alias\tAddress\tKey Version\tDerivation Path\tTags\tCreated\tLast Used\tNote")
+				for _, v := range views {
+					path := v.DerivationPath
+					if path == "" {
+						path = "-"
+					}
+					note := v.Note
+					if note == "" {
+						note = "-"
+					}
+					fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n", v.Alias, v.Address, v.KeyVersion, path,
+						formatTags(v.Tags), formatTime(v.CreatedAt, "-"), formatTime(v.LastUsedAt, "never"), note)
+				}
+			})
 		},
 	}
 
-	cmd.Flags().StringVarP(&alias, "alias", "a", "", "Optional alias for the account")
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "Only list accounts with this tag (repeatable; all must match)")
+	cmd.Flags().StringVar(&order, "sort", sortAlias, "Sort by alias, created or last-used (most recent first)")
 	return cmd
 }
 
-func accountListCmd() *cobra.Command {
+func accountRemoveCmd(app *App) *cobra.Command {
+	var account string
+	var yes bool
 	cmd := &cobra.Command{
-		Use:   "list",
-		Short: "List all imported accounts",
+		Use:   "remove --account <alias-or-address> [--yes]",
+		Short: "Remove an account by alias or address",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			accounts, err := database.ListAccounts()
+			store, err := app.Store(cmd.Context())
 			if err != nil {
-				return fmt.Errorf("failed to list accounts: %v", err)
+				return err
+			}
+			acc, err := store.GetAccount(cmd.Context(), account)
+			if err != nil {
+				return fmt.Errorf("failed to get account: %w", err)
 			}
 
-			if len(accounts) == 0 {
-				fmt.Println("No accounts found.")
-				return nil
+			if !yes {
+				fmt.Fprintf(os.Stderr, "Are you sure you want to remove account %s (%s)? (y/N): ", acc.Alias, acc.Address)
+				var response string
+				fmt.Scanln(&response)
+				if strings.ToLower(response) != "y" {
+					return fmt.Errorf("account removal cancelled")
+				}
 			}
 
-			fmt.Println("Alias\tAddress\tKey Version")
-			fmt.Println("-----\t-------\t-----------")
-			for _, acc := range accounts {
-				fmt.Printf("%s\t%s\t%d\n", acc.Alias, acc.Address, acc.KeyVersion)
+			if err := store.RemoveAccount(cmd.Context(), acc.Address); err != nil {
+				return fmt.Errorf("failed to remove account: %w", err)
 			}
-			return nil
+			return app.renderAccount(acc, "Account removed")
 		},
 	}
+
+	cmd.Flags().StringVarP(&account, "account", "a", "", "Alias or address of the account to remove (required)")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Remove without asking for confirmation")
+	cmd.MarkFlagRequired("account")
 	return cmd
 }
 
-func accountRemoveCmd() *cobra.Command {
+func accountRenameCmd(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rename <alias-or-address> <new-alias>",
+		Short: "Change the alias of an account",
+		Long: `Changes an account's alias without touching its key. Aliases are unique ignoring case and
+must not look like an address.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := database.ValidateAlias(args[1]); err != nil {
+				return err
+			}
+			store, err := app.Store(cmd.Context())
+			if err != nil {
+				return err
+			}
+			acc, err := store.GetAccount(cmd.Context(), args[0])
+			if err != nil {
+				return fmt.Errorf("failed to get account: %w", err)
+			}
+			if err := store.RenameAccount(cmd.Context(), acc.Address, args[1]); err != nil {
+				return fmt.Errorf("failed to rename account: %w", err)
+			}
+			result := struct {
+				accountView
+				PreviousAlias string `json:"previous_alias"`
+			}{newAccountView(acc), acc.Alias}
+			result.Alias = args[1]
+			return app.render(result, func(w io.Writer) {
+				fmt.Fprintf(w, "Account renamed: alias=%s, address=%s (was %s)\n", result.Alias, result.Address, result.PreviousAlias)
+			})
+		},
+	}
+}
+
+func accountCheckCmd(app *App) *cobra.Command {
 	var account string
 	cmd := &cobra.Command{
-		Use:   "remove --account <alias-or-address>",
-		Short: "Remove an account by alias or address",
+		Use:   "check --account <alias-or-address>",
+		Short: "Decrypt an account's private key to check its passphrase and details",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Fprintf(os.Stdout, "Are you sure you want to remove account %s? (y/N): ", account)
-			var response string
-			fmt.Scanln(&response)
-			if strings.ToLower(response) != "y" {
-				return fmt.Errorf("account removal cancelled")
+			identifier, err := app.account(account)
+			if err != nil {
+				return err
+			}
+			profile, err := crypto.KDFProfile(app.kdfProfile(""))
+			if err != nil {
+				return err
+			}
+			store, err := app.Store(cmd.Context())
+			if err != nil {
+				return err
+			}
+			acc, err := store.GetAccount(cmd.Context(), identifier)
+			if err != nil {
+				return fmt.Errorf("failed to get account: %w", err)
 			}
 
-			if err := database.RemoveAccount(account); err != nil {
-				return fmt.Errorf("failed to remove account: %v", err)
+			passphrase, err := app.passphrase(cmd.Context(), "Enter passphrase to decrypt private key (input hidden): ")
+			if err != nil {
+				return err
+			}
+			defer zeroBytes(passphrase)
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Second)
+			defer cancel()
+			slog.Debug("decrypting private key")
+			signer, err := unlockAccount(ctx, store, acc, passphrase, profile)
+			if err != nil {
+				return err
 			}
-			fmt.Fprintf(os.Stdout, "Account removed: %s\n", account)
-			return nil
+			signer.Lock()
+
+			return app.render(newAccountView(acc), func(w io.Writer) {
+				fmt.Fprintf(w, "Account details: alias=%s, address=%s, key_version=%d\n", acc.Alias, acc.Address, acc.KeyVersion)
+			})
 		},
 	}
 
-	cmd.Flags().StringVarP(&account, "account", "a", "", "Alias or address of the account to remove (required)")
-	cmd.MarkFlagRequired("account")
+	cmd.Flags().StringVarP(&account, "account", "a", "", "Alias or address of the account to check (default: the profile's account)")
+	return cmd
+}
+
+func accountPasswdCmd(app *App) *cobra.Command {
+	var account, kdfProfile, newFile string
+	cmd := &cobra.Command{
+		Use:   "passwd --account <alias-or-address> [--new-passphrase-file <path>]",
+		Short: "Change the passphrase protecting an account's private key",
+		Long: `Decrypts the account's private key with the current passphrase and re-encrypts it
+under a new passphrase and salt. The private key is never displayed.
+
+The current passphrase may come from --passphrase-file, --passphrase-fd or
+SYNCORA_PASSPHRASE_CMD; the new one is then read from --new-passphrase-file.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			identifier, err := app.account(account)
+			if err != nil {
+				return err
+			}
+			if newFile == "" && app.secrets.configured() {
+				return usageError{errors.New("--new-passphrase-file is required when the current passphrase is not prompted for")}
+			}
+			store, err := app.Store(cmd.Context())
+			if err != nil {
+				return err
+			}
+			acc, err := store.GetAccount(cmd.Context(), identifier)
+			if err != nil {
+				return fmt.Errorf("failed to get account: %w", err)
+			}
+
+			newParams := accountKDFParams(acc)
+			if kdfProfile != "" {
+				if newParams, err = crypto.KDFProfile(kdfProfile); err != nil {
+					return err
+				}
+			} else if crypto.NeedsUpgrade(crypto.KeyVersion(acc.KeyVersion)) {
+				// Legacy rows still carry the old hardcoded parameters; strengthen them along with the cipher.
+				if newParams, err = crypto.KDFProfile(crypto.DefaultKDFProfile); err != nil {
+					return err
+				}
+			}
+
+			salt, err := hex.DecodeString(acc.Salt)
+			if err != nil {
+				return fmt.Errorf("failed to decode salt: %v", err)
+			}
+
+			oldPassphrase, err := app.passphrase(cmd.Context(), "Enter current passphrase (input hidden): ")
+			if err != nil {
+				return err
+			}
+			defer zeroBytes(oldPassphrase)
+
+			newPassphrase, err := replacementPassphrase(newFile, "Enter new passphrase (input hidden): ")
+			if err != nil {
+				return err
+			}
+			defer zeroBytes(newPassphrase)
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), 10*time.Second)
+			defer cancel()
+			slog.Debug("re-encrypting private key")
+			encryptedKey, newSalt, err := crypto.ChangePassphrase(ctx, acc.EncryptedKey, acc.Address, oldPassphrase, salt,
+				crypto.KeyVersion(acc.KeyVersion), accountKDFParams(acc), newPassphrase, newParams)
+			if err != nil {
+				return fmt.Errorf("failed to change passphrase: %w", err)
+			}
+
+			if err := store.UpdateAccountKey(cmd.Context(), newAccount(acc.Alias, acc.Address, encryptedKey, newSalt, newParams), acc.EncryptedKey); err != nil {
+				return fmt.Errorf("failed to update account: %w", err)
+			}
+			updated, err := store.GetAccount(cmd.Context(), acc.Address)
+			if err != nil {
+				return fmt.Errorf("failed to get account: %w", err)
+			}
+			return app.renderAccount(updated, "Passphrase changed")
+		},
+	}
+
+	cmd.Flags().StringVarP(&account, "account", "a", "", "Alias or address of the account (default: the profile's account)")
+	cmd.Flags().StringVar(&kdfProfile, "kdf", "", "Key derivation profile for the new passphrase (default: keep current)")
+	cmd.Flags().StringVar(&newFile, "new-passphrase-file", "", "Read the new passphrase from a file (mode 0600) instead of the terminal")
+	return cmd
+}
+
+func accountExportCmd(app *App) *cobra.Command {
+	var account, format, file string
+	var light bool
+	cmd := &cobra.Command{
+		Use:   "export --account <alias-or-address> [--format keystore] [--file <path>]",
+		Short: "Export an account as an Ethereum V3 keystore file",
+		Long: `Decrypts the account's private key and writes it as a Web3 Secret Storage (V3) keystore
+protected by a separate password, for use with geth, Foundry's cast wallet, or MetaMask.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			identifier, err := app.account(account)
+			if err != nil {
+				return err
+			}
+			if format != "keystore" {
+				return fmt.Errorf("unsupported export format: %s (expected keystore)", format)
+			}
+
+			store, err := app.Store(cmd.Context())
+			if err != nil {
+				return err
+			}
+			acc, err := store.GetAccount(cmd.Context(), identifier)
+			if err != nil {
+				return fmt.Errorf("failed to get account: %w", err)
+			}
+
+			salt, err := hex.DecodeString(acc.Salt)
+			if err != nil {
+				return fmt.Errorf("failed to decode salt: %v", err)
+			}
+
+			if file == "" {
+				file = keystoreFileName(acc.Address)
+			}
+
+			passphrase, err := app.passphrase(cmd.Context(), "Enter passphrase to decrypt private key (input hidden): ")
+			if err != nil {
+				return err
+			}
+			defer zeroBytes(passphrase)
+
+			keystorePassphrase, err := readNewPassphrase("Enter password for the keystore file (input hidden): ")
+			if err != nil {
+				return err
+			}
+			defer zeroBytes(keystorePassphrase)
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+			defer cancel()
+			slog.Debug("encrypting keystore")
+			keyJSON, err := crypto.ExportKeystore(ctx, acc.EncryptedKey, acc.Address, passphrase, salt,
+				crypto.KeyVersion(acc.KeyVersion), accountKDFParams(acc), keystorePassphrase, light)
+			if err != nil {
+				return fmt.Errorf("failed to export keystore: %w", err)
+			}
+
+			f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+			if err != nil {
+				return fmt.Errorf("failed to create keystore file: %v", err)
+			}
+			if _, err := f.Write(keyJSON); err != nil {
+				f.Close()
+				return fmt.Errorf("failed to write keystore file: %v", err)
+			}
+			if err := f.Close(); err != nil {
+				return fmt.Errorf("failed to write keystore file: %v", err)
+			}
+
+			result := struct {
+				Address string `json:"address"`
+				File    string `json:"file"`
+			}{acc.Address, file}
+			return app.render(result, func(w io.Writer) {
+				fmt.Fprintf(w, "Keystore exported: address=%s, file=%s\n", result.Address, result.File)
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&account, "account", "a", "", "Alias or address of the account to export (default: the profile's account)")
+	cmd.Flags().StringVar(&format, "format", "keystore", "Export format (keystore)")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Destination file (default: geth-style UTC--<time>--<address> in the current directory)")
+	cmd.Flags().BoolVar(&light, "light", false, "Use light scrypt parameters (faster, weaker)")
 	return cmd
-}
\ No newline at end of file
+}
+
+// keystoreFileName returns the file name geth uses for a keystore of the given address.
+func keystoreFileName(address string) string {
+	ts := time.Now().UTC().Format("2006-01-02T15-04-05.000000000Z")
+	return fmt.Sprintf("UTC--%s--%s", ts, strings.ToLower(strings.TrimPrefix(address, "0x")))
+}
+
+// newAccount builds a database record for a key sealed with crypto.CurrentKeyVersion.
+func newAccount(alias, address, encryptedKey string, salt []byte, params crypto.KDFParams) database.Account {
+	return database.Account{
+		Alias:        alias,
+		Address:      address,
+		EncryptedKey: encryptedKey,
+		Salt:         hex.EncodeToString(salt),
+		KeyVersion:   uint8(crypto.CurrentKeyVersion),
+		KDFTime:      params.Time,
+		KDFMemory:    params.Memory,
+		KDFThreads:   params.Threads,
+	}
+}
+
+// accountKDFParams returns the Argon2id parameters stored with an account.
+func accountKDFParams(acc *database.Account) crypto.KDFParams {
+	return crypto.KDFParams{Time: acc.KDFTime, Memory: acc.KDFMemory, Threads: acc.KDFThreads}
+}
+
+// accountView is how an account appears in command results. It never includes key material.
+type accountView struct {
+	Alias          string    `json:"alias"`
+	Address        string    `json:"address"`
+	KeyVersion     uint8     `json:"key_version"`
+	DerivationPath string    `json:"derivation_path,omitempty"`
+	Tags           []string  `json:"tags"`
+	Note           string    `json:"note,omitempty"`
+	CreatedAt      time.Time `json:"created_at,omitzero"`
+	LastUsedAt     time.Time `json:"last_used_at,omitzero"`
+}
+
+func newAccountView(acc *database.Account) accountView {
+	tags := acc.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return accountView{
+		Alias:          acc.Alias,
+		Address:        acc.Address,
+		KeyVersion:     acc.KeyVersion,
+		DerivationPath: acc.DerivationPath,
+		Tags:           tags,
+		Note:           acc.Note,
+		CreatedAt:      acc.CreatedAt,
+		LastUsedAt:     acc.LastUsedAt,
+	}
+}
+
+// renderAccount reports a single account a command acted on, prefixed by what happened.
+func (a *App) renderAccount(acc *database.Account, what string) error {
+	return a.render(newAccountView(acc), func(w io.Writer) {
+		fmt.Fprintf(w, "%s: alias=%s, address=%s\n", what, acc.Alias, acc.Address)
+	})
+}
+
+// renderAccounts reports the accounts derived from one mnemonic.
+func (a *App) renderAccounts(accounts []database.Account, what string) error {
+	views := make([]accountView, 0, len(accounts))
+	for i := range accounts {
+		views = append(views, newAccountView(&accounts[i]))
+	}
+	return a.render(views, func(w io.Writer) {
+		for _, v := range views {
+			fmt.Fprintf(w, "%s: alias=%s, address=%s, path=%s\n", what, v.Alias, v.Address, v.DerivationPath)
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xilverfang/syncora/internal/core/database"
+
+	"github.com/spf13/cobra"
+)
+
+// Sort orders accepted by account list --sort.
+const (
+	sortAlias    = "alias"
+	sortCreated  = "created"
+	sortLastUsed = "last-used"
+)
+
+func accountTagCmd(app *App) *cobra.Command {
+	var remove bool
+	cmd := &cobra.Command{
+		Use:   "tag <alias-or-address> <tag>... [--remove]",
+		Short: "Add tags to an account, or remove them with --remove",
+		Long: `Adds free-form tags such as hot, treasury or relayer to an account, for filtering with
+account list --tag. Tags are lowercased and may contain letters, digits, '-' and '_'.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			changed, err := database.NormalizeTags(args[1:])
+			if err != nil {
+				return err
+			}
+			store, err := app.Store(cmd.Context())
+			if err != nil {
+				return err
+			}
+			acc, err := store.GetAccount(cmd.Context(), args[0])
+			if err != nil {
+				return fmt.Errorf("failed to get account: %w", err)
+			}
+
+			tags := acc.Tags
+			if remove {
+				tags = slices.DeleteFunc(slices.Clone(tags), func(tag string) bool { return slices.Contains(changed, tag) })
+			} else {
+				tags = append(slices.Clone(tags), changed...)
+			}
+			if tags, err = database.NormalizeTags(tags); err != nil {
+				return err
+			}
+			if err := store.SetAccountMetadata(cmd.Context(), acc.Address, tags, acc.Note); err != nil {
+				return fmt.Errorf("failed to update account: %w", err)
+			}
+			acc.Tags = tags
+			return app.render(newAccountView(acc), func(w io.Writer) {
+				fmt.Fprintf(w, "Account tags: alias=%s, tags=%s\n", acc.Alias, formatTags(tags))
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&remove, "remove", false, "Remove the given tags instead of adding them")
+	return cmd
+}
+
+func accountNoteCmd(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "note <alias-or-address> [<text>]",
+		Short: "Set or clear the note on an account",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var note string
+			if len(args) == 2 {
+				note = strings.TrimSpace(args[1])
+			}
+			store, err := app.Store(cmd.Context())
+			if err != nil {
+				return err
+			}
+			acc, err := store.GetAccount(cmd.Context(), args[0])
+			if err != nil {
+				return fmt.Errorf("failed to get account: %w", err)
+			}
+			if err := store.SetAccountMetadata(cmd.Context(), acc.Address, acc.Tags, note); err != nil {
+				return fmt.Errorf("failed to update account: %w", err)
+			}
+			acc.Note = note
+			if note == "" {
+				return app.renderAccount(acc, "Account note cleared")
+			}
+			return app.renderAccount(acc, "Account note set")
+		},
+	}
+}
+
+// filterAccounts returns the accounts that have every one of tags.
+func filterAccounts(accounts []database.Account, tags []string) []database.Account {
+	if len(tags) == 0 {
+		return accounts
+	}
+	var filtered []database.Account
+	for _, acc := range accounts {
+		if !slices.ContainsFunc(tags, func(tag string) bool { return !slices.Contains(acc.Tags, tag) }) {
+			filtered = append(filtered, acc)
+		}
+	}
+	return filtered
+}
+
+// sortAccounts orders accounts by alias, or most recent first by creation or last use. Accounts
+// without the timestamp sort last, and ties are broken by alias.
+func sortAccounts(accounts []database.Account, order string) error {
+	var key func(acc database.Account) time.Time
+	switch order {
+	case sortAlias:
+	case sortCreated:
+		key = func(acc database.Account) time.Time { return acc.CreatedAt }
+	case sortLastUsed:
+		key = func(acc database.Account) time.Time { return acc.LastUsedAt }
+	default:
+		return fmt.Errorf("invalid --sort: %s (expected %s, %s or %s)", order, sortAlias, sortCreated, sortLastUsed)
+	}
+
+	sort.SliceStable(accounts, func(i, j int) bool {
+		if key != nil {
+			ti, tj := key(accounts[i]), key(accounts[j])
+			if !ti.Equal(tj) {
+				return ti.After(tj)
+			}
+		}
+		return strings.ToLower(accounts[i].Alias) < strings.ToLower(accounts[j].Alias)
+	})
+	return nil
+}
+
+// formatTime formats an account timestamp for listing, or returns empty if it is zero.
+func formatTime(t time.Time, empty string) string {
+	if t.IsZero() {
+		return empty
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return "-"
+	}
+	return strings.Join(tags, ",")
+}
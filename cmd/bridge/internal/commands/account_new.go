@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xilverfang/syncora/internal/core/crypto"
+	"github.com/xilverfang/syncora/internal/core/database"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// mnemonicQuizWords is how many words the user must repeat back before a new mnemonic is stored.
+const mnemonicQuizWords = 3
+
+func accountNewCmd(app *App) *cobra.Command {
+	var alias, kdfProfile string
+	var mnemonic bool
+	var words int
+	var count uint32
+	cmd := &cobra.Command{
+		Use:   "new [--alias <name>] [--mnemonic [--words 12|24] [--count <n>]]",
+		Short: "Generate a new account locally",
+		Long: `Generates a new secp256k1 private key, or with --mnemonic a new BIP-39 mnemonic that is shown
+once and must be confirmed, then encrypts and stores it like an imported account.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kdfParams, err := crypto.KDFProfile(app.kdfProfile(kdfProfile))
+			if err != nil {
+				return err
+			}
+			if err := checkAliasFlag(alias); err != nil {
+				return err
+			}
+
+			store, err := app.Store(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if mnemonic {
+				accounts, err := newMnemonicAccounts(cmd.Context(), app, store, alias, words, count, kdfParams)
+				if err != nil {
+					return err
+				}
+				return app.renderAccounts(accounts, "Account created")
+			}
+
+			passphrase, err := app.newPassphrase(cmd.Context(), "Enter passphrase for encryption (input hidden): ")
+			if err != nil {
+				return err
+			}
+			defer zeroBytes(passphrase)
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Second)
+			defer cancel()
+			slog.Debug("generating private key")
+			encryptedKey, address, salt, err := crypto.GenerateEncryptedKey(ctx, passphrase, crypto.CurrentKeyVersion, kdfParams)
+			if err != nil {
+				return fmt.Errorf("failed to generate private key: %v", err)
+			}
+
+			acc, err := saveAccount(cmd.Context(), store, alias, address, encryptedKey, salt, kdfParams)
+			if err != nil {
+				return err
+			}
+			return app.renderAccount(acc, "Account created")
+		},
+	}
+
+	cmd.Flags().StringVarP(&alias, "alias", "a", "", "Optional alias for the account")
+	cmd.Flags().StringVar(&kdfProfile, "kdf", "", "Key derivation profile: interactive, sensitive or test (default: from the profile, else interactive)")
+	cmd.Flags().BoolVar(&mnemonic, "mnemonic", false, "Generate a BIP-39 mnemonic and derive accounts at "+crypto.HDBasePath+"/i")
+	cmd.Flags().IntVar(&words, "words", 12, "Mnemonic length with --mnemonic: 12 or 24")
+	cmd.Flags().Uint32Var(&count, "count", 1, "Number of accounts to derive with --mnemonic")
+	return cmd
+}
+
+// newMnemonicAccounts generates a mnemonic, shows it once, quizzes the user on it, and stores the
+// derived accounts through the same path as a mnemonic import. It returns the stored accounts.
+func newMnemonicAccounts(ctx context.Context, app *App, store database.AccountStore, alias string, words int, count uint32, kdfParams crypto.KDFParams) ([]database.Account, error) {
+	if count == 0 || count > crypto.MaxHDAccounts {
+		return nil, fmt.Errorf("invalid --count: %d (expected 1-%d)", count, crypto.MaxHDAccounts)
+	}
+
+	mnemonic, err := crypto.NewMnemonic(words)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(mnemonic)
+
+	if err := showMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	if err := quizMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+
+	bip39Passphrase, err := readPassword("Enter BIP-39 passphrase, if any (input hidden): ")
+	defer zeroBytes(bip39Passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read BIP-39 passphrase: %v", err)
+	}
+
+	passphrase, err := app.newPassphrase(ctx, "Enter passphrase for encryption (input hidden): ")
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(passphrase)
+
+	return saveMnemonicAccounts(ctx, store, mnemonic, bip39Passphrase, passphrase, alias, 0, count, kdfParams)
+}
+
+// showMnemonic prints the numbered mnemonic once and clears the terminal after the user confirms
+// they have written it down. It writes to stderr, so the phrase never ends up in piped results.
+func showMnemonic(mnemonic []byte) error {
+	fmt.Fprintln(os.Stderr, "Write down this recovery phrase and keep it offline. It will not be shown again.")
+	fmt.Fprintln(os.Stderr)
+	for i, word := range strings.Fields(string(mnemonic)) {
+		fmt.Fprintf(os.Stderr, "%2d. %s\n", i+1, word)
+	}
+	fmt.Fprintln(os.Stderr)
+
+	ack, err := readPassword("Press Enter once you have written it down: ")
+	zeroBytes(ack)
+	if err != nil {
+		return fmt.Errorf("failed to read confirmation: %v", err)
+	}
+
+	if term.IsTerminal(int(os.Stderr.Fd())) {
+		// Clear the screen and scrollback so the phrase does not linger on the terminal.
+		fmt.Fprint(os.Stderr, "\033[H\033[2J\033[3J")
+	}
+	return nil
+}
+
+// quizMnemonic asks for randomly chosen words of the mnemonic and fails if any answer is wrong.
+func quizMnemonic(mnemonic []byte) error {
+	words := strings.Fields(string(mnemonic))
+	positions, err := randomPositions(len(words), mnemonicQuizWords)
+	if err != nil {
+		return err
+	}
+
+	for _, pos := range positions {
+		answer, err := readPassword(fmt.Sprintf("Enter word #%d (input hidden): ", pos+1))
+		if err != nil {
+			zeroBytes(answer)
+			return fmt.Errorf("failed to read word: %v", err)
+		}
+		ok := strings.ToLower(strings.TrimSpace(string(answer))) == words[pos]
+		zeroBytes(answer)
+		if !ok {
+			return fmt.Errorf("word #%d does not match; no account was created", pos+1)
+		}
+	}
+	return nil
+}
+
+// randomPositions returns k distinct indexes below n in ascending order.
+func randomPositions(n, k int) ([]int, error) {
+	if k > n {
+		k = n
+	}
+	chosen := make(map[int]bool, k)
+	positions := make([]int, 0, k)
+	for len(positions) < k {
+		r, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to pick quiz words: %v", err)
+		}
+		pos := int(r.Int64())
+		if chosen[pos] {
+			continue
+		}
+		chosen[pos] = true
+		positions = append(positions, pos)
+	}
+	sort.Ints(positions)
+	return positions, nil
+}
@@ -0,0 +1,194 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/xilverfang/syncora/internal/core/crypto"
+	"github.com/xilverfang/syncora/internal/core/database"
+
+	"github.com/spf13/cobra"
+)
+
+// maxMessageSize bounds message and typed data files read from disk.
+const maxMessageSize = 1024 * 1024
+
+// messageInput selects what is signed or verified: an EIP-191 message or an EIP-712 document.
+type messageInput struct {
+	message     string
+	messageFile string
+	typedData   string
+}
+
+func (in *messageInput) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&in.message, "message", "m", "", "Personal message to sign (EIP-191)")
+	cmd.Flags().StringVar(&in.messageFile, "message-file", "", "File containing the personal message (EIP-191)")
+	cmd.Flags().StringVar(&in.typedData, "typed-data", "", "JSON file containing EIP-712 typed data")
+	cmd.MarkFlagsMutuallyExclusive("message", "message-file", "typed-data")
+	cmd.MarkFlagsOneRequired("message", "message-file", "typed-data")
+}
+
+// load returns the payload and whether it is EIP-712 typed data.
+func (in *messageInput) load() ([]byte, bool, error) {
+	switch {
+	case in.typedData != "":
+		data, err := readLimitedFile(in.typedData, maxMessageSize)
+		return data, true, err
+	case in.messageFile != "":
+		data, err := readLimitedFile(in.messageFile, maxMessageSize)
+		return data, false, err
+	default:
+		return []byte(in.message), false, nil
+	}
+}
+
+// hash returns the EIP-191 or EIP-712 signing hash of the payload.
+func (in *messageInput) hash() ([]byte, error) {
+	payload, typed, err := in.load()
+	if err != nil {
+		return nil, err
+	}
+	if typed {
+		return crypto.TypedDataHash(payload)
+	}
+	return crypto.PersonalMessageHash(payload), nil
+}
+
+func accountSignMessageCmd(app *App) *cobra.Command {
+	var account string
+	var input messageInput
+	cmd := &cobra.Command{
+		Use:   "sign-message --account <alias-or-address> (--message <text> | --message-file <file> | --typed-data <file>)",
+		Short: "Sign an EIP-191 personal message or EIP-712 typed data with a stored account",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			identifier, err := app.account(account)
+			if err != nil {
+				return err
+			}
+			payload, typed, err := input.load()
+			if err != nil {
+				return err
+			}
+			if typed {
+				// Fail on malformed typed data before asking for the passphrase.
+				if _, err := crypto.TypedDataHash(payload); err != nil {
+					return err
+				}
+			}
+
+			store, err := app.Store(cmd.Context())
+			if err != nil {
+				return err
+			}
+			acc, err := store.GetAccount(cmd.Context(), identifier)
+			if err != nil {
+				return fmt.Errorf("failed to get account: %w", err)
+			}
+
+			signer, err := accountSigner(cmd.Context(), app, store, acc)
+			if err != nil {
+				return err
+			}
+			defer signer.Lock()
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Second)
+			defer cancel()
+			var sig []byte
+			if typed {
+				sig, err = signer.SignTypedData(ctx, payload)
+			} else {
+				sig, err = signer.SignPersonalMessage(ctx, payload)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to sign message: %v", err)
+			}
+
+			result := struct {
+				Signature string `json:"signature"`
+				Signer    string `json:"signer"`
+			}{hexutil.Encode(sig), signer.Address().Hex()}
+			return app.render(result, func(w io.Writer) {
+				fmt.Fprintf(w, "Signature: %s\n", result.Signature)
+				fmt.Fprintf(w, "Signer: %s\n", result.Signer)
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&account, "account", "a", "", "Alias or address of the signing account (default: the profile's account)")
+	input.addFlags(cmd)
+	return cmd
+}
+
+func accountVerifyMessageCmd(app *App) *cobra.Command {
+	var account, signature string
+	var input messageInput
+	cmd := &cobra.Command{
+		Use:   "verify-message --signature <hex> (--message <text> | --message-file <file> | --typed-data <file>) [--account <alias-or-address>]",
+		Short: "Recover the signer of an EIP-191 or EIP-712 signature and check it against stored accounts",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sig, err := hexutil.Decode(signature)
+			if err != nil {
+				return fmt.Errorf("invalid signature: %v", err)
+			}
+
+			hash, err := input.hash()
+			if err != nil {
+				return err
+			}
+
+			signer, err := crypto.RecoverAddress(hash, sig)
+			if err != nil {
+				return err
+			}
+
+			store, err := app.Store(cmd.Context())
+			if err != nil {
+				return err
+			}
+			var acc *database.Account
+			if account != "" {
+				if acc, err = store.GetAccount(cmd.Context(), account); err != nil {
+					return fmt.Errorf("failed to get account: %w", err)
+				}
+				if !strings.EqualFold(acc.Address, signer.Hex()) {
+					return fmt.Errorf("signature was not made by account %s (%s)", acc.Alias, acc.Address)
+				}
+			} else if acc, err = store.GetAccount(cmd.Context(), signer.Hex()); errors.Is(err, database.ErrNotFound) {
+				acc = nil
+			} else if err != nil {
+				return fmt.Errorf("failed to look up signer: %w", err)
+			}
+
+			// Account is null when the signer is not a stored account.
+			result := struct {
+				Signer  string       `json:"signer"`
+				Account *accountView `json:"account"`
+			}{Signer: signer.Hex()}
+			if acc != nil {
+				view := newAccountView(acc)
+				result.Account = &view
+			}
+			return app.render(result, func(w io.Writer) {
+				fmt.Fprintf(w, "Recovered signer: %s\n", result.Signer)
+				if acc == nil {
+					fmt.Fprintln(w, "Signer is not a stored account")
+					return
+				}
+				fmt.Fprintf(w, "Signature valid: alias=%s, address=%s\n", acc.Alias, acc.Address)
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&signature, "signature", "s", "", "Hex-encoded 65-byte signature (required)")
+	cmd.Flags().StringVarP(&account, "account", "a", "", "Expected signer alias or address")
+	input.addFlags(cmd)
+	cmd.MarkFlagRequired("signature")
+	return cmd
+}
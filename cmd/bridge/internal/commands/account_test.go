@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xilverfang/syncora/cmd/bridge/internal/config"
+	"github.com/xilverfang/syncora/internal/core/crypto"
+	"github.com/xilverfang/syncora/internal/core/database"
+)
+
+func TestImportPrivateKeyFile(t *testing.T) {
+	ctx := context.Background()
+	vault := filepath.Join(t.TempDir(), "vault.json")
+	for name, data := range map[string]string{
+		"bare":       "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80",
+		"whitespace": "  \t0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80 \n\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			keyFile := filepath.Join(t.TempDir(), "key")
+			if err := os.WriteFile(keyFile, []byte(data), 0600); err != nil {
+				t.Fatal(err)
+			}
+			app := NewApp(func() (database.Config, error) {
+				return database.Config{Backend: database.BackendFile, Path: vault}, nil
+			})
+			app.config = &config.Config{File: &config.File{}}
+			app.stdout = io.Discard
+			app.secrets.file = writeSecretFile(t, "hunter2 hunter2\n", 0600)
+			defer app.Close()
+
+			cmd := accountImportCmd(app)
+			cmd.SetArgs([]string{"--private-key-file", keyFile, "--alias", name, "--kdf", "test"})
+			cmd.SetOut(io.Discard)
+			if err := cmd.ExecuteContext(ctx); err != nil {
+				t.Fatalf("import: %v", err)
+			}
+			store, err := app.Store(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			acc, err := store.GetAccount(ctx, name)
+			if err != nil || acc.Address != "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266" {
+				t.Fatalf("GetAccount(%s) = %+v, %v", name, acc, err)
+			}
+		})
+	}
+}
+
+func TestUpgradeAccountKeyKDF(t *testing.T) {
+	ctx := context.Background()
+	store, err := database.NewFileStore(filepath.Join(t.TempDir(), "vault.json"), func(context.Context, bool) ([]byte, error) {
+		return []byte("vaultpass"), nil
+	}, crypto.KDFTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	strong := crypto.KDFParams{Time: 2, Memory: 16 * 1024, Threads: 2}
+	for _, tc := range []struct {
+		alias         string
+		legacy        crypto.KDFParams
+		profile, want crypto.KDFParams
+	}{
+		{"weak", crypto.KDFTest, strong, strong},
+		{"strong", strong, crypto.KDFTest, strong},
+		{"mixed", crypto.KDFParams{Time: 3, Memory: 8 * 1024, Threads: 1}, strong, crypto.KDFParams{Time: 3, Memory: 16 * 1024, Threads: 2}},
+	} {
+		t.Run(tc.alias, func(t *testing.T) {
+			passphrase := []byte("hunter2 hunter2")
+			encryptedKey, address, salt, err := crypto.EncryptPrivateKey(ctx, "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80", passphrase, crypto.KeyVersion1, tc.legacy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			legacy := newAccount(tc.alias, address, encryptedKey, salt, tc.legacy)
+			legacy.KeyVersion = uint8(crypto.KeyVersion1)
+			if err := store.SaveAccount(ctx, legacy); err != nil {
+				t.Fatal(err)
+			}
+			acc, err := store.GetAccount(ctx, tc.alias)
+			if err != nil {
+				t.Fatal(err)
+			}
+			signer, err := unlockAccount(ctx, store, acc, passphrase, tc.profile)
+			if err != nil {
+				t.Fatalf("unlockAccount: %v", err)
+			}
+			signer.Lock()
+
+			acc, err = store.GetAccount(ctx, tc.alias)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if acc.KeyVersion != uint8(crypto.CurrentKeyVersion) || accountKDFParams(acc) != tc.want {
+				t.Fatalf("upgraded key version %d with %+v, want %d with %+v", acc.KeyVersion, accountKDFParams(acc), crypto.CurrentKeyVersion, tc.want)
+			}
+			signer, err = unlockAccount(ctx, store, acc, passphrase, tc.profile)
+			if err != nil {
+				t.Fatalf("unlockAccount after the upgrade: %v", err)
+			}
+			signer.Lock()
+		})
+	}
+}
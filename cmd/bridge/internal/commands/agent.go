@@ -0,0 +1,275 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/xilverfang/syncora/cmd/bridge/internal/agent"
+	"github.com/xilverfang/syncora/internal/core/database"
+
+	"github.com/spf13/cobra"
+)
+
+func AgentCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "agent",
+		Short: "Run a local signing agent that keeps accounts unlocked for a limited time",
+		Long: `Commands to start a local signing agent, similar to ssh-agent, and manage the keys it holds.
+The agent keeps decrypted keys in locked memory and only exposes signing over a Unix socket
+readable by the current user. Set SYNCORA_AGENT_SOCK to use it from other commands.`,
+	}
+
+	cmd.AddCommand(agentStartCmd(app))
+	cmd.AddCommand(agentAddCmd(app))
+	cmd.AddCommand(agentListCmd(app))
+	cmd.AddCommand(agentRemoveCmd(app))
+	cmd.AddCommand(agentLockCmd(app))
+
+	return cmd
+}
+
+func agentStartCmd(app *App) *cobra.Command {
+	var socket string
+	var ttl, maxTTL time.Duration
+	cmd := &cobra.Command{
+		Use:   "start [--socket <path>] [--ttl <duration>] [--max-ttl <duration>]",
+		Short: "Start the signing agent in the foreground",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if ttl <= 0 {
+				return fmt.Errorf("--ttl must be positive")
+			}
+			if maxTTL <= 0 || maxTTL > agent.MaxTTL {
+				return usageError{fmt.Errorf("invalid --max-ttl %s (expected at most %s)", maxTTL, agent.MaxTTL)}
+			}
+			if ttl > maxTTL {
+				return usageError{fmt.Errorf("--ttl %s exceeds --max-ttl %s", ttl, maxTTL)}
+			}
+			if socket == "" {
+				socket = agent.DefaultSocketPath()
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			// The table form stays eval-able, like ssh-agent's output.
+			err := app.render(struct {
+				Socket string `json:"socket"`
+			}{socket}, func(w io.Writer) {
+				fmt.Fprintf(w, "%s=%s; export %s;\n", agent.SockEnv, socket, agent.SockEnv)
+			})
+			if err != nil {
+				return err
+			}
+			slog.Info("agent listening", "socket", socket)
+			if err := agent.NewServer(ttl, maxTTL).ListenAndServe(ctx, socket); err != nil {
+				return err
+			}
+			slog.Info("agent stopped, all keys locked")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&socket, "socket", "", "Socket path (default $XDG_RUNTIME_DIR/syncora/agent.sock)")
+	cmd.Flags().DurationVar(&ttl, "ttl", agent.DefaultTTL, "Default time keys stay unlocked")
+	cmd.Flags().DurationVar(&maxTTL, "max-ttl", agent.MaxTTL, "Longest time agent add may unlock a key for, at most 24h")
+	return cmd
+}
+
+func agentAddCmd(app *App) *cobra.Command {
+	var account string
+	var ttl time.Duration
+	cmd := &cobra.Command{
+		Use:   "add --account <alias-or-address> [--ttl <duration>]",
+		Short: "Unlock an account in the agent",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkAgentTTL(ttl); err != nil {
+				return err
+			}
+			identifier, err := app.account(account)
+			if err != nil {
+				return err
+			}
+			client, err := agentClient()
+			if err != nil {
+				return err
+			}
+			store, err := app.Store(cmd.Context())
+			if err != nil {
+				return err
+			}
+			acc, err := store.GetAccount(cmd.Context(), identifier)
+			if err != nil {
+				return fmt.Errorf("failed to get account: %w", err)
+			}
+
+			passphrase, err := app.passphrase(cmd.Context(), "Enter passphrase to decrypt private key (input hidden): ")
+			if err != nil {
+				return err
+			}
+			defer zeroBytes(passphrase)
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+			defer cancel()
+			expires, err := client.Add(ctx, storedKey(acc), passphrase, ttl)
+			if err != nil {
+				return fmt.Errorf("failed to add account to agent: %v", err)
+			}
+			markUsed(cmd.Context(), store, acc)
+			result := struct {
+				Alias     string    `json:"alias"`
+				Address   string    `json:"address"`
+				ExpiresAt time.Time `json:"expires_at"`
+			}{acc.Alias, acc.Address, expires.UTC()}
+			return app.render(result, func(w io.Writer) {
+				fmt.Fprintf(w, "Account unlocked in agent: alias=%s, address=%s, until=%s\n", acc.Alias, acc.Address, expires.Local().Format(time.RFC3339))
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&account, "account", "a", "", "Alias or address of the account to unlock (default: the profile's account)")
+	cmd.Flags().DurationVar(&ttl, "ttl", 0, "Time the key stays unlocked, in whole seconds up to the agent's --max-ttl (default: the agent's TTL)")
+	return cmd
+}
+
+// checkAgentTTL checks the --ttl of agent add: zero selects the agent's default, and other TTLs
+// must be whole seconds, as the agent counts them, of at most agent.MaxTTL.
+func checkAgentTTL(ttl time.Duration) error {
+	if ttl != 0 && (ttl < time.Second || ttl > agent.MaxTTL || ttl%time.Second != 0) {
+		return usageError{fmt.Errorf("invalid --ttl %s (expected whole seconds from 1s to %s)", ttl, agent.MaxTTL)}
+	}
+	return nil
+}
+
+func agentListCmd(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List accounts unlocked in the agent",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := agentClient()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Second)
+			defer cancel()
+			keys, err := client.List(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to list agent keys: %v", err)
+			}
+
+			type agentKey struct {
+				Address   string    `json:"address"`
+				ExpiresAt time.Time `json:"expires_at"`
+			}
+			result := make([]agentKey, 0, len(keys))
+			for _, k := range keys {
+				result = append(result, agentKey{k.Address, k.Expires.UTC()})
+			}
+			return app.render(result, func(w io.Writer) {
+				if len(result) == 0 {
+					fmt.Fprintln(w, "No accounts unlocked in agent")
+					return
+				}
+				fmt.Fprintln(w, "Address\tLocks At")
+				for _, k := range result {
+					fmt.Fprintf(w, "%s\t%s\n", k.Address, k.ExpiresAt.Local().Format(time.RFC3339))
+				}
+			})
+		},
+	}
+}
+
+func agentRemoveCmd(app *App) *cobra.Command {
+	var account string
+	cmd := &cobra.Command{
+		Use:   "remove --account <alias-or-address>",
+		Short: "Lock a single account in the agent",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := agentClient()
+			if err != nil {
+				return err
+			}
+			store, err := app.Store(cmd.Context())
+			if err != nil {
+				return err
+			}
+			acc, err := store.GetAccount(cmd.Context(), account)
+			if err != nil {
+				return fmt.Errorf("failed to get account: %w", err)
+			}
+			ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Second)
+			defer cancel()
+			if err := client.Remove(ctx, acc.Address); err != nil {
+				return fmt.Errorf("failed to remove account from agent: %v", err)
+			}
+			return app.render(struct {
+				Address string `json:"address"`
+				Locked  bool   `json:"locked"`
+			}{acc.Address, true}, func(w io.Writer) {
+				fmt.Fprintf(w, "Account locked: %s\n", acc.Address)
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&account, "account", "a", "", "Alias or address of the account to lock (required)")
+	cmd.MarkFlagRequired("account")
+	return cmd
+}
+
+func agentLockCmd(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "lock",
+		Short: "Lock every account held by the agent",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := agentClient()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Second)
+			defer cancel()
+			if err := client.LockAll(ctx); err != nil {
+				return fmt.Errorf("failed to lock agent: %v", err)
+			}
+			return app.render(struct {
+				Locked bool `json:"locked"`
+			}{true}, func(w io.Writer) {
+				fmt.Fprintln(w, "All agent accounts locked")
+			})
+		},
+	}
+}
+
+// agentClient connects to the agent named by SYNCORA_AGENT_SOCK.
+func agentClient() (*agent.Client, error) {
+	client, err := agent.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, fmt.Errorf("%s is not set; start an agent with 'syncora agent start'", agent.SockEnv)
+	}
+	return client, nil
+}
+
+// storedKey converts an account into the encrypted form the agent decrypts itself.
+func storedKey(acc *database.Account) agent.StoredKey {
+	return agent.StoredKey{
+		Address:      acc.Address,
+		EncryptedKey: acc.EncryptedKey,
+		Salt:         acc.Salt,
+		KeyVersion:   acc.KeyVersion,
+		KDFTime:      acc.KDFTime,
+		KDFMemory:    acc.KDFMemory,
+		KDFThreads:   acc.KDFThreads,
+	}
+}
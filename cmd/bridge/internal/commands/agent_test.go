@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckAgentTTL(t *testing.T) {
+	for _, tt := range []struct {
+		ttl time.Duration
+		ok  bool
+	}{
+		{0, true},
+		{time.Second, true},
+		{90 * time.Second, true},
+		{24 * time.Hour, true},
+		{500 * time.Millisecond, false},
+		{1500 * time.Millisecond, false},
+		{-time.Minute, false},
+		{24*time.Hour + time.Second, false},
+		{1<<63 - 1, false},
+	} {
+		err := checkAgentTTL(tt.ttl)
+		if (err == nil) != tt.ok {
+			t.Errorf("checkAgentTTL(%s) = %v", tt.ttl, err)
+		}
+		if err != nil && errorCode(err) != codeUsage {
+			t.Errorf("checkAgentTTL(%s) is not a usage error", tt.ttl)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/xilverfang/syncora/cmd/bridge/internal/config"
+	engine "github.com/xilverfang/syncora/internal/bridge-engine"
+	"github.com/xilverfang/syncora/internal/bridge-engine/adapters/across"
+	"github.com/xilverfang/syncora/internal/bridge-engine/adapters/opstack"
+	"github.com/xilverfang/syncora/internal/core/crypto"
+	"github.com/xilverfang/syncora/internal/core/database"
+	"github.com/xilverfang/syncora/internal/core/registry"
+)
+
+// App holds the dependencies shared by commands. The account store is opened on first use, so
+// commands that never touch accounts work without any storage configured.
+type App struct {
+	loadConfig func() (database.Config, error)
+	config     *config.Config // loaded before any command runs
+	profile    string         // --profile
+	store      database.AccountStore
+	registry   *registry.Registry
+	engine     *engine.Registry
+	clientsMu  sync.Mutex
+	clients    map[string]*ethclient.Client // by chain name
+	migrator   database.Migrator
+	output     string    // --output format
+	stdout     io.Writer // receives command results
+	logLevel   string    // --log-level
+	logFormat  string    // --log-format
+	secrets    secretSource
+}
+
+// NewApp returns an App that reads its storage configuration with loadConfig when a command
+// first needs the database. Settings that loadConfig leaves empty are taken from the selected
+// configuration profile.
+func NewApp(loadConfig func() (database.Config, error)) *App {
+	return &App{loadConfig: loadConfig, output: outputTable, stdout: os.Stdout, secrets: secretSource{fd: -1}}
+}
+
+// Store returns the account store, opening it on the first call.
+func (a *App) Store(ctx context.Context) (database.AccountStore, error) {
+	if a.store == nil {
+		cfg, err := a.databaseConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open account store: %w", err)
+		}
+		store, err := database.Open(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open account store: %w", err)
+		}
+		a.store = store
+	}
+	return a.store, nil
+}
+
+// Migrator returns the schema migrator for the configured database, connecting on the first call
+// without requiring the schema to be current.
+func (a *App) Migrator(ctx context.Context) (database.Migrator, error) {
+	if a.migrator == nil {
+		cfg, err := a.databaseConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open database: %w", err)
+		}
+		migrator, err := database.OpenMigrator(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open database: %w", err)
+		}
+		a.migrator = migrator
+	}
+	return a.migrator, nil
+}
+
+// Registry returns the chain, token and bridge registry, loading it on the first call with the
+// user's overrides applied.
+func (a *App) Registry() (*registry.Registry, error) {
+	if a.registry == nil {
+		dir, err := config.RegistryDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load registry: %w", err)
+		}
+		sources, err := registrySources(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load registry: %w", err)
+		}
+		reg, err := registry.Load(sources...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load registry: %w", err)
+		}
+		a.registry = reg
+	}
+	return a.registry, nil
+}
+
+// Engine returns the bridge adapters, creating them on the first call from the registry. Adapters
+// connect to chains through Client.
+func (a *App) Engine() (*engine.Registry, error) {
+	if a.engine == nil {
+		reg, err := a.Registry()
+		if err != nil {
+			return nil, err
+		}
+		e, err := engine.NewRegistry(append(opstack.Adapters(reg, a.dial), across.Adapters(reg, a.dial)...)...)
+		if err != nil {
+			return nil, err
+		}
+		a.engine = e
+	}
+	return a.engine, nil
+}
+
+// dial is the engine.Dialer of adapters and price feeds.
+func (a *App) dial(ctx context.Context, chain string) (engine.Client, error) {
+	client, err := a.Client(ctx, chain)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// Client returns an RPC client for a registry chain, connecting on the first call to the
+// rpc.<chain> setting or, if it is not set, the chain's first endpoint in the registry. It fails if
+// the endpoint serves another chain. Client is safe for concurrent use.
+func (a *App) Client(ctx context.Context, chain string) (*ethclient.Client, error) {
+	reg, err := a.Registry()
+	if err != nil {
+		return nil, err
+	}
+	c, err := reg.Chain(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	a.clientsMu.Lock()
+	defer a.clientsMu.Unlock()
+	if client, ok := a.clients[c.Name]; ok {
+		return client, nil
+	}
+	url := a.setting(config.KeyRPCPrefix + c.Name)
+	if url == "" {
+		if len(c.RPC) == 0 {
+			return nil, fmt.Errorf("no RPC endpoint for %s (set one with 'syncora config set %s%s <url>')", c.Name, config.KeyRPCPrefix, c.Name)
+		}
+		url = c.RPC[0]
+	}
+	client, err := ethclient.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", c.Name, err)
+	}
+	id, err := client.ChainID(ctx)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to get chain ID from %s RPC: %v", c.Name, err)
+	}
+	if !id.IsUint64() || id.Uint64() != c.ChainID {
+		client.Close()
+		return nil, fmt.Errorf("%s RPC serves chain ID %s, expected %d", c.Name, id, c.ChainID)
+	}
+	if a.clients == nil {
+		a.clients = make(map[string]*ethclient.Client)
+	}
+	a.clients[c.Name] = client
+	return client, nil
+}
+
+// registrySources returns the built-in registry files followed by the overrides in dir, if it
+// exists.
+func registrySources(dir string) ([]registry.Source, error) {
+	sources := []registry.Source{registry.Embedded()}
+	info, err := os.Stat(dir)
+	switch {
+	case os.IsNotExist(err):
+		return sources, nil
+	case err != nil:
+		return nil, err
+	case !info.IsDir():
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return append(sources, registry.Dir(dir)), nil
+}
+
+// databaseConfig returns the storage configuration from loadConfig over the profile's.
+func (a *App) databaseConfig() (database.Config, error) {
+	cfg, err := a.loadConfig()
+	if err != nil {
+		return cfg, err
+	}
+	if a.config != nil {
+		cfg = cfg.WithDefaults(a.config.Database())
+	}
+	cfg.VaultPassphrase = a.vaultPassphrase
+	cfg.VaultKDF, err = crypto.KDFProfile(a.kdfProfile(""))
+	return cfg, err
+}
+
+// setting returns the value of a configuration key from the environment, the selected profile or
+// the defaults.
+func (a *App) setting(key string) string {
+	if a.config == nil {
+		return ""
+	}
+	value, _, err := a.config.Lookup(key)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// kdfProfile returns the --kdf flag if it was given, and the configured KDF profile otherwise.
+func (a *App) kdfProfile(flag string) string {
+	if flag != "" {
+		return flag
+	}
+	if kdf := a.setting(config.KeyKDF); kdf != "" {
+		return kdf
+	}
+	return crypto.DefaultKDFProfile
+}
+
+// account returns the --account flag if it was given, and the profile's default account otherwise.
+func (a *App) account(flag string) (string, error) {
+	if flag != "" {
+		return flag, nil
+	}
+	if account := a.setting(config.KeyAccount); account != "" {
+		return account, nil
+	}
+	return "", usageError{errors.New(`required flag "account" not set (or set a default with 'syncora config set account <alias>')`)}
+}
+
+// Close closes the account store, migrator and RPC clients that were opened.
+func (a *App) Close() error {
+	zeroBytes(a.secrets.fdLine)
+	for _, client := range a.clients {
+		client.Close()
+	}
+	var errs []error
+	if a.store != nil {
+		errs = append(errs, a.store.Close())
+	}
+	if a.migrator != nil {
+		errs = append(errs, a.migrator.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,776 @@
+package commands
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	engine "github.com/xilverfang/syncora/internal/bridge-engine"
+	"github.com/xilverfang/syncora/internal/core/amount"
+	"github.com/xilverfang/syncora/internal/core/crypto"
+	"github.com/xilverfang/syncora/internal/core/database"
+	"github.com/xilverfang/syncora/internal/core/registry"
+
+	"github.com/spf13/cobra"
+)
+
+// receiptTimeout bounds the wait for each source transaction to be mined.
+const receiptTimeout = 10 * time.Minute
+
+// defaultSlippageBPS is the shortfall of the received amount tolerated unless --slippage-bps is given.
+const defaultSlippageBPS = 50
+
+// arrivalPollInterval is how often bridge send asks whether a leg of a plan has arrived.
+const arrivalPollInterval = 15 * time.Second
+
+// arrivalMargin is added to twice the expected time of a leg before bridge send stops waiting.
+const arrivalMargin = 10 * time.Minute
+
+func BridgeCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bridge",
+		Short: "Send tokens to another chain and follow transfers",
+		Long: `Commands to move tokens between chains through a bridge service, directly or through other
+chains, and to list the transfers sent from stored accounts.`,
+	}
+
+	cmd.AddCommand(bridgeSendCmd(app))
+	cmd.AddCommand(bridgeRouteCmd(app))
+	cmd.AddCommand(bridgeListCmd(app))
+	return cmd
+}
+
+func bridgeSendCmd(app *App) *cobra.Command {
+	var from, to, bridgeName, amountFlag, account, recipient string
+	var slippageBPS uint32
+	var maxHops int
+	var yes bool
+	cmd := &cobra.Command{
+		Use:   "send <token> --from <chain> --to <chain> --amount <value> [--bridge <name>] [--account <alias-or-address>] [--recipient <address>] [--yes]",
+		Short: "Bridge tokens to another chain",
+		Long: `Quotes a transfer with the bridge, shows the fees, the minimum amount received, the expected time
+and the recipient, and asks for confirmation. The transactions are then signed with the account,
+sent on the source chain one after the other, and recorded in the account store; syncora bridge
+list shows them.
+
+Without --bridge, the transfer follows the best plan of syncora bridge route, which may pass
+through other chains. Its legs are sent one after the other: each leg starts once the previous one
+has arrived, sends the minimum amount the previous leg guaranteed, and is quoted only then. Each
+later leg's quote is shown and confirmed like the first unless --yes is given.`,
+		Example: `  syncora bridge send ETH --from mainnet --to base --bridge base-bridge --amount 0.5 --account my-wallet
+  syncora bridge send ETH --from arbitrum --to base --amount 0.1`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			identifier, err := app.account(account)
+			if err != nil {
+				return err
+			}
+			if recipient != "" && !common.IsHexAddress(recipient) {
+				return usageError{fmt.Errorf("invalid recipient address: %s", recipient)}
+			}
+			if slippageBPS > 10_000 {
+				return usageError{fmt.Errorf("invalid slippage: %d basis points (at most 10000)", slippageBPS)}
+			}
+			if bridgeName != "" && cmd.Flags().Changed("max-hops") {
+				return usageError{errors.New("--max-hops applies only without --bridge")}
+			}
+			if maxHops < 1 {
+				return usageError{fmt.Errorf("invalid maximum hops: %d", maxHops)}
+			}
+
+			reg, err := app.Registry()
+			if err != nil {
+				return err
+			}
+			token, err := reg.Token(args[0])
+			if err != nil {
+				return err
+			}
+			source, err := reg.Chain(from)
+			if err != nil {
+				return err
+			}
+			destination, err := reg.Chain(to)
+			if err != nil {
+				return err
+			}
+			if source.Name == destination.Name {
+				return usageError{fmt.Errorf("--from and --to are both %s", source.Name)}
+			}
+			bridges, err := app.Engine()
+			if err != nil {
+				return err
+			}
+			plan, err := sendPlan(ctx, reg, bridges, bridgeName, token.Symbol, source.Name, destination.Name, maxHops)
+			if err != nil {
+				return err
+			}
+			adapters := make([]engine.BridgeAdapter, len(plan.Legs))
+			for i, leg := range plan.Legs {
+				adapters[i], err = bridges.Resolve(ctx, leg)
+				if errors.Is(err, engine.ErrUnknownBridge) {
+					return fmt.Errorf("sending through %s is not supported yet: %w", leg.Bridge, err)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			value, err := token.ParseAmount(source.Name, amountFlag)
+			if err != nil {
+				return err
+			}
+			deployment, _ := token.On(source.Name)
+			if value.IsMax() && deployment.Native {
+				return usageError{fmt.Errorf("--amount max is not supported for %s, which also pays for gas on %s", token.Symbol, source.Name)}
+			}
+
+			store, err := app.Store(ctx)
+			if err != nil {
+				return err
+			}
+			acc, err := store.GetAccount(ctx, identifier)
+			if err != nil {
+				return fmt.Errorf("failed to get account: %w", err)
+			}
+			sender := common.HexToAddress(acc.Address)
+
+			client, err := app.Client(ctx, source.Name)
+			if err != nil {
+				return err
+			}
+			balance, err := tokenBalance(ctx, client, deployment, sender)
+			if err != nil {
+				return err
+			}
+			sent := value.Of(balance)
+			if sent.Sign() <= 0 {
+				return fmt.Errorf("%w %q: must be more than zero", amount.ErrInvalid, amountFlag)
+			}
+			if sent.Cmp(balance) > 0 {
+				return fmt.Errorf("insufficient balance: %s holds %s on %s", acc.Alias, token.FormatAmount(source.Name, balance), source.Name)
+			}
+
+			confirm := func(prompt string) bool {
+				fmt.Fprint(os.Stderr, prompt+" (y/N): ")
+				var response string
+				fmt.Scanln(&response)
+				return strings.ToLower(response) == "y"
+			}
+			legs := &planSender{
+				reg:          reg,
+				store:        store,
+				token:        token,
+				acc:          acc,
+				plan:         plan,
+				adapters:     adapters,
+				recipient:    common.HexToAddress(recipient),
+				slippageBPS:  slippageBPS,
+				pollInterval: arrivalPollInterval,
+				out:          os.Stderr,
+			}
+			if !yes {
+				legs.confirm = confirm
+			}
+			quote, txs, err := prepareLeg(ctx, adapters[0], legs.request(0, sent))
+			if err != nil {
+				return err
+			}
+
+			if len(plan.Legs) > 1 {
+				printPlanSummary(legs.out, plan)
+			}
+			printTransferSummary(legs.out, reg, token, acc, quote, txs)
+			if !yes {
+				if !confirm("Sign and send?") {
+					return fmt.Errorf("transfer cancelled")
+				}
+				if quote.Expired(time.Now()) {
+					return fmt.Errorf("the quote expired; run the command again for a new one")
+				}
+			}
+
+			signer, err := accountSigner(ctx, app, store, acc)
+			if err != nil {
+				return err
+			}
+			defer signer.Lock()
+			legs.send = func(ctx context.Context, chain string, tx engine.Transaction) (common.Hash, error) {
+				client, err := app.Client(ctx, chain)
+				if err != nil {
+					return common.Hash{}, err
+				}
+				return sendTransaction(ctx, legs.out, client, signer, tx)
+			}
+
+			planID, transfers, err := legs.sendAll(ctx, quote, txs)
+			return app.renderSent(plan, planID, transfers, err)
+		},
+	}
+
+	cmd.Flags().StringVarP(&from, "from", "f", "", "Source network: a name, alias or chain ID (required)")
+	cmd.Flags().StringVarP(&to, "to", "t", "", "Destination network: a name, alias or chain ID (required)")
+	cmd.Flags().StringVarP(&bridgeName, "bridge", "b", "", "Bridge service name, e.g. base-bridge (default: the best plan of bridge route)")
+	cmd.Flags().StringVarP(&amountFlag, "amount", "m", "", "Amount to send, e.g. 1.5, or max for the whole balance (required)")
+	cmd.Flags().StringVarP(&account, "account", "a", "", "Alias or address of the sending account (default: the profile's account)")
+	cmd.Flags().StringVarP(&recipient, "recipient", "r", "", "Address receiving the tokens on the destination network (default: the sending account)")
+	cmd.Flags().Uint32Var(&slippageBPS, "slippage-bps", defaultSlippageBPS, "Tolerated shortfall of the received amount, in basis points")
+	cmd.Flags().IntVar(&maxHops, "max-hops", engine.DefaultMaxHops, "Most legs of a plan chosen without --bridge")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Send every leg without asking for confirmation")
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("to")
+	cmd.MarkFlagRequired("amount")
+	return cmd
+}
+
+func bridgeRouteCmd(app *App) *cobra.Command {
+	var from, to string
+	var maxHops int
+	cmd := &cobra.Command{
+		Use:   "route <token> --from <chain> --to <chain> [--max-hops <n>]",
+		Short: "Find plans that carry a token between two chains, through other chains if needed",
+		Long: `Searches the routes of the bridges syncora can send through for plans of up to --max-hops legs,
+so that tokens can reach chains without a direct bridge, for example from one L2 to another
+through Ethereum. Plans are ranked by their indicative fee over all legs, then by total time, then
+by number of legs; syncora bridge send without --bridge follows the first.`,
+		Example: "  syncora bridge route ETH --from arbitrum --to base --max-hops 2",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if maxHops < 1 {
+				return usageError{fmt.Errorf("invalid maximum hops: %d", maxHops)}
+			}
+			reg, err := app.Registry()
+			if err != nil {
+				return err
+			}
+			token, err := reg.Token(args[0])
+			if err != nil {
+				return err
+			}
+			source, err := reg.Chain(from)
+			if err != nil {
+				return err
+			}
+			destination, err := reg.Chain(to)
+			if err != nil {
+				return err
+			}
+			if source.Name == destination.Name {
+				return usageError{fmt.Errorf("--from and --to are both %s", source.Name)}
+			}
+			bridges, err := app.Engine()
+			if err != nil {
+				return err
+			}
+			plans, err := bridges.Plans(cmd.Context(), token.Symbol, source.Name, destination.Name, maxHops)
+			if err != nil {
+				return err
+			}
+
+			type planView struct {
+				Rank       int         `json:"rank"`
+				Legs       []routeView `json:"legs"`
+				FeeBPS     uint32      `json:"fee_bps"`
+				ETASeconds int64       `json:"eta_seconds"`
+			}
+			result := struct {
+				Token string     `json:"token"`
+				From  string     `json:"from"`
+				To    string     `json:"to"`
+				Plans []planView `json:"plans"`
+			}{token.Symbol, source.Name, destination.Name, make([]planView, len(plans))}
+			for i, p := range plans {
+				view := planView{i + 1, make([]routeView, len(p.Legs)), p.FeeBPS, int64(p.ETA / time.Second)}
+				for j, r := range p.Legs {
+					view.Legs[j] = routeView{r.Bridge, r.From, r.To, r.FeeBPS, int64(r.ETA / time.Second)}
+				}
+				result.Plans[i] = view
+			}
+			return app.render(result, func(w io.Writer) {
+				fmt.Fprintln(w, "Rank\tLegs\tPath\tFee\tETA")
+				for i, p := range plans {
+					fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\n", i+1, len(p.Legs), p, formatBPS(p.FeeBPS), formatETA(p.ETA))
+				}
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&from, "from", "f", "", "Source network: a name, alias or chain ID (required)")
+	cmd.Flags().StringVarP(&to, "to", "t", "", "Destination network: a name, alias or chain ID (required)")
+	cmd.Flags().IntVar(&maxHops, "max-hops", engine.DefaultMaxHops, "Most legs of a plan")
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("to")
+	return cmd
+}
+
+func bridgeListCmd(app *App) *cobra.Command {
+	var account string
+	cmd := &cobra.Command{
+		Use:   "list [--account <alias-or-address>]",
+		Short: "List recorded transfers, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := app.Store(cmd.Context())
+			if err != nil {
+				return err
+			}
+			var address string
+			if account != "" {
+				acc, err := store.GetAccount(cmd.Context(), account)
+				if err != nil {
+					return fmt.Errorf("failed to get account: %w", err)
+				}
+				address = acc.Address
+			}
+			transfers, err := store.ListTransfers(cmd.Context(), address)
+			if err != nil {
+				return err
+			}
+			if transfers == nil {
+				transfers = []database.Transfer{}
+			}
+			reg, err := app.Registry()
+			if err != nil {
+				return err
+			}
+
+			return app.render(transfers, func(w io.Writer) {
+				if len(transfers) == 0 {
+					fmt.Fprintln(w, "No transfers found.")
+					return
+				}
+				fmt.Fprintln(w, "ID\tCreated\tAmount\tFrom\tTo\tBridge\tStatus\tPlan")
+				for _, t := range transfers {
+					plan := "-"
+					if t.PlanID != "" {
+						plan = fmt.Sprintf("%s leg %d", t.PlanID, t.Leg)
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", t.ID, t.CreatedAt.Local().Format(time.DateTime),
+						formatTokenAmount(reg, t.Token, t.FromChain, t.Amount), t.FromChain, t.ToChain, t.Bridge, t.Status, plan)
+				}
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&account, "account", "a", "", "Only list transfers sent from this account")
+	return cmd
+}
+
+// printTransferSummary writes what a transfer will do, for confirmation before signing.
+func printTransferSummary(w io.Writer, reg *registry.Registry, token *registry.Token, acc *database.Account, quote *engine.Quote, txs []engine.Transaction) {
+	route := quote.Request.Route
+	fmt.Fprintf(w, "Bridge %s from %s to %s with %s\n", token.FormatAmount(route.From, quote.Request.Amount), route.From, route.To, route.Bridge)
+	fmt.Fprintf(w, "  Account:           %s (%s)\n", acc.Alias, acc.Address)
+	fmt.Fprintf(w, "  Recipient:         %s on %s\n", quote.Request.Receiver().Hex(), route.To)
+	if len(quote.Fees) == 0 {
+		fmt.Fprintln(w, "  Fees:              none besides gas")
+	}
+	for i, fee := range quote.Fees {
+		label := ""
+		if i == 0 {
+			label = "Fees:"
+		}
+		fmt.Fprintf(w, "  %-18s %s %s on %s\n", label, fee.Name, formatTokenAmount(reg, fee.Token, fee.Chain, fee.Amount.String()), fee.Chain)
+	}
+	fmt.Fprintf(w, "  Expected received: %s\n", token.FormatAmount(route.To, quote.AmountOut))
+	fmt.Fprintf(w, "  Minimum received:  %s\n", token.FormatAmount(route.To, quote.MinAmountOut))
+	fmt.Fprintf(w, "  Estimated time:    %s\n", formatETA(quote.ETA))
+	fmt.Fprintln(w, "  Transactions:")
+	for i, tx := range txs {
+		fmt.Fprintf(w, "    %d. %s\n", i+1, tx.Description)
+	}
+}
+
+// sendPlan returns the plan bridge send follows: the bridge's direct route when one is named,
+// otherwise the best plan the engine finds.
+func sendPlan(ctx context.Context, reg *registry.Registry, bridges *engine.Registry, bridgeName, token, from, to string, maxHops int) (engine.Plan, error) {
+	if bridgeName == "" {
+		plans, err := bridges.Plans(ctx, token, from, to, maxHops)
+		if err != nil {
+			return engine.Plan{}, err
+		}
+		return plans[0], nil
+	}
+	routes, err := reg.Routes(registry.RouteFilter{Bridge: bridgeName, Token: token, From: from, To: to})
+	if err != nil {
+		return engine.Plan{}, err
+	}
+	if len(routes) == 0 {
+		return engine.Plan{}, fmt.Errorf("%w: %s %s from %s to %s", engine.ErrRouteNotSupported, bridgeName, token, from, to)
+	}
+	return engine.Plan{Legs: routes[:1], FeeBPS: routes[0].FeeBPS, ETA: routes[0].ETA}, nil
+}
+
+// prepareLeg quotes one leg of a transfer and builds its transactions.
+func prepareLeg(ctx context.Context, adapter engine.BridgeAdapter, req engine.QuoteRequest) (*engine.Quote, []engine.Transaction, error) {
+	quote, err := adapter.Quote(ctx, req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get quote from %s: %w", req.Route.Bridge, err)
+	}
+	txs, err := adapter.BuildTransaction(ctx, quote)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build %s transactions: %w", req.Route.Bridge, err)
+	}
+	return quote, txs, nil
+}
+
+// printPlanSummary writes the legs of a multi-leg transfer, for confirmation before signing.
+func printPlanSummary(w io.Writer, plan engine.Plan) {
+	fmt.Fprintf(w, "Plan of %d legs: %s\n", len(plan.Legs), plan)
+	fmt.Fprintf(w, "  Indicative fee:    %s\n", formatBPS(plan.FeeBPS))
+	fmt.Fprintf(w, "  Estimated time:    %s\n", formatETA(plan.ETA))
+	fmt.Fprintln(w, "  Each leg is quoted and sent once the previous one has arrived, with the minimum amount it")
+	fmt.Fprintln(w, "  guaranteed. Intermediate legs deliver to the account, which needs gas on every chain.")
+	fmt.Fprintln(w, "Leg 1:")
+}
+
+// formatTokenAmount formats an amount in base units of a token on a chain, falling back to the
+// chain's native currency and then to base units for symbols the registry does not know.
+func formatTokenAmount(reg *registry.Registry, symbol, chain, value string) string {
+	v, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return value + " " + symbol
+	}
+	if token, err := reg.Token(symbol); err == nil {
+		return token.FormatAmount(chain, v)
+	}
+	if c, err := reg.Chain(chain); err == nil && strings.EqualFold(c.NativeCurrency.Symbol, symbol) {
+		return amount.Format(v, c.NativeCurrency.Decimals) + " " + c.NativeCurrency.Symbol
+	}
+	return value + " base units of " + symbol
+}
+
+// tokenBalance returns the balance of a token deployment held by owner.
+func tokenBalance(ctx context.Context, client *ethclient.Client, deployment registry.Deployment, owner common.Address) (*big.Int, error) {
+	if deployment.Native {
+		balance, err := client.BalanceAt(ctx, owner, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get balance: %v", err)
+		}
+		return balance, nil
+	}
+	return engine.BalanceOf(ctx, client, common.HexToAddress(deployment.Address), owner)
+}
+
+// errTransactionFailed is returned when a sent transaction reverted.
+var errTransactionFailed = errors.New("transaction failed")
+
+// sendTransaction fills in the nonce, EIP-1559 fees and, if unset, the gas limit of tx, signs and
+// sends it, reports its hash to out, and waits for it to be mined. The hash is returned once the
+// transaction was sent, also when waiting fails.
+func sendTransaction(ctx context.Context, out io.Writer, client *ethclient.Client, signer crypto.Signer, tx engine.Transaction) (common.Hash, error) {
+	from := signer.Address()
+	nonce, err := client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get nonce: %v", err)
+	}
+	tip, err := client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get gas price: %v", err)
+	}
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get latest block: %v", err)
+	}
+	if head.BaseFee == nil {
+		return common.Hash{}, errors.New("chain does not support EIP-1559 transactions")
+	}
+	// Twice the base fee stays valid through several full blocks.
+	feeCap := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip)
+	gas := tx.Gas
+	if gas == 0 {
+		estimate, err := client.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &tx.To, Value: tx.Value, Data: tx.Data})
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("failed to estimate gas for %q: %v", tx.Description, err)
+		}
+		gas = estimate + estimate/5
+	}
+
+	chainID := new(big.Int).SetUint64(tx.ChainID)
+	unsigned := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+		Gas:       gas,
+		To:        &tx.To,
+		Value:     tx.Value,
+		Data:      tx.Data,
+	})
+	signCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	signed, _, err := signer.SignTx(signCtx, unsigned, chainID)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to sign %q: %v", tx.Description, err)
+	}
+	if err := client.SendTransaction(ctx, signed); err != nil {
+		return common.Hash{}, fmt.Errorf("failed to send %q: %v", tx.Description, err)
+	}
+	hash := signed.Hash()
+	fmt.Fprintf(out, "Sent %s: %s\n", tx.Description, hash.Hex())
+
+	waitCtx, cancel := context.WithTimeout(ctx, receiptTimeout)
+	defer cancel()
+	receipt, err := bind.WaitMined(waitCtx, client, hash)
+	if err != nil {
+		return hash, fmt.Errorf("failed waiting for %s to be mined: %v", hash.Hex(), err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return hash, fmt.Errorf("%w: %q reverted in %s", errTransactionFailed, tx.Description, hash.Hex())
+	}
+	return hash, nil
+}
+
+// planSender sends the legs of a plan one after the other from an account. Each later leg waits
+// for the previous one to arrive and sends the minimum amount it guaranteed.
+type planSender struct {
+	reg         *registry.Registry
+	store       database.AccountStore
+	token       *registry.Token
+	acc         *database.Account
+	plan        engine.Plan
+	adapters    []engine.BridgeAdapter // one per leg of plan
+	recipient   common.Address         // receives the last leg; zero for the account
+	slippageBPS uint32
+
+	// send signs and sends tx on chain and waits for it to be mined, like sendTransaction.
+	send func(ctx context.Context, chain string, tx engine.Transaction) (common.Hash, error)
+	// confirm asks whether to go on with a later leg once its summary is shown; nil sends
+	// without asking.
+	confirm func(prompt string) bool
+
+	pollInterval time.Duration // between status checks of a leg that has not arrived
+	out          io.Writer     // progress and summaries
+}
+
+// request returns the quote request for a leg sending value. Intermediate legs deliver to the
+// sending account, which sends the next leg.
+func (s *planSender) request(leg int, value *big.Int) engine.QuoteRequest {
+	req := engine.QuoteRequest{
+		Route:       s.plan.Legs[leg],
+		Amount:      value,
+		Sender:      common.HexToAddress(s.acc.Address),
+		SlippageBPS: s.slippageBPS,
+	}
+	if leg == len(s.plan.Legs)-1 {
+		req.Recipient = s.recipient
+	}
+	return req
+}
+
+// sendAll sends the first leg with its confirmed quote and transactions, then each later leg once
+// the previous one has arrived. It returns the plan ID, empty for a single leg, and the recorded
+// transfers of the legs sent, also on failure.
+func (s *planSender) sendAll(ctx context.Context, quote *engine.Quote, txs []engine.Transaction) (string, []*database.Transfer, error) {
+	var planID string
+	if len(s.plan.Legs) > 1 {
+		planID = newTransferID()
+	}
+	var transfers []*database.Transfer
+	for i, leg := range s.plan.Legs {
+		if i > 0 {
+			if err := s.awaitArrival(ctx, quote, transfers[i-1]); err != nil {
+				return planID, transfers, err
+			}
+			var err error
+			if quote, txs, err = s.prepareLater(ctx, i, quote.MinAmountOut); err != nil {
+				return planID, transfers, fmt.Errorf("transfer %s: leg %d: %w", transfers[i-1].ID, i+1, err)
+			}
+		}
+
+		transfer := &database.Transfer{
+			ID:           newTransferID(),
+			Account:      s.acc.Address,
+			Bridge:       leg.Bridge,
+			Token:        s.token.Symbol,
+			FromChain:    leg.From,
+			ToChain:      leg.To,
+			Amount:       quote.Request.Amount.String(),
+			MinAmountOut: quote.MinAmountOut.String(),
+			Recipient:    quote.Request.Receiver().Hex(),
+			Status:       string(engine.StatePending),
+			Data:         quote.Data,
+			CreatedAt:    time.Now().UTC(),
+		}
+		if planID != "" {
+			transfer.PlanID, transfer.Leg = planID, i+1
+		}
+		err := s.sendLeg(ctx, transfer, txs)
+		transfers = append(transfers, transfer)
+		if err != nil {
+			return planID, transfers, err
+		}
+	}
+	return planID, transfers, nil
+}
+
+// planResult is the structured output of bridge send for a multi-leg plan: the legs sent, and why
+// the plan stopped if it did not complete.
+type planResult struct {
+	PlanID    string               `json:"plan_id"`
+	Transfers []*database.Transfer `json:"transfers"`
+	Error     *errorDetail         `json:"error,omitempty"`
+}
+
+// renderSent renders the outcome of sendAll. A single transfer is rendered on its own and only if
+// it was sent. The legs of a plan are rendered even if the plan stopped early, with err in the
+// result, which is then returned marked as reported.
+func (a *App) renderSent(plan engine.Plan, planID string, transfers []*database.Transfer, err error) error {
+	table := func(w io.Writer) {
+		if planID != "" {
+			fmt.Fprintf(w, "Plan %s: %s\n", planID, plan)
+		}
+		for _, t := range transfers {
+			if t.Leg > 0 {
+				fmt.Fprintf(w, "Leg %d: ", t.Leg)
+			}
+			fmt.Fprintf(w, "Transfer sent: id=%s, status=%s\n", t.ID, t.Status)
+			for _, hash := range t.TxHashes {
+				fmt.Fprintf(w, "  %s\n", hash)
+			}
+		}
+	}
+	if planID == "" {
+		if err != nil {
+			return err
+		}
+		return a.render(transfers[0], table)
+	}
+
+	result := planResult{PlanID: planID, Transfers: transfers}
+	if err != nil {
+		detail := newErrorDetail(err)
+		result.Error = &detail
+	}
+	renderErr := a.render(result, table)
+	switch {
+	case err == nil:
+		return renderErr
+	case renderErr != nil:
+		return err
+	}
+	return reportedError{err}
+}
+
+// prepareLater quotes a later leg for the amount the previous leg delivered and shows it. Unless
+// confirm is nil, it asks before the leg is sent and quotes again if the quote expired meanwhile.
+func (s *planSender) prepareLater(ctx context.Context, leg int, value *big.Int) (*engine.Quote, []engine.Transaction, error) {
+	for {
+		quote, txs, err := prepareLeg(ctx, s.adapters[leg], s.request(leg, value))
+		if err != nil {
+			return nil, nil, err
+		}
+		fmt.Fprintf(s.out, "Leg %d of %d:\n", leg+1, len(s.plan.Legs))
+		printTransferSummary(s.out, s.reg, s.token, s.acc, quote, txs)
+		if s.confirm == nil {
+			return quote, txs, nil
+		}
+		if !s.confirm(fmt.Sprintf("Sign and send leg %d?", leg+1)) {
+			from := s.plan.Legs[leg].From
+			return nil, nil, fmt.Errorf("cancelled; %s remains on %s", s.token.FormatAmount(from, value), from)
+		}
+		if !quote.Expired(time.Now()) {
+			return quote, txs, nil
+		}
+		fmt.Fprintln(s.out, "The quote expired; quoting again.")
+	}
+}
+
+// sendLeg records transfer as pending, sends its transactions one after the other and marks it in
+// flight once they are mined.
+func (s *planSender) sendLeg(ctx context.Context, transfer *database.Transfer, txs []engine.Transaction) error {
+	if err := s.store.SaveTransfer(ctx, *transfer); err != nil {
+		return fmt.Errorf("failed to record transfer: %w", err)
+	}
+	for _, tx := range txs {
+		hash, err := s.send(ctx, transfer.FromChain, tx)
+		if hash != (common.Hash{}) {
+			transfer.TxHashes = append(transfer.TxHashes, hash.Hex())
+		}
+		if errors.Is(err, errTransactionFailed) || hash == (common.Hash{}) && err != nil {
+			transfer.Status = string(engine.StateFailed)
+		}
+		saveTransfer(ctx, s.store, transfer)
+		if err != nil {
+			return fmt.Errorf("transfer %s: %w", transfer.ID, err)
+		}
+	}
+	transfer.Status = string(engine.StateInFlight)
+	saveTransfer(ctx, s.store, transfer)
+	return nil
+}
+
+// awaitArrival polls the bridge until the leg sent for quote has arrived, recording its progress in
+// transfer. It fails if the leg ends in any other state or takes more than twice its expected time.
+func (s *planSender) awaitArrival(ctx context.Context, quote *engine.Quote, transfer *database.Transfer) error {
+	timeout := 2*quote.ETA + arrivalMargin
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	tracked := engine.Transfer{
+		Route:        quote.Request.Route,
+		SourceTxHash: common.HexToHash(transfer.TxHashes[len(transfer.TxHashes)-1]),
+		Sender:       quote.Request.Sender,
+		Recipient:    quote.Request.Receiver(),
+		Amount:       quote.Request.Amount,
+		Data:         quote.Data,
+	}
+	fmt.Fprintf(s.out, "Waiting for leg %d to arrive on %s (%s)...\n", transfer.Leg, transfer.ToChain, formatETA(quote.ETA))
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		status, err := s.adapters[transfer.Leg-1].TrackStatus(ctx, tracked)
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("failed to get transfer status", "id", transfer.ID, "err", err)
+		}
+		if err == nil {
+			destination := ""
+			if status.DestinationTxHash != (common.Hash{}) {
+				destination = status.DestinationTxHash.Hex()
+			}
+			if string(status.State) != transfer.Status || destination != transfer.DestinationTxHash {
+				transfer.Status, transfer.DestinationTxHash = string(status.State), destination
+				saveTransfer(ctx, s.store, transfer)
+			}
+			if status.State == engine.StateCompleted {
+				return nil
+			}
+			if status.State.Final() {
+				return fmt.Errorf("transfer %s: leg %d ended %s", transfer.ID, transfer.Leg, status.State)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("transfer %s: leg %d did not arrive on %s within %s; it is still %s", transfer.ID, transfer.Leg, transfer.ToChain, timeout, transfer.Status)
+		case <-ticker.C:
+		}
+	}
+}
+
+// saveTransfer records the progress of a transfer whose transactions are being sent. Failures only
+// warn, since the transactions cannot be taken back.
+func saveTransfer(ctx context.Context, store database.AccountStore, t *database.Transfer) {
+	if err := store.SaveTransfer(ctx, *t); err != nil {
+		slog.Warn("failed to record transfer", "id", t.ID, "status", t.Status, "err", err)
+	}
+}
+
+// newTransferID returns a random transfer ID of 16 hex characters.
+func newTransferID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,307 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	engine "github.com/xilverfang/syncora/internal/bridge-engine"
+	"github.com/xilverfang/syncora/internal/core/crypto"
+	"github.com/xilverfang/syncora/internal/core/database"
+	"github.com/xilverfang/syncora/internal/core/registry"
+	"gopkg.in/yaml.v3"
+)
+
+// legAdapter quotes a fee of 1% and a slippage of 1%, builds one deposit transaction and reports
+// the given states, one per TrackStatus call, repeating the last.
+type legAdapter struct {
+	route  registry.BridgeRoute
+	states []engine.TransferState
+	fill   common.Hash
+
+	mu       sync.Mutex
+	requests []engine.QuoteRequest
+	tracked  []engine.Transfer
+}
+
+func (f *legAdapter) Name() string { return f.route.Bridge }
+
+func (f *legAdapter) SupportedRoutes(ctx context.Context) ([]registry.BridgeRoute, error) {
+	return []registry.BridgeRoute{f.route}, nil
+}
+
+func (f *legAdapter) Quote(ctx context.Context, req engine.QuoteRequest) (*engine.Quote, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, req)
+	out := new(big.Int).Div(new(big.Int).Mul(req.Amount, big.NewInt(99)), big.NewInt(100))
+	return &engine.Quote{
+		Request:      req,
+		AmountOut:    out,
+		MinAmountOut: new(big.Int).Div(new(big.Int).Mul(out, big.NewInt(99)), big.NewInt(100)),
+		ETA:          time.Minute,
+		ExpiresAt:    time.Now().Add(time.Minute),
+		Data:         map[string]string{"leg": f.route.From},
+	}, nil
+}
+
+func (f *legAdapter) BuildTransaction(ctx context.Context, quote *engine.Quote) ([]engine.Transaction, error) {
+	return []engine.Transaction{{Description: "Deposit with " + f.route.Bridge, Value: quote.Request.Amount}}, nil
+}
+
+func (f *legAdapter) TrackStatus(ctx context.Context, transfer engine.Transfer) (*engine.Status, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tracked = append(f.tracked, transfer)
+	state := f.states[min(len(f.tracked), len(f.states))-1]
+	status := &engine.Status{State: state}
+	if state == engine.StateCompleted {
+		status.DestinationTxHash = f.fill
+	}
+	return status, nil
+}
+
+func (f *legAdapter) EstimateTime(ctx context.Context, route registry.BridgeRoute) (time.Duration, error) {
+	return time.Minute, nil
+}
+
+// sentTx is a transaction handed to planSender.send.
+type sentTx struct {
+	chain string
+	tx    engine.Transaction
+}
+
+func newPlanSender(t *testing.T, first, second *legAdapter, answers ...bool) (*planSender, *[]sentTx, *[]string) {
+	t.Helper()
+	reg, err := registry.Default()
+	if err != nil {
+		t.Fatalf("Default: %v", err)
+	}
+	token, err := reg.Token("ETH")
+	if err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	store, err := database.NewFileStore(filepath.Join(t.TempDir(), "vault.json"), func(ctx context.Context, create bool) ([]byte, error) {
+		return []byte("vault pass"), nil
+	}, crypto.KDFTest)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+
+	var sent []sentTx
+	var prompts []string
+	s := &planSender{
+		reg:          reg,
+		store:        store,
+		token:        token,
+		acc:          &database.Account{Alias: "wallet", Address: "0x1111111111111111111111111111111111111111"},
+		plan:         engine.Plan{Legs: []registry.BridgeRoute{first.route, second.route}},
+		adapters:     []engine.BridgeAdapter{first, second},
+		recipient:    common.HexToAddress("0x2222222222222222222222222222222222222222"),
+		slippageBPS:  100,
+		pollInterval: time.Millisecond,
+		out:          io.Discard,
+		send: func(ctx context.Context, chain string, tx engine.Transaction) (common.Hash, error) {
+			sent = append(sent, sentTx{chain, tx})
+			return common.BigToHash(big.NewInt(int64(len(sent)))), nil
+		},
+	}
+	if answers != nil {
+		s.confirm = func(prompt string) bool {
+			prompts = append(prompts, prompt)
+			answer := answers[0]
+			answers = answers[1:]
+			return answer
+		}
+	}
+	return s, &sent, &prompts
+}
+
+func legAdapters() (*legAdapter, *legAdapter) {
+	first := &legAdapter{
+		route:  registry.BridgeRoute{Bridge: "across", Token: "ETH", From: "arbitrum", To: "ethereum"},
+		states: []engine.TransferState{engine.StateInFlight, engine.StateInFlight, engine.StateCompleted},
+		fill:   common.HexToHash("0xf111"),
+	}
+	second := &legAdapter{
+		route:  registry.BridgeRoute{Bridge: "base-bridge", Token: "ETH", From: "ethereum", To: "base"},
+		states: []engine.TransferState{engine.StateInFlight},
+	}
+	return first, second
+}
+
+func TestPlanSenderSendAll(t *testing.T) {
+	ctx := context.Background()
+	first, second := legAdapters()
+	s, sent, prompts := newPlanSender(t, first, second, true)
+
+	quote, txs, err := prepareLeg(ctx, first, s.request(0, big.NewInt(1e18)))
+	if err != nil {
+		t.Fatalf("prepareLeg: %v", err)
+	}
+	if quote.Request.Recipient != (common.Address{}) {
+		t.Errorf("leg 1 delivers to %s, want the account", quote.Request.Recipient.Hex())
+	}
+	planID, transfers, err := s.sendAll(ctx, quote, txs)
+	if err != nil {
+		t.Fatalf("sendAll: %v", err)
+	}
+
+	if planID == "" || len(transfers) != 2 {
+		t.Fatalf("sendAll = %q, %d transfers", planID, len(transfers))
+	}
+	if len(first.tracked) != 3 || first.tracked[0].SourceTxHash != common.BigToHash(big.NewInt(1)) || first.tracked[0].Data["leg"] != "arbitrum" {
+		t.Errorf("leg 1 tracked %+v, want 3 polls of the first transaction", first.tracked)
+	}
+	if len(second.requests) != 1 {
+		t.Fatalf("leg 2 quoted %d times", len(second.requests))
+	}
+	req := second.requests[0]
+	if req.Amount.Cmp(quote.MinAmountOut) != 0 || req.Sender != common.HexToAddress(s.acc.Address) || req.Recipient != s.recipient || req.SlippageBPS != 100 {
+		t.Errorf("leg 2 request %+v, want the minimum of leg 1 (%s) sent to the recipient", req, quote.MinAmountOut)
+	}
+	if len(*prompts) != 1 || !strings.Contains((*prompts)[0], "leg 2") {
+		t.Errorf("prompts = %q, want one for leg 2", *prompts)
+	}
+	if len(*sent) != 2 || (*sent)[0].chain != "arbitrum" || (*sent)[1].chain != "ethereum" || (*sent)[1].tx.Value.Cmp(quote.MinAmountOut) != 0 {
+		t.Errorf("sent %+v", *sent)
+	}
+
+	leg1, leg2 := transfers[0], transfers[1]
+	if leg1.PlanID != planID || leg1.Leg != 1 || leg1.Status != string(engine.StateCompleted) || leg1.DestinationTxHash != first.fill.Hex() {
+		t.Errorf("leg 1 = %+v", leg1)
+	}
+	if leg2.PlanID != planID || leg2.Leg != 2 || leg2.Status != string(engine.StateInFlight) || leg2.Amount != leg1.MinAmountOut ||
+		leg2.Recipient != s.recipient.Hex() || len(leg2.TxHashes) != 1 {
+		t.Errorf("leg 2 = %+v", leg2)
+	}
+	stored, err := s.store.ListTransfers(ctx, s.acc.Address)
+	if err != nil {
+		t.Fatalf("ListTransfers: %v", err)
+	}
+	statuses := map[int]string{}
+	for _, tr := range stored {
+		statuses[tr.Leg] = tr.Status
+	}
+	if len(stored) != 2 || statuses[1] != "completed" || statuses[2] != "in_flight" {
+		t.Errorf("stored transfers %+v", stored)
+	}
+}
+
+func TestPlanSenderStops(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		answers []bool
+		states  []engine.TransferState
+		wantErr string
+		quoted  int
+	}{
+		{"declined", []bool{false}, []engine.TransferState{engine.StateCompleted}, "cancelled; 0.9801 ETH remains on ethereum", 1},
+		{"refunded", []bool{true}, []engine.TransferState{engine.StateInFlight, engine.StateRefunded}, "leg 1 ended refunded", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, second := legAdapters()
+			first.states = tt.states
+			s, sent, _ := newPlanSender(t, first, second, tt.answers...)
+			quote, txs, err := prepareLeg(ctx, first, s.request(0, big.NewInt(1e18)))
+			if err != nil {
+				t.Fatalf("prepareLeg: %v", err)
+			}
+			_, transfers, err := s.sendAll(ctx, quote, txs)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("sendAll error = %v, want %q", err, tt.wantErr)
+			}
+			if len(transfers) != 1 || len(*sent) != 1 || len(second.requests) != tt.quoted {
+				t.Errorf("sent %d transfers and %d transactions, quoted leg 2 %d times", len(transfers), len(*sent), len(second.requests))
+			}
+			stored, _ := s.store.ListTransfers(ctx, "")
+			if len(stored) != 1 || stored[0].Status != string(tt.states[len(tt.states)-1]) {
+				t.Errorf("stored transfers %s", fmt.Sprint(stored))
+			}
+		})
+	}
+}
+
+func TestRenderSent(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		states    []engine.TransferState
+		transfers int
+		wantErr   string
+	}{
+		{"completed", []engine.TransferState{engine.StateCompleted}, 2, ""},
+		{"stopped", []engine.TransferState{engine.StateRefunded}, 1, "leg 1 ended refunded"},
+	}
+	for _, tt := range tests {
+		for _, output := range []string{outputJSON, outputYAML} {
+			t.Run(tt.name+"/"+output, func(t *testing.T) {
+				first, second := legAdapters()
+				first.states = tt.states
+				s, _, _ := newPlanSender(t, first, second, true)
+				quote, txs, err := prepareLeg(ctx, first, s.request(0, big.NewInt(1e18)))
+				if err != nil {
+					t.Fatalf("prepareLeg: %v", err)
+				}
+				planID, transfers, sendErr := s.sendAll(ctx, quote, txs)
+
+				var out bytes.Buffer
+				app := &App{output: output, stdout: &out}
+				err = app.renderSent(s.plan, planID, transfers, sendErr)
+				if tt.wantErr == "" && err != nil {
+					t.Fatalf("renderSent: %v", err)
+				}
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("renderSent error = %v, want %q", err, tt.wantErr)
+					}
+					// The error is part of the result; no second object is written.
+					n := out.Len()
+					app.ReportError(err)
+					if out.Len() != n {
+						t.Errorf("ReportError wrote %q after the result", out.String()[n:])
+					}
+				}
+
+				// YAML uses the JSON field names; decode it generically and convert it to JSON.
+				data := out.Bytes()
+				if output == outputYAML {
+					var doc any
+					if err := yaml.Unmarshal(data, &doc); err != nil {
+						t.Fatalf("decode %q: %v", out.String(), err)
+					}
+					data, _ = json.Marshal(doc)
+				}
+				var got planResult
+				if err := json.Unmarshal(data, &got); err != nil {
+					t.Fatalf("decode %q: %v", out.String(), err)
+				}
+				if got.PlanID != planID || len(got.Transfers) != tt.transfers {
+					t.Fatalf("rendered plan %q with %d transfers, want %q with %d", got.PlanID, len(got.Transfers), planID, tt.transfers)
+				}
+				for i, tr := range got.Transfers {
+					if tr.ID != transfers[i].ID || tr.Leg != i+1 || tr.PlanID != planID {
+						t.Errorf("transfer %d = %+v", i, tr)
+					}
+				}
+				if tt.wantErr == "" && got.Error != nil {
+					t.Errorf("completed plan has error %+v", got.Error)
+				}
+				if tt.wantErr != "" && (got.Error == nil || got.Error.Code != codeError || got.Error.Message != sendErr.Error()) {
+					t.Errorf("error = %+v, want %q", got.Error, sendErr)
+				}
+			})
+		}
+	}
+}
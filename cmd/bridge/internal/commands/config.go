@@ -0,0 +1,176 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+
+	"github.com/xilverfang/syncora/cmd/bridge/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// annotationNewProfile marks commands that may run with a profile that is not defined yet: 'config
+// set', which creates it, and 'config use-profile', which replaces it.
+const annotationNewProfile = "syncora/new-profile"
+
+// dsnPassword matches the password of a key=value PostgreSQL connection string.
+var dsnPassword = regexp.MustCompile(`(password=)('[^']*'|\S+)`)
+
+// settingView is a resolved configuration value and the layer it came from.
+type settingView struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+func ConfigCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage configuration profiles in ~/.syncora/config.yaml",
+		Long: `Commands to read and change the named profiles in ~/.syncora/config.yaml ($SYNCORA_CONFIG).
+A profile selects the storage backend, KDF profile, default account, chain RPC endpoints and output
+format. Settings are resolved from flags, then environment variables, then the selected profile,
+then the defaults. The profile is chosen with --profile, $SYNCORA_PROFILE or 'config use-profile'.`,
+	}
+
+	cmd.AddCommand(configGetCmd(app))
+	cmd.AddCommand(configSetCmd(app))
+	cmd.AddCommand(configUseProfileCmd(app))
+	return cmd
+}
+
+func configGetCmd(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get [key]",
+		Short: "Show the effective settings of the selected profile and where they come from",
+		Long: `Shows the value of key, or of every setting, as resolved for the selected profile, and
+whether it comes from a flag, the environment, the profile or the defaults. Passwords in db_url
+are masked.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := app.config
+			keys := slices.Clone(config.Keys)
+			for _, chain := range slices.Sorted(maps.Keys(cfg.Active().RPC)) {
+				keys = append(keys, config.KeyRPCPrefix+chain)
+			}
+			if len(args) == 1 {
+				keys = args
+			}
+
+			settings := make([]settingView, 0, len(keys))
+			for _, key := range keys {
+				value, source, err := cfg.Lookup(key)
+				if err != nil {
+					return usageError{err}
+				}
+				if key == config.KeyOutput && cmd.Flags().Changed("output") {
+					value, source = app.output, config.SourceFlag
+				}
+				if key == config.KeyDBURL {
+					value = maskURL(value)
+				}
+				settings = append(settings, settingView{key, value, source})
+			}
+
+			if len(args) == 1 {
+				s := settings[0]
+				return app.render(s, func(w io.Writer) {
+					fmt.Fprintln(w, s.Value)
+				})
+			}
+			result := struct {
+				Profile  string        `json:"profile"`
+				File     string        `json:"file"`
+				Settings []settingView `json:"settings"`
+			}{cfg.Profile, cfg.Path, settings}
+			return app.render(result, func(w io.Writer) {
+				fmt.Fprintf(w, "Profile: %s (%s)\n", result.Profile, result.File)
+				fmt.Fprintln(w, "Key\tValue\tSource")
+				for _, s := range settings {
+					fmt.Fprintf(w, "%s\t%s\t%s\n", s.Key, s.Value, s.Source)
+				}
+			})
+		},
+	}
+}
+
+func configSetCmd(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Change a setting of the selected profile, creating the profile if needed",
+		Long: `Stores value under key in the selected profile. Keys are store, db_url, store_path, kdf,
+account, output and rpc.<chain>; an empty value removes the key. Use --profile to change a profile
+other than the current one.`,
+		Example: `  syncora config set --profile dev store sqlite
+  syncora config set --profile prod rpc.ethereum https://eth.example.com
+  syncora config set account ""`,
+		Args:        cobra.ExactArgs(2),
+		Annotations: map[string]string{annotationNewProfile: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, value := args[0], args[1]
+			cfg := app.config
+			if err := cfg.Edit().Set(key, value); err != nil {
+				return usageError{err}
+			}
+			if err := cfg.File.Save(cfg.Path); err != nil {
+				return err
+			}
+
+			shown := value
+			if key == config.KeyDBURL {
+				shown = maskURL(value)
+			}
+			result := struct {
+				Profile string `json:"profile"`
+				Key     string `json:"key"`
+				Value   string `json:"value"`
+			}{cfg.Profile, key, shown}
+			return app.render(result, func(w io.Writer) {
+				if value == "" {
+					fmt.Fprintf(w, "Setting removed: profile=%s, key=%s\n", result.Profile, result.Key)
+					return
+				}
+				fmt.Fprintf(w, "Setting saved: profile=%s, %s=%s\n", result.Profile, result.Key, result.Value)
+			})
+		},
+	}
+}
+
+func configUseProfileCmd(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:         "use-profile <name>",
+		Short:       "Select the profile used when neither --profile nor $SYNCORA_PROFILE is set",
+		Args:        cobra.ExactArgs(1),
+		Annotations: map[string]string{annotationNewProfile: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			cfg := app.config
+			if _, ok := cfg.File.Profiles[name]; !ok && name != config.DefaultProfile {
+				return fmt.Errorf("%w: %s (create it with 'syncora config set --profile %s <key> <value>')", config.ErrUnknownProfile, name, name)
+			}
+			cfg.File.CurrentProfile = name
+			if err := cfg.File.Save(cfg.Path); err != nil {
+				return err
+			}
+
+			result := struct {
+				Profile string `json:"profile"`
+			}{name}
+			return app.render(result, func(w io.Writer) {
+				fmt.Fprintf(w, "Current profile: %s\n", name)
+			})
+		},
+	}
+}
+
+// maskURL hides the password of a connection URL or a key=value connection string.
+func maskURL(raw string) string {
+	if u, err := url.Parse(raw); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(raw, "${1}xxxxx")
+}
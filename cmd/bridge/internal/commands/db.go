@@ -0,0 +1,189 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/xilverfang/syncora/internal/core/database"
+
+	"github.com/spf13/cobra"
+)
+
+func DBCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Manage the account database schema",
+		Long: `Commands to apply, inspect and revert the versioned schema migrations embedded in syncora.
+They apply to the postgres and sqlite backends; the vault file backend has no schema.`,
+	}
+
+	cmd.AddCommand(dbMigrateCmd(app))
+	cmd.AddCommand(dbStatusCmd(app))
+	cmd.AddCommand(dbRollbackCmd(app))
+
+	return cmd
+}
+
+func dbMigrateCmd(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply all pending schema migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			migrator, err := app.Migrator(cmd.Context())
+			if err != nil {
+				return err
+			}
+			applied, err := migrator.Migrate(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to migrate: %w", err)
+			}
+			result := struct {
+				Applied []migrationView `json:"applied"`
+			}{newMigrationViews(applied)}
+			return app.render(result, func(w io.Writer) {
+				for _, m := range result.Applied {
+					fmt.Fprintf(w, "Applied: %04d_%s\n", m.Version, m.Name)
+				}
+				if len(result.Applied) == 0 {
+					fmt.Fprintln(w, "Schema is up to date.")
+				}
+			})
+		},
+	}
+}
+
+func dbStatusCmd(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show applied and pending schema migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			migrator, err := app.Migrator(cmd.Context())
+			if err != nil {
+				return err
+			}
+			status, err := migrator.MigrationStatus(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to read migration status: %w", err)
+			}
+
+			views := make([]migrationStatusView, 0, len(status))
+			for _, st := range status {
+				v := migrationStatusView{Version: st.Version, Name: st.Name, Status: "pending"}
+				if st.Applied {
+					v.Status = "applied"
+					v.AppliedAt = st.AppliedAt.UTC()
+				}
+				switch {
+				case st.Unknown:
+					v.Status = "unknown"
+				case st.Modified:
+					v.Status = "modified"
+				}
+				views = append(views, v)
+			}
+			return app.render(views, func(w io.Writer) {
+				fmt.Fprintln(w, "Version\tName\tStatus\tApplied At")
+				for _, v := range views {
+					state := v.Status
+					switch state {
+					case "unknown":
+						state = "unknown (newer syncora?)"
+					case "modified":
+						state = "modified after apply"
+					}
+					appliedAt := "-"
+					if !v.AppliedAt.IsZero() {
+						appliedAt = v.AppliedAt.Format(time.RFC3339)
+					}
+					fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", v.Version, v.Name, state, appliedAt)
+				}
+			})
+		},
+	}
+}
+
+func dbRollbackCmd(app *App) *cobra.Command {
+	var steps int
+	var yes bool
+	cmd := &cobra.Command{
+		Use:   "rollback [--steps <n>] [--yes]",
+		Short: "Revert the most recently applied schema migrations",
+		Long: `Runs the down scripts of the last --steps applied migrations, newest first. Reverting a
+migration drops the columns or tables it added, along with any data stored in them, so such
+rollbacks ask for confirmation first. The baseline migration, which holds the accounts and their
+keys, is never reverted.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			migrator, err := app.Migrator(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if !yes {
+				status, err := migrator.MigrationStatus(cmd.Context())
+				if err != nil {
+					return fmt.Errorf("failed to read migration status: %w", err)
+				}
+				var dropping []string
+				for i, n := len(status)-1, 0; i >= 0 && n < steps; i-- {
+					if st := status[i]; st.Applied {
+						n++
+						if st.DropsData || st.Unknown {
+							dropping = append(dropping, fmt.Sprintf("%04d_%s", st.Version, st.Name))
+						}
+					}
+				}
+				if len(dropping) > 0 {
+					fmt.Fprintf(os.Stderr, "Reverting %s drops tables or columns and the data in them. Continue? (y/N): ", strings.Join(dropping, ", "))
+					var response string
+					fmt.Scanln(&response)
+					if strings.ToLower(response) != "y" {
+						return fmt.Errorf("rollback cancelled")
+					}
+				}
+			}
+			reverted, err := migrator.Rollback(cmd.Context(), steps)
+			if err != nil {
+				return fmt.Errorf("failed to roll back: %w", err)
+			}
+			result := struct {
+				Reverted []migrationView `json:"reverted"`
+			}{newMigrationViews(reverted)}
+			return app.render(result, func(w io.Writer) {
+				for _, m := range result.Reverted {
+					fmt.Fprintf(w, "Reverted: %04d_%s\n", m.Version, m.Name)
+				}
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&steps, "steps", 1, "Number of migrations to revert")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Revert without asking for confirmation")
+	return cmd
+}
+
+// migrationView is how a migration appears in db migrate and db rollback results.
+type migrationView struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+}
+
+func newMigrationViews(migrations []database.Migration) []migrationView {
+	views := make([]migrationView, 0, len(migrations))
+	for _, m := range migrations {
+		views = append(views, migrationView{Version: m.Version, Name: m.Name})
+	}
+	return views
+}
+
+// migrationStatusView is a row of db status: pending, applied, modified or unknown.
+type migrationStatusView struct {
+	Version   int       `json:"version"`
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	AppliedAt time.Time `json:"applied_at,omitzero"`
+}
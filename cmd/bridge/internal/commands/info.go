@@ -1,81 +1,105 @@
 package commands
 
 import (
-	"context"
-	"encoding/hex"
+	"cmp"
 	"fmt"
-	"os"
-	"syscall"
+	"io"
+	"slices"
 	"time"
 
-	"github.com/xilverfang/syncora/internal/core/crypto"
-	"github.com/xilverfang/syncora/internal/core/database"
+	"github.com/xilverfang/syncora/internal/core/registry"
 
 	"github.com/spf13/cobra"
-	"golang.org/x/term"
 )
 
-func InfoCmd() *cobra.Command {
+func InfoCmd(app *App) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "info",
-		Short: "Retrieve information about accounts or bridge services",
-		Long:  `Commands to check account details or bridge service status, using securely stored accounts.`,
+		Short: "Retrieve information about bridge services",
+		Long:  `Commands to discover the bridges, networks, fees and transfer times available for a token.`,
 	}
 
-	cmd.AddCommand(infoCheckCmd())
+	cmd.AddCommand(infoCheckCmd(app))
 	return cmd
 }
 
-func infoCheckCmd() *cobra.Command {
-	var account string
+// routeView is a bridge route as shown by info check.
+type routeView struct {
+	Bridge     string `json:"bridge"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	FeeBPS     uint32 `json:"fee_bps"`
+	ETASeconds int64  `json:"eta_seconds"`
+}
+
+func infoCheckCmd(app *App) *cobra.Command {
+	var network string
 	cmd := &cobra.Command{
-		Use:   "check --account <alias-or-address>",
-		Short: "Check account details",
-		Args:  cobra.NoArgs,
+		Use:   "check <token> [--network <chain>]",
+		Short: "List the bridges and networks that support a token",
+		Long: `Lists the routes the chain, token and bridge registry knows for a token, with indicative fees
+and transfer times. Bridges quote actual fees when a transfer is prepared.`,
+		Example: "  syncora info check ETH --network mainnet",
+		Args:    cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Fprintln(os.Stderr, "Starting account check")
-			acc, err := database.GetAccount(account)
-			if err != nil {
-				return fmt.Errorf("failed to get account: %v", err)
-			}
-
-			fmt.Fprint(os.Stdout, "Enter passphrase to decrypt private key (input hidden): ")
-			passphrase, err := term.ReadPassword(int(syscall.Stdin))
-			fmt.Fprintln(os.Stdout)
+			reg, err := app.Registry()
 			if err != nil {
-				return fmt.Errorf("failed to read passphrase: %v", err)
+				return err
 			}
-
-			salt, err := hex.DecodeString(acc.Salt)
+			token, err := reg.Token(args[0])
 			if err != nil {
-				return fmt.Errorf("failed to decode salt: %v", err)
+				return err
 			}
-
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			fmt.Fprintln(os.Stderr, "Decrypting private key")
-			privateKeyHex, err := crypto.DecryptPrivateKey(ctx, acc.EncryptedKey, passphrase, salt, crypto.KeyVersion(acc.KeyVersion))
+			routes, err := reg.Routes(registry.RouteFilter{Token: token.Symbol, From: network})
 			if err != nil {
-				return fmt.Errorf("failed to decrypt private key: %v", err)
+				return err
 			}
+			slices.SortStableFunc(routes, func(a, b registry.BridgeRoute) int {
+				return cmp.Or(cmp.Compare(a.From, b.From), cmp.Compare(a.To, b.To), cmp.Compare(a.ETA, b.ETA))
+			})
 
-			// Convert to byte slice for zeroing
-			privateKeyBytes := []byte(privateKeyHex)
-			fmt.Fprintln(os.Stderr, "Private key decrypted for address:", acc.Address[:10]+"...")
-			fmt.Fprintf(os.Stdout, "Account details: alias=%s, address=%s, key_version=%d\n", acc.Alias, acc.Address, acc.KeyVersion)
-
-			// Zero sensitive data
-			for i := range privateKeyBytes {
-				privateKeyBytes[i] = 0
+			result := struct {
+				Token  string      `json:"token"`
+				Routes []routeView `json:"routes"`
+			}{token.Symbol, make([]routeView, len(routes))}
+			for i, r := range routes {
+				result.Routes[i] = routeView{r.Bridge, r.From, r.To, r.FeeBPS, int64(r.ETA / time.Second)}
 			}
-			for i := range passphrase {
-				passphrase[i] = 0
-			}
-			return nil
+			return app.render(result, func(w io.Writer) {
+				if len(routes) == 0 {
+					fmt.Fprintf(w, "No routes found for %s.\n", token.Symbol)
+					return
+				}
+				fmt.Fprintln(w, "Bridge\tFrom\tTo\tFee\tETA")
+				for _, r := range routes {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Bridge, r.From, r.To, formatBPS(r.FeeBPS), formatETA(r.ETA))
+				}
+			})
 		},
 	}
 
-	cmd.Flags().StringVarP(&account, "account", "a", "", "Alias or address of the account to check (required)")
-	cmd.MarkFlagRequired("account")
+	cmd.Flags().StringVarP(&network, "network", "n", "", "Only list routes from this network (name, alias or chain ID)")
 	return cmd
-}
\ No newline at end of file
+}
+
+// formatBPS formats basis points as a percentage, e.g. 6 as 0.06%.
+func formatBPS(bps uint32) string {
+	return fmt.Sprintf("%d.%02d%%", bps/100, bps%100)
+}
+
+// formatETA formats an indicative duration in its largest whole units, e.g. ~7d or ~1h30m.
+func formatETA(d time.Duration) string {
+	day := 24 * time.Hour
+	switch {
+	case d >= day && d%day == 0:
+		return fmt.Sprintf("~%dd", d/day)
+	case d >= time.Hour && d%time.Hour < time.Minute:
+		return fmt.Sprintf("~%dh", d/time.Hour)
+	case d >= time.Hour:
+		return fmt.Sprintf("~%dh%dm", d/time.Hour, d%time.Hour/time.Minute)
+	case d >= time.Minute:
+		return fmt.Sprintf("~%dm", d/time.Minute)
+	default:
+		return fmt.Sprintf("~%ds", d/time.Second)
+	}
+}
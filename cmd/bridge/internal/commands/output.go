@@ -0,0 +1,262 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"github.com/xilverfang/syncora/cmd/bridge/internal/config"
+	engine "github.com/xilverfang/syncora/internal/bridge-engine"
+	"github.com/xilverfang/syncora/internal/core/amount"
+	"github.com/xilverfang/syncora/internal/core/crypto"
+	"github.com/xilverfang/syncora/internal/core/database"
+	"github.com/xilverfang/syncora/internal/core/logging"
+	"github.com/xilverfang/syncora/internal/core/registry"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats selected with the global --output flag.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// Error codes reported in the error object of --output json and yaml. They are part of the CLI's
+// interface; add new codes rather than changing existing ones.
+const (
+	codeError           = "error"
+	codeUsage           = "usage"
+	codeNotFound        = "not_found"
+	codeAliasInUse      = "alias_in_use"
+	codeConflict        = "conflict"
+	codeInvalidInput    = "invalid_input"
+	codeAuthentication  = "authentication_failed"
+	codeUnavailable     = "store_unavailable"
+	codeSchemaOutdated  = "schema_outdated"
+	codeInvalidRegistry = "invalid_registry"
+	codeNoRoute         = "no_route"
+)
+
+// usageError marks errors caused by invalid flags or arguments.
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string { return e.err.Error() }
+func (e usageError) Unwrap() error { return e.err }
+
+// errorObject is written to stdout in place of a result when a command fails with structured output.
+type errorObject struct {
+	Error errorDetail `json:"error"`
+}
+
+// errorDetail describes an error in structured output.
+type errorDetail struct {
+	Code    string             `json:"code"`
+	Message string             `json:"message"`
+	Details []registry.Problem `json:"details,omitempty"` // with invalid_registry
+}
+
+// newErrorDetail describes err for structured output.
+func newErrorDetail(err error) errorDetail {
+	detail := errorDetail{Code: errorCode(err), Message: err.Error()}
+	var invalid *registry.ValidationError
+	if errors.As(err, &invalid) {
+		detail.Details = invalid.Problems
+	}
+	return detail
+}
+
+// reportedError marks an error that a command already wrote to stdout along with a partial result,
+// so that ReportError does not write a second object.
+type reportedError struct {
+	err error
+}
+
+func (e reportedError) Error() string { return e.err.Error() }
+func (e reportedError) Unwrap() error { return e.err }
+
+// Bind registers the global flags on root, and checks them and sets up logging before any command
+// runs. Call it after all subcommands are added, so that their argument errors are reported as
+// usage errors.
+func (a *App) Bind(root *cobra.Command) {
+	root.PersistentFlags().StringVarP(&a.output, "output", "o", "", "Output format: table, json or yaml (default: from the profile, else table)")
+	root.PersistentFlags().StringVar(&a.profile, "profile", "", "Configuration profile to use (default: $SYNCORA_PROFILE or the current profile)")
+	root.PersistentFlags().StringVar(&a.logLevel, "log-level", "warn", "Log level on stderr: debug, info, warn or error")
+	root.PersistentFlags().StringVar(&a.logFormat, "log-format", logging.FormatText, "Log format on stderr: text or json")
+	root.PersistentFlags().StringVar(&a.secrets.file, "passphrase-file", "", "Read the account and vault passphrase from a file (mode 0600) instead of the terminal")
+	root.PersistentFlags().IntVar(&a.secrets.fd, "passphrase-fd", -1, "Read the account and vault passphrase from the first line of this file descriptor")
+	root.SilenceErrors = true
+	root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError{err}
+	})
+	wrapArgs(root)
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := a.loadProfile(cmd); err != nil {
+			return err
+		}
+
+		switch a.output {
+		case outputTable:
+		case outputJSON, outputYAML:
+			// The error object replaces the usage text.
+			cmd.SilenceUsage = true
+		default:
+			err := usageError{fmt.Errorf("invalid output format: %s (expected %s, %s or %s)", a.output, outputTable, outputJSON, outputYAML)}
+			a.output = outputTable
+			return err
+		}
+
+		if a.secrets.file != "" && a.secrets.fd >= 0 {
+			return usageError{fmt.Errorf("--passphrase-file and --passphrase-fd cannot be used together")}
+		}
+
+		logger, err := logging.New(os.Stderr, a.logLevel, a.logFormat)
+		if err != nil {
+			return usageError{err}
+		}
+		slog.SetDefault(logger)
+		crypto.SetLogger(logger)
+		database.SetLogger(logger)
+		return nil
+	}
+}
+
+// loadProfile reads the configuration file and selects the profile, whose output format applies
+// unless --output was given. Only commands annotated with annotationNewProfile may select a
+// profile that is not defined yet.
+func (a *App) loadProfile(cmd *cobra.Command) error {
+	path, err := config.DefaultPath()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Resolve(path, a.profile)
+	if err != nil {
+		return err
+	}
+	if cmd.Annotations[annotationNewProfile] == "" {
+		if err := cfg.CheckProfile(); err != nil {
+			return err
+		}
+	}
+	a.config = cfg
+	if !cmd.Flags().Changed("output") {
+		a.output = a.setting(config.KeyOutput)
+	}
+	return nil
+}
+
+// wrapArgs marks argument validation errors of cmd and its subcommands as usage errors.
+func wrapArgs(cmd *cobra.Command) {
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			if err := validate(cmd, args); err != nil {
+				return usageError{err}
+			}
+			return nil
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		wrapArgs(sub)
+	}
+}
+
+// render writes result to a.stdout as JSON or YAML, or calls table to print it for people. Rows
+// written to the table writer are aligned on tabs.
+func (a *App) render(result any, table func(w io.Writer)) error {
+	switch a.output {
+	case outputJSON:
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	case outputYAML:
+		return writeYAML(a.stdout, result)
+	default:
+		tw := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+		table(tw)
+		return tw.Flush()
+	}
+}
+
+// writeYAML encodes v as YAML with the same field names and order as its JSON encoding.
+func writeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	// JSON is valid YAML; decoding it into a node keeps the field order, and clearing the
+	// styles turns the flow syntax into block syntax.
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	clearStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// ReportError prints a failed command's error: as an error object on stdout with structured
+// output, and as text on stderr otherwise.
+func (a *App) ReportError(err error) {
+	if a.output != outputJSON && a.output != outputYAML {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return
+	}
+	if errors.As(err, new(reportedError)) {
+		return
+	}
+	obj := errorObject{Error: newErrorDetail(err)}
+	if renderErr := a.render(obj, nil); renderErr != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+}
+
+// errorCode maps an error to its stable code.
+func errorCode(err error) string {
+	var usage usageError
+	var invalid *registry.ValidationError
+	switch {
+	case errors.As(err, &usage):
+		return codeUsage
+	case errors.As(err, &invalid):
+		return codeInvalidRegistry
+	case errors.Is(err, database.ErrNotFound), errors.Is(err, config.ErrUnknownProfile), errors.Is(err, registry.ErrNotFound),
+		errors.Is(err, database.ErrTransferNotFound):
+		return codeNotFound
+	case errors.Is(err, engine.ErrRouteNotSupported), errors.Is(err, engine.ErrUnknownBridge):
+		return codeNoRoute
+	case errors.Is(err, database.ErrAliasInUse):
+		return codeAliasInUse
+	case errors.Is(err, database.ErrConflict):
+		return codeConflict
+	case errors.Is(err, database.ErrInvalid), errors.Is(err, amount.ErrInvalid), errors.Is(err, amount.ErrPrecision):
+		return codeInvalidInput
+	case errors.Is(err, database.ErrSchemaOutdated):
+		return codeSchemaOutdated
+	case errors.Is(err, database.ErrUnavailable):
+		return codeUnavailable
+	case errors.Is(err, crypto.ErrAuthentication):
+		return codeAuthentication
+	default:
+		return codeError
+	}
+}
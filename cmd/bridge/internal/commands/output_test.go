@@ -0,0 +1,158 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/xilverfang/syncora/cmd/bridge/internal/config"
+	engine "github.com/xilverfang/syncora/internal/bridge-engine"
+	"github.com/xilverfang/syncora/internal/core/amount"
+	"github.com/xilverfang/syncora/internal/core/crypto"
+	"github.com/xilverfang/syncora/internal/core/database"
+	"github.com/xilverfang/syncora/internal/core/registry"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// invalidRegistry is a registry validation error with two problems.
+var invalidRegistry = &registry.ValidationError{Problems: []registry.Problem{
+	{File: "chains.json", Path: "/chains/0/chain_id", Message: "duplicate chain ID 1"},
+	{File: "tokens.json", Message: "no tokens"},
+}}
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{usageError{errors.New("unknown flag: --amout")}, codeUsage},
+		{fmt.Errorf("send: %w", usageError{errors.New("invalid slippage")}), codeUsage},
+		{usageError{fmt.Errorf("bad account: %w", database.ErrNotFound)}, codeUsage},
+		{fmt.Errorf("failed to load registry: %w", invalidRegistry), codeInvalidRegistry},
+		{fmt.Errorf("failed to get account: %w", database.ErrNotFound), codeNotFound},
+		{fmt.Errorf("%w: work", config.ErrUnknownProfile), codeNotFound},
+		{fmt.Errorf("%w: chain zora", registry.ErrNotFound), codeNotFound},
+		{database.ErrTransferNotFound, codeNotFound},
+		{fmt.Errorf("%w: no bridge adapter carries DAI from arbitrum to base", engine.ErrRouteNotSupported), codeNoRoute},
+		{fmt.Errorf("sending through hop is not supported yet: %w", engine.ErrUnknownBridge), codeNoRoute},
+		{database.ErrAliasInUse, codeAliasInUse},
+		{database.ErrConflict, codeConflict},
+		{database.ErrInvalid, codeInvalidInput},
+		{fmt.Errorf("%w \"1.2.3\"", amount.ErrInvalid), codeInvalidInput},
+		{amount.ErrPrecision, codeInvalidInput},
+		{database.ErrSchemaOutdated, codeSchemaOutdated},
+		{fmt.Errorf("failed to open account store: %w", database.ErrUnavailable), codeUnavailable},
+		{crypto.ErrAuthentication, codeAuthentication},
+		{errors.New("connection refused"), codeError},
+	}
+	for _, tt := range tests {
+		if got := errorCode(tt.err); got != tt.want {
+			t.Errorf("errorCode(%q) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestReportError(t *testing.T) {
+	tests := []struct {
+		output  string
+		err     error
+		want    string
+		details []registry.Problem
+	}{
+		{outputJSON, fmt.Errorf("%w: no plan", engine.ErrRouteNotSupported), codeNoRoute, nil},
+		{outputYAML, fmt.Errorf("%w: no plan", engine.ErrRouteNotSupported), codeNoRoute, nil},
+		{outputJSON, fmt.Errorf("failed to load registry: %w", invalidRegistry), codeInvalidRegistry, invalidRegistry.Problems},
+		{outputYAML, fmt.Errorf("failed to load registry: %w", invalidRegistry), codeInvalidRegistry, invalidRegistry.Problems},
+		{outputJSON, usageError{errors.New(`required flag(s) "amount" not set`)}, codeUsage, nil},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		app := &App{output: tt.output, stdout: &out}
+		app.ReportError(tt.err)
+
+		var got errorObject
+		var err error
+		if tt.output == outputJSON {
+			err = json.Unmarshal(out.Bytes(), &got)
+		} else {
+			err = yaml.Unmarshal(out.Bytes(), &got)
+		}
+		if err != nil {
+			t.Fatalf("%s: decode %q: %v", tt.output, out.String(), err)
+		}
+		if got.Error.Code != tt.want || got.Error.Message != tt.err.Error() || fmt.Sprint(got.Error.Details) != fmt.Sprint(tt.details) {
+			t.Errorf("%s: ReportError(%q) wrote %+v", tt.output, tt.err, got.Error)
+		}
+	}
+
+	// Without structured output, errors go to stderr and stdout stays empty.
+	var out bytes.Buffer
+	app := &App{output: outputTable, stdout: &out}
+	app.ReportError(errors.New("boom"))
+	if out.Len() != 0 {
+		t.Errorf("ReportError with table output wrote %q to stdout", out.String())
+	}
+}
+
+func TestRender(t *testing.T) {
+	result := struct {
+		Alias string   `json:"alias"`
+		Tags  []string `json:"tags"`
+		Note  string   `json:"note,omitempty"`
+	}{Alias: "savings", Tags: []string{"cold", "ledger"}}
+	table := func(w io.Writer) {
+		fmt.Fprintln(w, "Alias\tTags")
+		fmt.Fprintf(w, "%s\t%v\n", result.Alias, result.Tags)
+	}
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{outputTable, "Alias    Tags\nsavings  [cold ledger]\n"},
+		{outputJSON, "{\n  \"alias\": \"savings\",\n  \"tags\": [\n    \"cold\",\n    \"ledger\"\n  ]\n}\n"},
+		{outputYAML, "alias: savings\ntags:\n  - cold\n  - ledger\n"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		app := &App{output: tt.output, stdout: &out}
+		if err := app.render(result, table); err != nil {
+			t.Fatalf("render %s: %v", tt.output, err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("render %s = %q, want %q", tt.output, out.String(), tt.want)
+		}
+	}
+}
+
+func TestBindUsageErrors(t *testing.T) {
+	t.Setenv(config.PathEnv, t.TempDir()+"/config.yaml")
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"show"}, "accepts 1 arg(s), received 0"},
+		{[]string{"show", "a", "--amout", "1"}, "unknown flag: --amout"},
+		{[]string{"show", "a", "--output", "xml"}, "invalid output format: xml"},
+		{[]string{"show", "a", "--passphrase-file", "p", "--passphrase-fd", "3"}, "cannot be used together"},
+	}
+	for _, tt := range tests {
+		app := NewApp(database.ConfigFromEnv)
+		root := &cobra.Command{Use: "syncora", SilenceUsage: true}
+		root.AddCommand(&cobra.Command{Use: "show <alias>", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error { return nil }})
+		app.Bind(root)
+		root.SetArgs(tt.args)
+		root.SetOut(io.Discard)
+		root.SetErr(io.Discard)
+
+		err := root.Execute()
+		if err == nil || errorCode(err) != codeUsage || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: error %v (%s), want a usage error containing %q", tt.args, err, errorCode(err), tt.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/term"
+)
+
+// minPassphraseLen is the minimum accepted passphrase length.
+const minPassphraseLen = 8
+
+// readPassword prompts on stderr and reads a line from the terminal without echo. Prompts stay off
+// stdout so that it carries only command results.
+func readPassword(prompt string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	secret, err := term.ReadPassword(int(syscall.Stdin))
+	fmt.Fprintln(os.Stderr)
+	return secret, err
+}
+
+// readNewPassphrase prompts for a passphrase and its confirmation, enforcing the minimum length.
+func readNewPassphrase(prompt string) ([]byte, error) {
+	passphrase, err := readPassword(prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase: %v", err)
+	}
+	if len(passphrase) < minPassphraseLen {
+		zeroBytes(passphrase)
+		return nil, fmt.Errorf("passphrase too short, minimum %d characters", minPassphraseLen)
+	}
+
+	passphraseConfirm, err := readPassword("Confirm passphrase: ")
+	defer zeroBytes(passphraseConfirm)
+	if err != nil {
+		zeroBytes(passphrase)
+		return nil, fmt.Errorf("failed to read passphrase confirmation: %v", err)
+	}
+	if string(passphrase) != string(passphraseConfirm) {
+		zeroBytes(passphrase)
+		return nil, fmt.Errorf("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// zeroBytes overwrites sensitive data in place.
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// readLimitedFile reads a regular file no larger than limit bytes.
+func readLimitedFile(path string, limit int64) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %v", path, err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("not a regular file: %s", path)
+	}
+	if info.Size() > limit {
+		return nil, fmt.Errorf("file too large: %s (%d bytes)", path, info.Size())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return data, nil
+}
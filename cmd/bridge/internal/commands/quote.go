@@ -0,0 +1,265 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/xilverfang/syncora/cmd/bridge/internal/config"
+	engine "github.com/xilverfang/syncora/internal/bridge-engine"
+	"github.com/xilverfang/syncora/internal/core/amount"
+	"github.com/xilverfang/syncora/internal/core/registry"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultQuoteTimeout bounds how long each bridge may take to quote.
+const defaultQuoteTimeout = 10 * time.Second
+
+// rankingTitles describe each ranking in the quote table.
+var rankingTitles = map[engine.Ranking]string{
+	engine.RankReceived: "most received first",
+	engine.RankFee:      "lowest fee first",
+	engine.RankETA:      "fastest first",
+}
+
+// quoteView is one bridge's quote as shown by quote.
+type quoteView struct {
+	Rank         int       `json:"rank"`
+	Bridge       string    `json:"bridge"`
+	AmountOut    string    `json:"amount_out"`
+	MinAmountOut string    `json:"min_amount_out"`
+	Fees         []feeView `json:"fees"`
+	FeeUSD       *string   `json:"fee_usd"` // null when a fee token has no price
+	NetUSD       *string   `json:"net_usd"` // amount_out minus the fees not included in it; null when unknown
+	PriceError   string    `json:"price_error,omitempty"`
+	ETASeconds   int64     `json:"eta_seconds"`
+	ExpiresAt    time.Time `json:"expires_at,omitzero"`
+}
+
+type feeView struct {
+	Name     string `json:"name"`
+	Token    string `json:"token"`
+	Chain    string `json:"chain"`
+	Amount   string `json:"amount"`
+	Included bool   `json:"included"` // taken from the amount sent rather than paid on top
+}
+
+// quoteFailure is a bridge that supports the route but returned no quote.
+type quoteFailure struct {
+	Bridge string `json:"bridge"`
+	Error  string `json:"error"`
+}
+
+func QuoteCmd(app *App) *cobra.Command {
+	var from, to, amountFlag, sortFlag, account string
+	var slippageBPS uint32
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "quote <token> --from <chain> --to <chain> --amount <value> [--sort received|fee|eta]",
+		Short: "Compare quotes from every bridge that carries a token between two networks",
+		Long: `Asks every bridge adapter that supports the route for a quote at the same time, each within
+--timeout, and ranks the answers by amount received, total fee in USD or expected time. Bridges
+that fail or time out are listed after the quotes, so the others are still shown. Fees are
+converted to USD with the Chainlink price feeds of the token registry.`,
+		Example: "  syncora quote ETH --from mainnet --to base --amount 0.5 --sort eta",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			ranking, err := engine.ParseRanking(sortFlag)
+			if err != nil {
+				return usageError{err}
+			}
+			if timeout <= 0 {
+				return usageError{fmt.Errorf("invalid timeout: %s", timeout)}
+			}
+			if slippageBPS > 10_000 {
+				return usageError{fmt.Errorf("invalid slippage: %d basis points (at most 10000)", slippageBPS)}
+			}
+
+			reg, err := app.Registry()
+			if err != nil {
+				return err
+			}
+			token, err := reg.Token(args[0])
+			if err != nil {
+				return err
+			}
+			source, err := reg.Chain(from)
+			if err != nil {
+				return err
+			}
+			destination, err := reg.Chain(to)
+			if err != nil {
+				return err
+			}
+			if source.Name == destination.Name {
+				return usageError{fmt.Errorf("--from and --to are both %s", source.Name)}
+			}
+			value, err := token.ParseAmount(source.Name, amountFlag)
+			if err != nil {
+				return err
+			}
+			if value.IsMax() {
+				return usageError{errors.New("--amount max is not supported by quote; give an exact amount")}
+			}
+			if value.Int().Sign() == 0 {
+				return fmt.Errorf("%w %q: must be more than zero", amount.ErrInvalid, amountFlag)
+			}
+
+			// Quotes are for the account's address when one is given or configured; bridges that
+			// need no sender quote without it. Only an account given with --account must exist.
+			sender, err := quoteSender(ctx, app, account)
+			if err != nil {
+				return err
+			}
+
+			bridges, err := app.Engine()
+			if err != nil {
+				return err
+			}
+			req := engine.QuoteRequest{
+				Route:       registry.BridgeRoute{Token: token.Symbol, From: source.Name, To: destination.Name},
+				Amount:      value.Int(),
+				Sender:      sender,
+				SlippageBPS: slippageBPS,
+			}
+			results, err := bridges.QuoteAll(ctx, req, timeout, engine.NewPrices(reg, app.dial))
+			if err != nil {
+				return err
+			}
+			engine.Rank(results, ranking)
+
+			result := struct {
+				Token  string         `json:"token"`
+				From   string         `json:"from"`
+				To     string         `json:"to"`
+				Amount string         `json:"amount"` // in base units on the source network
+				Sort   engine.Ranking `json:"sort"`
+				Quotes []quoteView    `json:"quotes"`
+				Failed []quoteFailure `json:"failed"`
+			}{token.Symbol, source.Name, destination.Name, req.Amount.String(), ranking, []quoteView{}, []quoteFailure{}}
+			var errs []error
+			for _, r := range results {
+				if r.Err != nil {
+					result.Failed = append(result.Failed, quoteFailure{r.Bridge, r.Err.Error()})
+					errs = append(errs, fmt.Errorf("%s: %w", r.Bridge, r.Err))
+					continue
+				}
+				result.Quotes = append(result.Quotes, newQuoteView(len(result.Quotes)+1, r))
+			}
+			if len(result.Quotes) == 0 {
+				return fmt.Errorf("no bridge returned a quote: %w", errors.Join(errs...))
+			}
+
+			return app.render(result, func(w io.Writer) {
+				fmt.Fprintf(w, "Quotes for %s from %s to %s, %s:\n", token.FormatAmount(source.Name, req.Amount),
+					source.Name, destination.Name, rankingTitles[ranking])
+				fmt.Fprintln(w, "Rank\tBridge\tReceived\tMinimum\tFees (USD)\tNet (USD)\tETA")
+				var unpriced []quoteView
+				for _, q := range result.Quotes {
+					if q.PriceError != "" {
+						unpriced = append(unpriced, q)
+					}
+					fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", q.Rank, q.Bridge,
+						formatTokenAmount(reg, token.Symbol, destination.Name, q.AmountOut),
+						formatTokenAmount(reg, token.Symbol, destination.Name, q.MinAmountOut),
+						formatUSD(q.FeeUSD), formatUSD(q.NetUSD), formatETA(time.Duration(q.ETASeconds)*time.Second))
+				}
+				if len(unpriced) > 0 {
+					fmt.Fprintln(w, "Unknown USD values:")
+					for _, q := range unpriced {
+						fmt.Fprintf(w, "  %s: %s\n", q.Bridge, q.PriceError)
+					}
+				}
+				if len(result.Failed) > 0 {
+					fmt.Fprintln(w, "Failed:")
+					for _, f := range result.Failed {
+						fmt.Fprintf(w, "  %s: %s\n", f.Bridge, f.Error)
+					}
+				}
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&from, "from", "f", "", "Source network: a name, alias or chain ID (required)")
+	cmd.Flags().StringVarP(&to, "to", "t", "", "Destination network: a name, alias or chain ID (required)")
+	cmd.Flags().StringVarP(&amountFlag, "amount", "m", "", "Amount to send, e.g. 1.5 (required)")
+	cmd.Flags().StringVarP(&sortFlag, "sort", "s", string(engine.RankReceived), "Rank by received (most first), fee (lowest USD total first) or eta (fastest first)")
+	cmd.Flags().DurationVar(&timeout, "timeout", defaultQuoteTimeout, "Time each bridge has to answer")
+	cmd.Flags().StringVarP(&account, "account", "a", "", "Alias or address of the sending account, for bridges that quote per sender (default: the profile's account, if any)")
+	cmd.Flags().Uint32Var(&slippageBPS, "slippage-bps", defaultSlippageBPS, "Tolerated shortfall of the received amount, in basis points")
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("to")
+	cmd.MarkFlagRequired("amount")
+	return cmd
+}
+
+func newQuoteView(rank int, r engine.QuoteResult) quoteView {
+	q := r.Quote
+	view := quoteView{
+		Rank:         rank,
+		Bridge:       r.Bridge,
+		AmountOut:    q.AmountOut.String(),
+		MinAmountOut: q.MinAmountOut.String(),
+		Fees:         make([]feeView, len(q.Fees)),
+		ETASeconds:   int64(q.ETA / time.Second),
+		ExpiresAt:    q.ExpiresAt,
+	}
+	for i, f := range q.Fees {
+		view.Fees[i] = feeView{f.Name, f.Token, f.Chain, f.Amount.String(), f.Included}
+	}
+	if r.FeeUSD != nil {
+		usd := r.FeeUSD.FloatString(2)
+		view.FeeUSD = &usd
+	}
+	if r.NetUSD != nil {
+		usd := r.NetUSD.FloatString(2)
+		view.NetUSD = &usd
+	}
+	if r.PriceErr != nil {
+		view.PriceError = r.PriceErr.Error()
+	}
+	return view
+}
+
+// formatUSD formats a dollar amount of a quote view, which is nil when unknown.
+func formatUSD(usd *string) string {
+	if usd == nil {
+		return "unknown"
+	}
+	return "$" + *usd
+}
+
+// quoteSender returns the address quotes are made for: the account given with --account, which
+// must exist, else the profile's account if it can be read, else the zero address.
+func quoteSender(ctx context.Context, app *App, account string) (common.Address, error) {
+	explicit := account != ""
+	if !explicit {
+		account = app.setting(config.KeyAccount)
+	}
+	if account == "" {
+		return common.Address{}, nil
+	}
+	store, err := app.Store(ctx)
+	if err != nil {
+		if explicit {
+			return common.Address{}, err
+		}
+		slog.Debug("quoting without a sender: the account store is unavailable", "account", account, "err", err)
+		return common.Address{}, nil
+	}
+	acc, err := store.GetAccount(ctx, account)
+	if err != nil {
+		if explicit {
+			return common.Address{}, fmt.Errorf("failed to get account: %w", err)
+		}
+		slog.Debug("quoting without a sender: the profile's account cannot be read", "account", account, "err", err)
+		return common.Address{}, nil
+	}
+	return common.HexToAddress(acc.Address), nil
+}
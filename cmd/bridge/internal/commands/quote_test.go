@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/xilverfang/syncora/cmd/bridge/internal/config"
+	"github.com/xilverfang/syncora/internal/core/database"
+)
+
+func TestQuoteSender(t *testing.T) {
+	ctx := context.Background()
+	vault := filepath.Join(t.TempDir(), "vault.json")
+	unavailable := errors.New("no storage configured")
+	tests := []struct {
+		name    string
+		flag    string // --account
+		profile string // the profile's account
+		store   error  // from loadConfig
+		wantErr error
+	}{
+		{name: "no account"},
+		{name: "profile account missing", profile: "savings"},
+		{name: "profile account without a store", profile: "savings", store: unavailable},
+		{name: "account missing", flag: "savings", wantErr: database.ErrNotFound},
+		{name: "account without a store", flag: "savings", store: unavailable, wantErr: unavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SYNCORA_ACCOUNT", tt.profile)
+			app := NewApp(func() (database.Config, error) {
+				return database.Config{Backend: database.BackendFile, Path: vault}, tt.store
+			})
+			app.config = &config.Config{File: &config.File{}}
+			defer app.Close()
+
+			sender, err := quoteSender(ctx, app, tt.flag)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("quoteSender error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || sender != (common.Address{}) {
+				t.Fatalf("quoteSender = %s, %v, want the zero address", sender.Hex(), err)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/xilverfang/syncora/cmd/bridge/internal/config"
+	"github.com/xilverfang/syncora/internal/core/registry"
+
+	"github.com/spf13/cobra"
+)
+
+func RegistryCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "registry",
+		Short: "Inspect the chain, token and bridge registry",
+		Long: `Commands to check the registry of chains, tokens and bridge routes. The built-in registry can be
+extended or overridden with chains.json, tokens.json and bridges.json in ~/.syncora/registry
+($SYNCORA_REGISTRY_DIR): their entries replace built-in entries with the same chain name, token
+symbol or bridge name, and add the others.`,
+	}
+
+	cmd.AddCommand(registryValidateCmd(app))
+	return cmd
+}
+
+func registryValidateCmd(app *App) *cobra.Command {
+	var dir string
+	cmd := &cobra.Command{
+		Use:   "validate [--dir <path>]",
+		Short: "Check the registry files against their JSON Schemas",
+		Long: `Validates the built-in registry and the overrides against the JSON Schemas in shared/schemas,
+and checks that tokens and routes only refer to known chains and tokens. Every problem is
+reported with its file and JSON Pointer.`,
+		Example: "  syncora registry validate --dir ./my-registry",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dir == "" {
+				var err error
+				if dir, err = config.RegistryDir(); err != nil {
+					return err
+				}
+			} else if _, err := os.Stat(dir); err != nil {
+				return usageError{err}
+			}
+			sources, err := registrySources(dir)
+			if err != nil {
+				return err
+			}
+			reg, err := registry.Load(sources...)
+			if err != nil {
+				return err
+			}
+			routes, err := reg.Routes(registry.RouteFilter{})
+			if err != nil {
+				return err
+			}
+
+			result := struct {
+				Valid   bool     `json:"valid"`
+				Files   []string `json:"files"`
+				Chains  int      `json:"chains"`
+				Tokens  int      `json:"tokens"`
+				Bridges int      `json:"bridges"`
+				Routes  int      `json:"routes"`
+			}{true, reg.Files(), len(reg.Chains()), len(reg.Tokens()), len(reg.Bridges()), len(routes)}
+			return app.render(result, func(w io.Writer) {
+				fmt.Fprintf(w, "Registry is valid: %d chains, %d tokens, %d bridges, %d routes.\n",
+					result.Chains, result.Tokens, result.Bridges, result.Routes)
+				fmt.Fprintln(w, "Files:")
+				for _, file := range result.Files {
+					fmt.Fprintf(w, "  %s\n", file)
+				}
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", "", "Directory of overrides to validate (default: ~/.syncora/registry)")
+	return cmd
+}
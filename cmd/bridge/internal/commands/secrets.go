@@ -0,0 +1,254 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// PassphraseCmdEnv names a shell command that prints the account and vault passphrase, for secret
+// managers.
+const PassphraseCmdEnv = "SYNCORA_PASSPHRASE_CMD"
+
+const (
+	// maxSecretSize bounds secrets read from files, descriptors and commands.
+	maxSecretSize = 4096
+	// passphraseCmdTimeout bounds how long SYNCORA_PASSPHRASE_CMD may run.
+	passphraseCmdTimeout = 30 * time.Second
+)
+
+// secretSource holds the non-interactive passphrase options. With none set, passphrases are
+// prompted for on the terminal.
+type secretSource struct {
+	file   string // --passphrase-file
+	fd     int    // --passphrase-fd, -1 if unset
+	fdLine []byte // what was read from fd, which cannot be read twice; zeroed by App.Close
+}
+
+// passphrase returns the account passphrase from the configured source, or prompts for it.
+func (a *App) passphrase(ctx context.Context, prompt string) ([]byte, error) {
+	passphrase, ok, err := a.readSecretSource(ctx)
+	if err != nil || ok {
+		return passphrase, err
+	}
+	passphrase, err = readPassword(prompt)
+	if err != nil {
+		zeroBytes(passphrase)
+		return nil, fmt.Errorf("failed to read passphrase: %v", err)
+	}
+	return passphrase, nil
+}
+
+// newPassphrase returns a passphrase for encrypting a key. A passphrase from the configured source
+// is used as is, without confirmation; otherwise it is prompted for twice.
+func (a *App) newPassphrase(ctx context.Context, prompt string) ([]byte, error) {
+	passphrase, ok, err := a.readSecretSource(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return readNewPassphrase(prompt)
+	}
+	if len(passphrase) < minPassphraseLen {
+		zeroBytes(passphrase)
+		return nil, fmt.Errorf("passphrase too short, minimum %d characters", minPassphraseLen)
+	}
+	return passphrase, nil
+}
+
+// vaultPassphrase returns the passphrase of the encrypted vault file like passphrase, or like
+// newPassphrase when a new vault is created. With a passphrase source set, the vault shares the
+// accounts' passphrase.
+func (a *App) vaultPassphrase(ctx context.Context, create bool) ([]byte, error) {
+	if create {
+		return a.newPassphrase(ctx, "Enter passphrase for the new account vault (input hidden): ")
+	}
+	return a.passphrase(ctx, "Enter account vault passphrase (input hidden): ")
+}
+
+// configured reports whether the account passphrase comes from a file, descriptor or command
+// rather than the terminal.
+func (s *secretSource) configured() bool {
+	return s.file != "" || s.fd >= 0 || os.Getenv(PassphraseCmdEnv) != ""
+}
+
+// replacementPassphrase returns the new passphrase of a passphrase change, read from file if one
+// is given and prompted for twice otherwise. The configured source supplies only the current
+// passphrase.
+func replacementPassphrase(file, prompt string) ([]byte, error) {
+	if file == "" {
+		return readNewPassphrase(prompt)
+	}
+	passphrase, err := readSecretFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(passphrase) < minPassphraseLen {
+		zeroBytes(passphrase)
+		return nil, fmt.Errorf("passphrase too short, minimum %d characters", minPassphraseLen)
+	}
+	return passphrase, nil
+}
+
+// readSecretSource reads the passphrase from --passphrase-file, --passphrase-fd or
+// SYNCORA_PASSPHRASE_CMD, in that order. It reports false if none is configured.
+func (a *App) readSecretSource(ctx context.Context) ([]byte, bool, error) {
+	src := &a.secrets
+	switch {
+	case src.file != "":
+		secret, err := readSecretFile(src.file)
+		return secret, true, err
+	case src.fd >= 0:
+		if src.fdLine == nil {
+			secret, err := readSecretFD(src.fd)
+			if err != nil {
+				return nil, true, err
+			}
+			src.fdLine = secret
+		}
+		// The vault and the account are unlocked with the same line.
+		return bytes.Clone(src.fdLine), true, nil
+	}
+	if command := os.Getenv(PassphraseCmdEnv); command != "" {
+		secret, err := runPassphraseCmd(ctx, command)
+		return secret, true, err
+	}
+	return nil, false, nil
+}
+
+// readSecretFile reads a secret from a file that only its owner can access. Trailing line breaks
+// are removed.
+func readSecretFile(path string) ([]byte, error) {
+	if err := checkPrivateFile(path); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+	secret, err := readSecret(f, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return secret, nil
+}
+
+// checkPrivateFile fails unless path is a regular file without group or other permissions.
+// Windows has no such permission bits and is not checked.
+func checkPrivateFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %v", path, err)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("not a regular file: %s", path)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("%s is accessible by other users (mode %04o); run chmod 600 %s", path, info.Mode().Perm(), path)
+	}
+	return nil
+}
+
+// readSecretFD reads the first line from an inherited file descriptor, like gpg --passphrase-fd.
+func readSecretFD(fd int) ([]byte, error) {
+	f := os.NewFile(uintptr(fd), fmt.Sprintf("fd %d", fd))
+	if f == nil {
+		return nil, fmt.Errorf("invalid --passphrase-fd: %d", fd)
+	}
+	defer f.Close()
+	secret, err := readSecret(f, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase from fd %d: %v", fd, err)
+	}
+	return secret, nil
+}
+
+// runPassphraseCmd runs command with the shell and returns what it prints on stdout. Its stderr
+// is passed through so that secret managers can prompt or report errors.
+func runPassphraseCmd(ctx context.Context, command string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, passphraseCmdTimeout)
+	defer cancel()
+
+	out := &secretBuffer{buf: make([]byte, maxSecretSize)}
+	defer zeroBytes(out.buf)
+
+	c := exec.CommandContext(ctx, "sh", "-c", command)
+	c.Stdin = os.Stdin
+	c.Stdout = out
+	c.Stderr = os.Stderr
+	// Children of the shell may keep stdout open after it is killed; stop waiting for them.
+	c.WaitDelay = time.Second
+	if err := c.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %v", PassphraseCmdEnv, err)
+	}
+	secret := trimLineBreaks(out.buf[:out.n])
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("%s printed no passphrase", PassphraseCmdEnv)
+	}
+	return append([]byte(nil), secret...), nil
+}
+
+// readSecret reads r to EOF, or with firstLine up to the first newline, into a fixed buffer so
+// that no copies are left behind when it grows. It returns a copy without trailing line breaks;
+// the caller must zero it.
+func readSecret(r io.Reader, firstLine bool) ([]byte, error) {
+	buf := make([]byte, maxSecretSize+1)
+	defer zeroBytes(buf)
+
+	n := 0
+	for n < len(buf) {
+		// Byte by byte for first lines, so nothing past the newline is consumed.
+		end := len(buf)
+		if firstLine {
+			end = n + 1
+		}
+		m, err := r.Read(buf[n:end])
+		n += m
+		if firstLine && m > 0 && buf[n-1] == '\n' {
+			break
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if n > maxSecretSize {
+		return nil, fmt.Errorf("secret longer than %d bytes", maxSecretSize)
+	}
+	secret := trimLineBreaks(buf[:n])
+	if len(secret) == 0 {
+		return nil, errors.New("empty secret")
+	}
+	return append([]byte(nil), secret...), nil
+}
+
+// trimLineBreaks removes trailing \n and \r\n, leaving any other whitespace in the secret.
+func trimLineBreaks(b []byte) []byte {
+	for len(b) > 0 && (b[len(b)-1] == '\n' || b[len(b)-1] == '\r') {
+		b = b[:len(b)-1]
+	}
+	return b
+}
+
+// secretBuffer is an io.Writer over a fixed buffer that fails instead of growing.
+type secretBuffer struct {
+	buf []byte
+	n   int
+}
+
+func (b *secretBuffer) Write(p []byte) (int, error) {
+	if len(p) > len(b.buf)-b.n {
+		return 0, fmt.Errorf("secret longer than %d bytes", len(b.buf))
+	}
+	b.n += copy(b.buf[b.n:], p)
+	return len(p), nil
+}
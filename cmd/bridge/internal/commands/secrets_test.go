@@ -0,0 +1,235 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeSecretFile writes data to a file of the given mode in a temporary directory.
+func writeSecretFile(t *testing.T, data string, mode os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "passphrase")
+	if err := os.WriteFile(path, []byte(data), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil { // not limited by the umask
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheckPrivateFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no group and other permission bits")
+	}
+	for _, tt := range []struct {
+		mode os.FileMode
+		ok   bool
+	}{
+		{0600, true},
+		{0400, true},
+		{0640, false},
+		{0604, false},
+		{0660, false},
+		{0644, false},
+	} {
+		err := checkPrivateFile(writeSecretFile(t, "secret", tt.mode))
+		if (err == nil) != tt.ok {
+			t.Errorf("mode %04o: checkPrivateFile = %v", tt.mode, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), "chmod 600") {
+			t.Errorf("mode %04o: error %q does not say how to fix it", tt.mode, err)
+		}
+	}
+	if err := checkPrivateFile(t.TempDir()); err == nil || !strings.Contains(err.Error(), "not a regular file") {
+		t.Errorf("directory: checkPrivateFile = %v", err)
+	}
+	if err := checkPrivateFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("missing file: expected error")
+	}
+}
+
+func TestReadSecretFile(t *testing.T) {
+	for _, tt := range []struct {
+		data, want string
+	}{
+		{"hunter2 secret", "hunter2 secret"},
+		{"hunter2\n", "hunter2"},
+		{"hunter2\r\n", "hunter2"},
+		{"hunter2\n\n\r\n", "hunter2"},
+		{" hunter2 \t\n", " hunter2 \t"}, // only line breaks are trimmed
+		{"line one\nline two\n", "line one\nline two"},
+	} {
+		got, err := readSecretFile(writeSecretFile(t, tt.data, 0600))
+		if err != nil || string(got) != tt.want {
+			t.Errorf("readSecretFile(%q) = %q, %v, want %q", tt.data, got, err, tt.want)
+		}
+	}
+	for name, data := range map[string]string{
+		"empty":       "",
+		"line breaks": "\r\n\n",
+		"too long":    strings.Repeat("a", maxSecretSize+1),
+	} {
+		if got, err := readSecretFile(writeSecretFile(t, data, 0600)); err == nil || got != nil {
+			t.Errorf("%s: readSecretFile = %q, %v, want an error", name, got, err)
+		}
+	}
+	if _, err := readSecretFile(writeSecretFile(t, "hunter2", 0644)); err == nil {
+		t.Error("readSecretFile read a file other users can read")
+	}
+	if got, err := readSecretFile(writeSecretFile(t, strings.Repeat("a", maxSecretSize), 0600)); err != nil || len(got) != maxSecretSize {
+		t.Errorf("readSecretFile of %d bytes = %d bytes, %v", maxSecretSize, len(got), err)
+	}
+}
+
+func TestPassphraseFD(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	go func() {
+		w.WriteString("first line\r\nsecond line\n")
+		w.Close()
+	}()
+
+	app := NewApp(nil)
+	app.secrets.fd = int(r.Fd())
+	secret, ok, err := app.readSecretSource(context.Background())
+	if err != nil || !ok || string(secret) != "first line" {
+		t.Fatalf("first read = %q, %t, %v, want the first line", secret, ok, err)
+	}
+	// Later reads, e.g. of the account after the vault, get the same line in a buffer of their own.
+	zeroBytes(secret)
+	if again, ok, err := app.readSecretSource(context.Background()); err != nil || !ok || string(again) != "first line" {
+		t.Fatalf("second read = %q, %t, %v, want the first line again", again, ok, err)
+	}
+	if again, err := app.newPassphrase(context.Background(), ""); err != nil || string(again) != "first line" {
+		t.Fatalf("newPassphrase = %q, %v, want the first line again", again, err)
+	}
+	app.Close()
+	if !bytes.Equal(app.secrets.fdLine, make([]byte, len("first line"))) {
+		t.Fatalf("Close left the descriptor line %q", app.secrets.fdLine)
+	}
+}
+
+func TestPassphraseCmd(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	ctx := context.Background()
+	for _, tt := range []struct {
+		command string
+		want    string // empty for an error
+	}{
+		{"printf 'hunter2\\n'", "hunter2"},
+		{"printf 'hunter2\\r\\n'", "hunter2"},
+		{"printf '\\n'", ""},
+		{"exit 3", ""},
+		{"head -c " + strconv.Itoa(maxSecretSize+1) + " /dev/zero | tr '\\0' a", ""},
+	} {
+		t.Setenv(PassphraseCmdEnv, tt.command)
+		app := NewApp(nil)
+		secret, ok, err := app.readSecretSource(ctx)
+		if !ok {
+			t.Fatalf("%s: the command was not used", tt.command)
+		}
+		if tt.want == "" {
+			if err == nil || secret != nil {
+				t.Errorf("%s: got %q, %v, want an error", tt.command, secret, err)
+			}
+			continue
+		}
+		if err != nil || string(secret) != tt.want {
+			t.Errorf("%s: got %q, %v, want %q", tt.command, secret, err, tt.want)
+		}
+	}
+
+	// The command is killed at the deadline, even if a child keeps its output open.
+	ctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := runPassphraseCmd(ctx, "sleep 3; echo late"); err == nil {
+		t.Fatal("expected the command to be killed")
+	}
+	if elapsed := time.Since(start); elapsed > 2500*time.Millisecond {
+		t.Fatalf("runPassphraseCmd returned after %s", elapsed)
+	}
+}
+
+// recordingReader returns its data in reads of up to step bytes, then err, and remembers the
+// buffers it was given.
+type recordingReader struct {
+	data string
+	step int
+	err  error
+	bufs [][]byte
+}
+
+func (r *recordingReader) Read(p []byte) (int, error) {
+	r.bufs = append(r.bufs, p)
+	if r.data == "" {
+		return 0, r.err
+	}
+	n := copy(p[:min(len(p), r.step)], r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestReadSecretZeroes(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		r         *recordingReader
+		firstLine bool
+		wantErr   bool
+	}{
+		{"read", &recordingReader{data: "hunter2\n", step: 3, err: io.EOF}, false, false},
+		{"first line", &recordingReader{data: "hunter2\nrest", step: 1, err: io.EOF}, true, false},
+		{"read error", &recordingReader{data: "hunter2", step: 4, err: errors.New("broken pipe")}, false, true},
+		{"too long", &recordingReader{data: strings.Repeat("a", maxSecretSize+10), step: 1000, err: io.EOF}, false, true},
+		{"empty", &recordingReader{data: "\n", step: 1, err: io.EOF}, false, true},
+	} {
+		secret, err := readSecret(tt.r, tt.firstLine)
+		if (err != nil) != tt.wantErr || tt.wantErr && secret != nil {
+			t.Errorf("%s: readSecret = %q, %v", tt.name, secret, err)
+		}
+		if !tt.wantErr && string(secret) != "hunter2" {
+			t.Errorf("%s: readSecret = %q, want hunter2", tt.name, secret)
+		}
+		// Every buffer handed to the reader is part of readSecret's own buffer, cleared on return.
+		for _, b := range tt.r.bufs {
+			if !bytes.Equal(b, make([]byte, len(b))) {
+				t.Errorf("%s: read buffer not zeroed: %q", tt.name, b)
+				break
+			}
+		}
+	}
+}
+
+func TestReplacementPassphrase(t *testing.T) {
+	got, err := replacementPassphrase(writeSecretFile(t, "new passphrase\n", 0600), "")
+	if err != nil || string(got) != "new passphrase" {
+		t.Fatalf("replacementPassphrase = %q, %v", got, err)
+	}
+	if got, err := replacementPassphrase(writeSecretFile(t, "short\n", 0600), ""); err == nil || got != nil {
+		t.Fatalf("replacementPassphrase accepted a short passphrase: %q", got)
+	}
+
+	app := NewApp(nil)
+	if app.secrets.configured() {
+		t.Fatal("a new App has a passphrase source")
+	}
+	t.Setenv(PassphraseCmdEnv, "echo hunter2")
+	if !app.secrets.configured() {
+		t.Fatal("SYNCORA_PASSPHRASE_CMD is not a passphrase source")
+	}
+}
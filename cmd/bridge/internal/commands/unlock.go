@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/xilverfang/syncora/cmd/bridge/internal/agent"
+	"github.com/xilverfang/syncora/internal/core/crypto"
+	"github.com/xilverfang/syncora/internal/core/database"
+)
+
+// unlockAccount decrypts an account's key into a signer. Keys sealed with a legacy key version are
+// re-encrypted in place with the current version while the passphrase is at hand, with KDF
+// parameters at least as strong as both their own and profile's.
+func unlockAccount(ctx context.Context, store database.AccountStore, acc *database.Account, passphrase []byte, profile crypto.KDFParams) (*crypto.LocalSigner, error) {
+	salt, err := hex.DecodeString(acc.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode salt: %v", err)
+	}
+
+	signer, err := crypto.Unlock(ctx, acc.EncryptedKey, acc.Address, passphrase, salt, crypto.KeyVersion(acc.KeyVersion), accountKDFParams(acc))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt private key: %w", err)
+	}
+
+	markUsed(ctx, store, acc)
+
+	if crypto.NeedsUpgrade(crypto.KeyVersion(acc.KeyVersion)) {
+		slog.Info("upgrading key encryption", "key_version", crypto.CurrentKeyVersion)
+		if err := upgradeAccountKey(ctx, store, acc, passphrase, salt, profile); err != nil {
+			slog.Warn("failed to upgrade key encryption", "err", err)
+		}
+	}
+	return signer, nil
+}
+
+// markUsed records that acc was just unlocked. Failures only warn, since the unlock succeeded.
+func markUsed(ctx context.Context, store database.AccountStore, acc *database.Account) {
+	if err := store.MarkAccountUsed(ctx, acc.Address, time.Now()); err != nil {
+		slog.Warn("failed to record account use", "err", err)
+	}
+}
+
+// upgradeAccountKey re-encrypts a legacy key under the same passphrase with crypto.CurrentKeyVersion
+// and stores it in place. The new KDF parameters are profile's, raised to the key's own where those
+// are higher, so that an upgrade never weakens a key, e.g. one imported with --kdf sensitive.
+func upgradeAccountKey(ctx context.Context, store database.AccountStore, acc *database.Account, passphrase, salt []byte, profile crypto.KDFParams) error {
+	current := accountKDFParams(acc)
+	params := crypto.KDFParams{
+		Time:    max(current.Time, profile.Time),
+		Memory:  max(current.Memory, profile.Memory),
+		Threads: max(current.Threads, profile.Threads),
+	}
+	encryptedKey, newSalt, err := crypto.ChangePassphrase(ctx, acc.EncryptedKey, acc.Address, passphrase, salt,
+		crypto.KeyVersion(acc.KeyVersion), current, passphrase, params)
+	if err != nil {
+		return fmt.Errorf("failed to re-encrypt private key: %v", err)
+	}
+	return store.UpdateAccountKey(ctx, newAccount(acc.Alias, acc.Address, encryptedKey, newSalt, params), acc.EncryptedKey)
+}
+
+// accountSigner returns a signer for acc. When SYNCORA_AGENT_SOCK names an agent holding the key,
+// signing goes through the agent; otherwise the passphrase is read and the key unlocked locally.
+func accountSigner(ctx context.Context, app *App, store database.AccountStore, acc *database.Account) (crypto.Signer, error) {
+	client, err := agent.FromEnv()
+	if err != nil {
+		slog.Warn("signing agent unavailable", "err", err)
+	} else if client != nil {
+		signer, err := client.Signer(ctx, acc.Address)
+		if err == nil {
+			slog.Info("signing with agent")
+			markUsed(ctx, store, acc)
+			return signer, nil
+		}
+		slog.Warn("signing agent cannot sign", "err", err)
+	}
+
+	profile, err := crypto.KDFProfile(app.kdfProfile(""))
+	if err != nil {
+		return nil, err
+	}
+	passphrase, err := app.passphrase(ctx, "Enter passphrase to decrypt private key (input hidden): ")
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(passphrase)
+	return unlockAccount(ctx, store, acc, passphrase, profile)
+}
@@ -0,0 +1,411 @@
+// Package config reads and writes the CLI configuration file, ~/.syncora/config.yaml, and resolves
+// settings from flags, the environment, the selected profile and the defaults, in that order.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/xilverfang/syncora/internal/core/crypto"
+	"github.com/xilverfang/syncora/internal/core/database"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables that select the configuration file, the profile and the directory of
+// registry overrides.
+const (
+	PathEnv        = "SYNCORA_CONFIG"
+	ProfileEnv     = "SYNCORA_PROFILE"
+	RegistryDirEnv = "SYNCORA_REGISTRY_DIR"
+)
+
+// DefaultProfile is used when no profile is selected. It need not exist in the file.
+const DefaultProfile = "default"
+
+// Sources of a resolved setting, from highest to lowest precedence.
+const (
+	SourceFlag    = "flag"
+	SourceEnv     = "env"
+	SourceProfile = "profile"
+	SourceDefault = "default"
+)
+
+// Setting keys. RPC endpoints are set per chain as rpc.<chain>.
+const (
+	KeyStore     = "store"
+	KeyDBURL     = "db_url"
+	KeyStorePath = "store_path"
+	KeyKDF       = "kdf"
+	KeyAccount   = "account"
+	KeyOutput    = "output"
+	KeyRPCPrefix = "rpc."
+)
+
+// Keys lists the settings of a profile, other than RPC endpoints, in display order.
+var Keys = []string{KeyStore, KeyDBURL, KeyStorePath, KeyKDF, KeyAccount, KeyOutput}
+
+// envVars maps each key to the environment variable that overrides it.
+var envVars = map[string]string{
+	KeyStore:     "SYNCORA_STORE",
+	KeyDBURL:     "SYNCORA_DB_URL",
+	KeyStorePath: "SYNCORA_STORE_PATH",
+	KeyKDF:       "SYNCORA_KDF",
+	KeyAccount:   "SYNCORA_ACCOUNT",
+	KeyOutput:    "SYNCORA_OUTPUT",
+}
+
+// defaults holds the values used when a key is set nowhere. The store and its location are chosen
+// by the database package, and there is no default account.
+var defaults = map[string]string{
+	KeyKDF:    crypto.DefaultKDFProfile,
+	KeyOutput: "table",
+}
+
+// outputFormats are the values accepted for the output key, as for the --output flag.
+var outputFormats = []string{"table", "json", "yaml"}
+
+// ErrUnknownProfile is returned when the selected profile does not exist in the file.
+var ErrUnknownProfile = errors.New("unknown profile")
+
+var (
+	profileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	chainNamePattern   = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+)
+
+// File is the configuration file: named profiles and the one selected by default.
+type File struct {
+	CurrentProfile string              `yaml:"current_profile,omitempty"`
+	Profiles       map[string]*Profile `yaml:"profiles,omitempty"`
+}
+
+// Profile holds the settings of one environment, such as dev, staging or prod. Empty fields fall
+// back to the defaults.
+type Profile struct {
+	Store     string            `yaml:"store,omitempty"`
+	DBURL     string            `yaml:"db_url,omitempty"`
+	StorePath string            `yaml:"store_path,omitempty"`
+	KDF       string            `yaml:"kdf,omitempty"`
+	Account   string            `yaml:"account,omitempty"`
+	Output    string            `yaml:"output,omitempty"`
+	RPC       map[string]string `yaml:"rpc,omitempty"` // chain name to endpoint URL
+}
+
+// DefaultPath returns $SYNCORA_CONFIG, or ~/.syncora/config.yaml.
+func DefaultPath() (string, error) {
+	if path := os.Getenv(PathEnv); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %v", err)
+	}
+	return filepath.Join(home, ".syncora", "config.yaml"), nil
+}
+
+// RegistryDir returns $SYNCORA_REGISTRY_DIR, or ~/.syncora/registry: the directory whose
+// chains.json, tokens.json and bridges.json override the built-in registry.
+func RegistryDir() (string, error) {
+	if dir := os.Getenv(RegistryDirEnv); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %v", err)
+	}
+	return filepath.Join(home, ".syncora", "registry"), nil
+}
+
+// Load reads the configuration file at path. A missing file is an empty configuration. Since
+// profiles may hold database credentials, the file must not be accessible by other users.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &File{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	if err := checkPermissions(path); err != nil {
+		return nil, err
+	}
+
+	f := &File{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(f); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid %s: %v", path, err)
+	}
+	for name, p := range f.Profiles {
+		if err := ValidateProfileName(name); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", path, err)
+		}
+		if p == nil {
+			f.Profiles[name] = &Profile{}
+		}
+	}
+	return f, nil
+}
+
+// checkPermissions fails if path has group or other permissions. Windows is not checked.
+func checkPermissions(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %v", path, err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("%s permissions too open: %s, expected 0600", path, info.Mode().Perm())
+	}
+	return nil
+}
+
+// Save writes the configuration file atomically with 0600 permissions, creating its directory
+// with 0700 permissions if needed.
+func (f *File) Save(path string) error {
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %v", path, err)
+	}
+	// CreateTemp creates the file with 0600 permissions.
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
+
+// ProfileNames returns the names of the profiles in the file, sorted.
+func (f *File) ProfileNames() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ValidateProfileName accepts lowercase names of letters, digits, '-' and '_'.
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use lowercase letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// Get returns the value of key in the profile, or an empty string if it is not set.
+func (p *Profile) Get(key string) (string, error) {
+	if chain, ok := strings.CutPrefix(key, KeyRPCPrefix); ok {
+		if err := validateChain(chain); err != nil {
+			return "", err
+		}
+		return p.RPC[chain], nil
+	}
+	field, err := p.field(key)
+	if err != nil {
+		return "", err
+	}
+	return *field, nil
+}
+
+// Set validates value and stores it under key. An empty value removes the key.
+func (p *Profile) Set(key, value string) error {
+	if value != "" {
+		if err := validate(key, value); err != nil {
+			return err
+		}
+	}
+	if chain, ok := strings.CutPrefix(key, KeyRPCPrefix); ok {
+		if err := validateChain(chain); err != nil {
+			return err
+		}
+		if value == "" {
+			delete(p.RPC, chain)
+			return nil
+		}
+		if p.RPC == nil {
+			p.RPC = make(map[string]string)
+		}
+		p.RPC[chain] = value
+		return nil
+	}
+	field, err := p.field(key)
+	if err != nil {
+		return err
+	}
+	*field = value
+	return nil
+}
+
+func (p *Profile) field(key string) (*string, error) {
+	switch key {
+	case KeyStore:
+		return &p.Store, nil
+	case KeyDBURL:
+		return &p.DBURL, nil
+	case KeyStorePath:
+		return &p.StorePath, nil
+	case KeyKDF:
+		return &p.KDF, nil
+	case KeyAccount:
+		return &p.Account, nil
+	case KeyOutput:
+		return &p.Output, nil
+	default:
+		return nil, fmt.Errorf("unknown key %q (expected %s or %s<chain>)", key, strings.Join(Keys, ", "), KeyRPCPrefix)
+	}
+}
+
+// validate checks a value before it is stored, so that a bad profile is caught by 'config set'
+// rather than by a later command.
+func validate(key, value string) error {
+	switch {
+	case key == KeyStore:
+		switch value {
+		case database.BackendPostgres, database.BackendSQLite, database.BackendFile:
+			return nil
+		}
+		return fmt.Errorf("invalid store: %s (expected %s, %s or %s)", value, database.BackendPostgres, database.BackendSQLite, database.BackendFile)
+	case key == KeyKDF:
+		_, err := crypto.KDFProfile(value)
+		return err
+	case key == KeyOutput:
+		if !slices.Contains(outputFormats, value) {
+			return fmt.Errorf("invalid output: %s (expected %s)", value, strings.Join(outputFormats, ", "))
+		}
+	case strings.HasPrefix(key, KeyRPCPrefix):
+		u, err := url.Parse(value)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid RPC endpoint: %s", value)
+		}
+		switch u.Scheme {
+		case "http", "https", "ws", "wss":
+		default:
+			return fmt.Errorf("invalid RPC endpoint: %s (expected an http, https, ws or wss URL)", value)
+		}
+	}
+	return nil
+}
+
+func validateChain(chain string) error {
+	if !chainNamePattern.MatchString(chain) {
+		return fmt.Errorf("invalid chain name %q in %s<chain>", chain, KeyRPCPrefix)
+	}
+	return nil
+}
+
+// Config is the configuration of one command run: the file and the profile selected for it.
+type Config struct {
+	Path    string
+	File    *File
+	Profile string // name of the selected profile
+}
+
+// Resolve loads the file at path and selects the profile named by flag, $SYNCORA_PROFILE or the
+// file's current profile, in that order, falling back to DefaultProfile.
+func Resolve(path, flag string) (*Config, error) {
+	f, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	name := flag
+	if name == "" {
+		name = os.Getenv(ProfileEnv)
+	}
+	if name == "" {
+		name = f.CurrentProfile
+	}
+	if name == "" {
+		name = DefaultProfile
+	}
+	if err := ValidateProfileName(name); err != nil {
+		return nil, err
+	}
+	return &Config{Path: path, File: f, Profile: name}, nil
+}
+
+// Exists reports whether the selected profile is defined in the file.
+func (c *Config) Exists() bool {
+	_, ok := c.File.Profiles[c.Profile]
+	return ok
+}
+
+// CheckProfile fails with ErrUnknownProfile if a profile other than DefaultProfile was selected
+// but is not defined, which is most likely a typo.
+func (c *Config) CheckProfile() error {
+	if c.Profile == DefaultProfile || c.Exists() {
+		return nil
+	}
+	return fmt.Errorf("%w: %s (defined: %s)", ErrUnknownProfile, c.Profile, strings.Join(c.File.ProfileNames(), ", "))
+}
+
+// Active returns the selected profile, which is empty if it is not defined.
+func (c *Config) Active() *Profile {
+	if p := c.File.Profiles[c.Profile]; p != nil {
+		return p
+	}
+	return &Profile{}
+}
+
+// Edit returns the selected profile for changing, adding it to the file if needed.
+func (c *Config) Edit() *Profile {
+	if !c.Exists() {
+		if c.File.Profiles == nil {
+			c.File.Profiles = make(map[string]*Profile)
+		}
+		c.File.Profiles[c.Profile] = &Profile{}
+	}
+	return c.File.Profiles[c.Profile]
+}
+
+// Lookup resolves key from the environment, the selected profile and the defaults, returning the
+// value and its source. Flags take precedence over all of these and are handled by the caller.
+// RPC endpoints are read from SYNCORA_RPC_<CHAIN>.
+func (c *Config) Lookup(key string) (value, source string, err error) {
+	env := envVars[key]
+	if chain, ok := strings.CutPrefix(key, KeyRPCPrefix); ok {
+		env = "SYNCORA_RPC_" + strings.ToUpper(strings.ReplaceAll(chain, "-", "_"))
+	}
+	if env != "" {
+		if v := os.Getenv(env); v != "" {
+			return v, SourceEnv, nil
+		}
+	}
+	v, err := c.Active().Get(key)
+	if err != nil {
+		return "", "", err
+	}
+	if v != "" {
+		return v, SourceProfile, nil
+	}
+	return defaults[key], SourceDefault, nil
+}
+
+// Database returns the storage settings of the selected profile, for database.Config.WithDefaults
+// to apply beneath the environment.
+func (c *Config) Database() database.Config {
+	p := c.Active()
+	return database.Config{Backend: p.Store, URL: p.DBURL, Path: p.StorePath}
+}
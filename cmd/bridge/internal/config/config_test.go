@@ -0,0 +1,117 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "syncora", "config.yaml")
+	f, err := Load(path)
+	if err != nil || len(f.Profiles) != 0 {
+		t.Fatalf("Load of a missing file = %+v, %v", f, err)
+	}
+
+	cfg := &Config{Path: path, File: f, Profile: "dev"}
+	p := cfg.Edit()
+	for key, value := range map[string]string{
+		KeyStore:       "sqlite",
+		KeyKDF:         "test",
+		KeyOutput:      "json",
+		"rpc.ethereum": "https://eth.example.com",
+	} {
+		if err := p.Set(key, value); err != nil {
+			t.Fatalf("Set(%s): %v", key, err)
+		}
+	}
+	for key, value := range map[string]string{
+		KeyStore:       "mysql",
+		KeyKDF:         "fast",
+		KeyOutput:      "xml",
+		"rpc.ethereum": "ftp://eth.example.com",
+		"rpc.Bad Name": "https://eth.example.com",
+		"colour":       "blue",
+	} {
+		if err := p.Set(key, value); err == nil {
+			t.Errorf("Set(%s, %s): expected error", key, value)
+		}
+	}
+	f.CurrentProfile = "dev"
+	if err := f.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("config file mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.CurrentProfile != "dev" || got.Profiles["dev"].KDF != "test" || got.Profiles["dev"].RPC["ethereum"] != "https://eth.example.com" {
+		t.Fatalf("Load = %+v", got)
+	}
+
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected error for a world-readable config file")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "current_profile: prod\nprofiles:\n  prod:\n    kdf: sensitive\n    account: treasury\n  dev:\n    kdf: test\n"
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, env := range []string{ProfileEnv, "SYNCORA_KDF", "SYNCORA_ACCOUNT", "SYNCORA_OUTPUT"} {
+		t.Setenv(env, "")
+	}
+
+	cfg, err := Resolve(path, "")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if cfg.Profile != "prod" {
+		t.Fatalf("profile = %s, want the current profile", cfg.Profile)
+	}
+	tests := []struct {
+		key, value, source string
+	}{
+		{KeyKDF, "sensitive", SourceProfile},
+		{KeyAccount, "treasury", SourceProfile},
+		{KeyOutput, "table", SourceDefault},
+		{KeyStore, "", SourceDefault},
+	}
+	for _, tt := range tests {
+		value, source, err := cfg.Lookup(tt.key)
+		if err != nil || value != tt.value || source != tt.source {
+			t.Errorf("Lookup(%s) = %q, %s, %v; want %q, %s", tt.key, value, source, err, tt.value, tt.source)
+		}
+	}
+
+	// The environment overrides the profile, and --profile overrides $SYNCORA_PROFILE.
+	t.Setenv("SYNCORA_ACCOUNT", "hot")
+	if value, source, _ := cfg.Lookup(KeyAccount); value != "hot" || source != SourceEnv {
+		t.Errorf("Lookup(account) = %q, %s; want the environment", value, source)
+	}
+	t.Setenv(ProfileEnv, "dev")
+	if cfg, _ := Resolve(path, ""); cfg.Profile != "dev" {
+		t.Errorf("profile = %s, want $%s", cfg.Profile, ProfileEnv)
+	}
+	if cfg, _ := Resolve(path, "prod"); cfg.Profile != "prod" {
+		t.Errorf("profile = %s, want the flag", cfg.Profile)
+	}
+
+	cfg, err = Resolve(path, "staging")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if err := cfg.CheckProfile(); !errors.Is(err, ErrUnknownProfile) {
+		t.Errorf("expected ErrUnknownProfile, got %v", err)
+	}
+}
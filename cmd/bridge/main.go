@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
+	"log/slog"
 	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/xilverfang/syncora/cmd/bridge/internal/commands"
+	"github.com/xilverfang/syncora/internal/core/database"
 )
 
 
@@ -17,11 +20,28 @@ func main()  {
 		networks, and execute token bridging operations.`,
 	}
 
-	rootCmd.AddCommand(commands.AccountCmd())
-	rootCmd.AddCommand(commands.InfoCmd())
+	app := commands.NewApp(database.ConfigFromEnv)
+
+	rootCmd.AddCommand(commands.AccountCmd(app))
+	rootCmd.AddCommand(commands.AgentCmd(app))
+	rootCmd.AddCommand(commands.BridgeCmd(app))
+	rootCmd.AddCommand(commands.ConfigCmd(app))
+	rootCmd.AddCommand(commands.DBCmd(app))
+	rootCmd.AddCommand(commands.InfoCmd(app))
+	rootCmd.AddCommand(commands.QuoteCmd(app))
+	rootCmd.AddCommand(commands.RegistryCmd(app))
 	rootCmd.AddCommand(commands.HelpCmd())
+	app.Bind(&rootCmd)
 
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.ExecuteContext(context.Background())
+	if err != nil {
+		app.ReportError(err)
+	}
+	if closeErr := app.Close(); closeErr != nil {
+		slog.Warn("failed to close database", "err", closeErr)
+	}
+	if err != nil {
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
+
@@ -11,17 +11,8 @@ ALTER USER syncora CREATEDB;
 -- Connect to the syncora_db database
 \c syncora_db;
 
--- Create the accounts table
-CREATE TABLE IF NOT EXISTS accounts (
-    address TEXT PRIMARY KEY,
-    alias TEXT NOT NULL,
-    encrypted_key TEXT NOT NULL,
-    salt TEXT NOT NULL DEFAULT '',
-    key_version SMALLINT NOT NULL DEFAULT 1,
-    CONSTRAINT valid_hex_encrypted_key CHECK (encrypted_key ~ '^[0-9a-fA-F]+$'),
-    CONSTRAINT valid_hex_salt CHECK (salt ~ '^[0-9a-fA-F]+$'),
-    CONSTRAINT valid_key_version CHECK (key_version >= 1)
-);
+-- Tables are not created here. They are managed by the versioned migrations embedded in
+-- syncora; run 'syncora db migrate' once the database is up.
 
 -- Grant permissions to syncora user
 GRANT ALL PRIVILEGES ON DATABASE syncora_db TO syncora;
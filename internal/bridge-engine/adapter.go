@@ -0,0 +1,128 @@
+// Package engine defines the interface that bridge integrations implement and the registry the
+// CLI resolves them through, so that commands never switch on bridge names.
+package engine
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/xilverfang/syncora/internal/core/registry"
+)
+
+// BridgeAdapter integrates one bridge service. Implementations must be safe for concurrent use.
+type BridgeAdapter interface {
+	// Name returns the bridge name used in the registry and on the command line, e.g. "hop".
+	Name() string
+
+	// SupportedRoutes lists the token transfers the bridge can carry.
+	SupportedRoutes(ctx context.Context) ([]registry.BridgeRoute, error)
+
+	// Quote prices a transfer: what arrives on the destination chain, the fees, and how long it
+	// takes. Quotes expire; BuildTransaction refuses expired ones.
+	Quote(ctx context.Context, req QuoteRequest) (*Quote, error)
+
+	// BuildTransaction returns the unsigned transactions that execute a quote on the source chain,
+	// in order, such as an ERC-20 approval followed by the deposit.
+	BuildTransaction(ctx context.Context, quote *Quote) ([]Transaction, error)
+
+	// TrackStatus reports the progress of a transfer whose source transaction was sent.
+	TrackStatus(ctx context.Context, transfer Transfer) (*Status, error)
+
+	// EstimateTime returns the expected time from the source transaction to funds arriving.
+	EstimateTime(ctx context.Context, route registry.BridgeRoute) (time.Duration, error)
+}
+
+// QuoteRequest asks a bridge to price a transfer.
+type QuoteRequest struct {
+	Route       registry.BridgeRoute
+	Amount      *big.Int // in base units of the token on the source chain
+	Sender      common.Address
+	Recipient   common.Address // on the destination chain; the zero address means the sender
+	SlippageBPS uint32         // tolerated shortfall of the received amount, in basis points
+}
+
+// Receiver returns the recipient, defaulting to the sender.
+func (r QuoteRequest) Receiver() common.Address {
+	if r.Recipient == (common.Address{}) {
+		return r.Sender
+	}
+	return r.Recipient
+}
+
+// Quote is a bridge's price for a transfer.
+type Quote struct {
+	Request      QuoteRequest
+	AmountOut    *big.Int // expected amount received, in base units of the token on the destination chain
+	MinAmountOut *big.Int // amount received at worst, after slippage
+	Fees         []Fee
+	ETA          time.Duration
+	ExpiresAt    time.Time // zero if the quote does not expire
+
+	// Data carries adapter-specific values from Quote to BuildTransaction and TrackStatus, such as
+	// a relayer fee or a quote ID. Other packages must not interpret it.
+	Data map[string]string
+}
+
+// Expired reports whether the quote can no longer be executed at now.
+func (q *Quote) Expired(now time.Time) bool {
+	return !q.ExpiresAt.IsZero() && !now.Before(q.ExpiresAt)
+}
+
+// Fee is one cost of a transfer, paid in a token on a chain.
+type Fee struct {
+	Name   string   // e.g. "bridge", "relayer" or "destination gas"
+	Token  string   // token symbol
+	Chain  string   // chain name
+	Amount *big.Int // in base units of the token on that chain
+
+	// Included is set for fees taken from the amount sent, which AmountOut already reflects, such
+	// as a relayer fee. Other fees, such as gas, are paid on top.
+	Included bool
+}
+
+// Transaction is an unsigned transaction on the source chain. Nonce, gas price and, when Gas is
+// zero, the gas limit are filled in by the sender.
+type Transaction struct {
+	Description string // shown before signing, e.g. "Approve USDC"
+	ChainID     uint64
+	To          common.Address
+	Value       *big.Int
+	Data        []byte
+	Gas         uint64
+}
+
+// Transfer identifies a transfer in progress for TrackStatus.
+type Transfer struct {
+	Route        registry.BridgeRoute
+	SourceTxHash common.Hash
+	Sender       common.Address
+	Recipient    common.Address
+	Amount       *big.Int
+	Data         map[string]string // Quote.Data of the executed quote
+}
+
+// TransferState is the stage a transfer has reached.
+type TransferState string
+
+// Transfer states. Completed, failed and refunded are final.
+const (
+	StatePending   TransferState = "pending"   // source transaction not yet confirmed
+	StateInFlight  TransferState = "in_flight" // confirmed on the source chain, not yet delivered
+	StateCompleted TransferState = "completed"
+	StateFailed    TransferState = "failed"
+	StateRefunded  TransferState = "refunded"
+)
+
+// Final reports whether a transfer in state s will not change anymore.
+func (s TransferState) Final() bool {
+	return s == StateCompleted || s == StateFailed || s == StateRefunded
+}
+
+// Status is the progress of a transfer.
+type Status struct {
+	State             TransferState
+	DestinationTxHash common.Hash // zero until delivered
+	Message           string      // detail from the bridge, if any
+}
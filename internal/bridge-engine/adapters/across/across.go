@@ -0,0 +1,514 @@
+// Package across implements transfers through Across, whose relayers fill a deposit on the
+// destination chain within minutes and are repaid from the deposit later. It carries tokens
+// between any two chains with a SpokePool, including from one L2 to another or back to Ethereum.
+//
+// Quotes come from the Across API, which prices the relayer fee; the deposit itself is a
+// depositV3 call on the SpokePool of the source chain, named spoke_pool in the registry's
+// contracts for the across bridge. The amount a deposit delivers is fixed when it is sent: either a
+// relayer fills it in full before the fill deadline, or it is refunded on the source chain.
+package across
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	engine "github.com/xilverfang/syncora/internal/bridge-engine"
+	"github.com/xilverfang/syncora/internal/core/registry"
+)
+
+// BridgeName is the name of Across in the registry.
+const BridgeName = "across"
+
+// ContractSpokePool is the registry name of the Across contract on each chain.
+const ContractSpokePool = "spoke_pool"
+
+// DefaultAPI is the base URL of the public Across API.
+const DefaultAPI = "https://app.across.to/api"
+
+// quoteLifetime is how long a quote is executed after the API priced it. Relayer fees follow gas
+// prices, so older quotes risk a deposit no relayer fills before its deadline.
+const quoteLifetime = 5 * time.Minute
+
+// depositGasFallback is the gas counted for a deposit that cannot be estimated before its
+// approval is mined.
+const depositGasFallback = 150_000
+
+// wrappedNative is the registry token that Across moves in place of a chain's native currency.
+const wrappedNative = "WETH"
+
+var spokePoolABI = engine.MustParseABI(`[
+	{"type": "function", "name": "depositV3", "stateMutability": "payable",
+	 "inputs": [{"name": "depositor", "type": "address"}, {"name": "recipient", "type": "address"},
+	            {"name": "inputToken", "type": "address"}, {"name": "outputToken", "type": "address"},
+	            {"name": "inputAmount", "type": "uint256"}, {"name": "outputAmount", "type": "uint256"},
+	            {"name": "destinationChainId", "type": "uint256"}, {"name": "exclusiveRelayer", "type": "address"},
+	            {"name": "quoteTimestamp", "type": "uint32"}, {"name": "fillDeadline", "type": "uint32"},
+	            {"name": "exclusivityDeadline", "type": "uint32"}, {"name": "message", "type": "bytes"}],
+	 "outputs": []}
+]`)
+
+// Topics of the SpokePool events a deposit emits: V3FundsDeposited by older SpokePools and
+// FundsDeposited by those that use bytes32 addresses. Both index the deposit ID second.
+var (
+	v3FundsDeposited = crypto.Keccak256Hash([]byte("V3FundsDeposited(address,address,uint256,uint256,uint256,uint32,uint32,uint32,uint32,address,address,address,bytes)"))
+	fundsDeposited   = crypto.Keccak256Hash([]byte("FundsDeposited(bytes32,bytes32,uint256,uint256,uint256,uint256,uint32,uint32,uint32,bytes32,bytes32,bytes32,bytes)"))
+)
+
+// Keys of Quote.Data.
+const (
+	dataOutputAmount        = "output_amount"
+	dataQuoteTimestamp      = "quote_timestamp"
+	dataFillDeadline        = "fill_deadline"
+	dataExclusiveRelayer    = "exclusive_relayer"
+	dataExclusivityDeadline = "exclusivity_deadline"
+)
+
+// errSourceFailed is returned when the deposit transaction reverted.
+var errSourceFailed = errors.New("deposit transaction failed")
+
+// Adapter transfers tokens through Across.
+type Adapter struct {
+	reg    *registry.Registry
+	bridge *registry.Bridge
+	dial   engine.Dialer
+	api    string
+	http   *http.Client
+}
+
+var _ engine.BridgeAdapter = (*Adapter)(nil)
+
+// Adapters returns the Across adapter, using the public API, if the registry lists the across
+// bridge with SpokePool contracts, and no adapter otherwise.
+func Adapters(reg *registry.Registry, dial engine.Dialer) []engine.BridgeAdapter {
+	a, err := New(reg, dial, DefaultAPI)
+	if err != nil {
+		return nil
+	}
+	return []engine.BridgeAdapter{a}
+}
+
+// New returns an adapter for the across bridge of the registry that quotes and tracks transfers
+// through the Across API at api.
+func New(reg *registry.Registry, dial engine.Dialer, api string) (*Adapter, error) {
+	b, err := reg.Bridge(BridgeName)
+	if err != nil {
+		return nil, err
+	}
+	for chain := range b.Contracts {
+		if _, ok := b.Contract(chain, ContractSpokePool); ok {
+			return &Adapter{reg: reg, bridge: b, dial: dial, api: strings.TrimSuffix(api, "/"), http: &http.Client{Timeout: 15 * time.Second}}, nil
+		}
+	}
+	return nil, fmt.Errorf("bridge %s has no %s contracts", BridgeName, ContractSpokePool)
+}
+
+// Name returns the bridge's registry name.
+func (a *Adapter) Name() string {
+	return a.bridge.Name
+}
+
+// SupportedRoutes returns the bridge's registry routes between chains with a SpokePool.
+func (a *Adapter) SupportedRoutes(ctx context.Context) ([]registry.BridgeRoute, error) {
+	routes, err := a.reg.Routes(registry.RouteFilter{Bridge: a.bridge.Name})
+	if err != nil {
+		return nil, err
+	}
+	var supported []registry.BridgeRoute
+	for _, r := range routes {
+		if _, _, err := a.tokens(r); err == nil {
+			supported = append(supported, r)
+		}
+	}
+	return supported, nil
+}
+
+// Quote asks the Across API for the relayer fee of a transfer. The amount delivered is the amount
+// sent minus that fee, exactly: slippage does not apply. The gas of the deposit on the source chain
+// is estimated as a second fee.
+func (a *Adapter) Quote(ctx context.Context, req engine.QuoteRequest) (*engine.Quote, error) {
+	if req.Amount == nil || req.Amount.Sign() <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	input, output, err := a.tokens(req.Route)
+	if err != nil {
+		return nil, err
+	}
+	source, destination, err := a.chains(req.Route)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{
+		"inputToken":         {input.Hex()},
+		"outputToken":        {output.Hex()},
+		"originChainId":      {strconv.FormatUint(source.ChainID, 10)},
+		"destinationChainId": {strconv.FormatUint(destination.ChainID, 10)},
+		"amount":             {req.Amount.String()},
+		"recipient":          {req.Receiver().Hex()},
+	}
+	if req.Sender != (common.Address{}) {
+		query.Set("depositor", req.Sender.Hex())
+	}
+	var fees struct {
+		TotalRelayFee struct {
+			Total string `json:"total"`
+		} `json:"totalRelayFee"`
+		Timestamp            json.Number `json:"timestamp"`
+		FillDeadline         json.Number `json:"fillDeadline"`
+		ExclusiveRelayer     string      `json:"exclusiveRelayer"`
+		ExclusivityDeadline  json.Number `json:"exclusivityDeadline"`
+		SpokePoolAddress     string      `json:"spokePoolAddress"`
+		IsAmountTooLow       bool        `json:"isAmountTooLow"`
+		EstimatedFillTimeSec json.Number `json:"estimatedFillTimeSec"`
+		Limits               struct {
+			MinDeposit string `json:"minDeposit"`
+			MaxDeposit string `json:"maxDeposit"`
+		} `json:"limits"`
+	}
+	if err := a.get(ctx, "suggested-fees", query, &fees); err != nil {
+		return nil, err
+	}
+	token, err := a.reg.Token(req.Route.Token)
+	if err != nil {
+		return nil, err
+	}
+	if fees.IsAmountTooLow {
+		return nil, fmt.Errorf("amount is below the Across minimum of %s", formatLimit(token, req.Route.From, fees.Limits.MinDeposit))
+	}
+	if max, ok := new(big.Int).SetString(fees.Limits.MaxDeposit, 10); ok && req.Amount.Cmp(max) > 0 {
+		return nil, fmt.Errorf("amount is above the Across maximum of %s", token.FormatAmount(req.Route.From, max))
+	}
+	spokePool, _ := a.bridge.Contract(req.Route.From, ContractSpokePool)
+	if fees.SpokePoolAddress != "" && !strings.EqualFold(fees.SpokePoolAddress, spokePool) {
+		return nil, fmt.Errorf("the Across API names SpokePool %s on %s, but the registry lists %s", fees.SpokePoolAddress, req.Route.From, spokePool)
+	}
+	relayFee, ok := new(big.Int).SetString(fees.TotalRelayFee.Total, 10)
+	if !ok || relayFee.Sign() < 0 {
+		return nil, fmt.Errorf("invalid relayer fee %q from the Across API", fees.TotalRelayFee.Total)
+	}
+	out := new(big.Int).Sub(req.Amount, relayFee)
+	if out.Sign() <= 0 {
+		return nil, fmt.Errorf("the relayer fee of %s exceeds the amount", token.FormatAmount(req.Route.From, relayFee))
+	}
+	quotedAt, err1 := fees.Timestamp.Int64()
+	fillDeadline, err2 := fees.FillDeadline.Int64()
+	if err := errors.Join(err1, err2); err != nil || !common.IsHexAddress(fees.ExclusiveRelayer) {
+		return nil, fmt.Errorf("invalid quote from the Across API: timestamp %q, fill deadline %q, exclusive relayer %q",
+			fees.Timestamp, fees.FillDeadline, fees.ExclusiveRelayer)
+	}
+	exclusivityDeadline, _ := fees.ExclusivityDeadline.Int64()
+
+	eta, err := a.EstimateTime(ctx, req.Route)
+	if err != nil {
+		return nil, err
+	}
+	if seconds, err := fees.EstimatedFillTimeSec.Int64(); err == nil && seconds > 0 {
+		eta = time.Duration(seconds) * time.Second
+	}
+	quote := &engine.Quote{
+		Request:      req,
+		AmountOut:    out,
+		MinAmountOut: new(big.Int).Set(out),
+		ETA:          eta,
+		ExpiresAt:    time.Unix(quotedAt, 0).Add(quoteLifetime),
+		Data: map[string]string{
+			dataOutputAmount:        out.String(),
+			dataQuoteTimestamp:      strconv.FormatInt(quotedAt, 10),
+			dataFillDeadline:        strconv.FormatInt(fillDeadline, 10),
+			dataExclusiveRelayer:    common.HexToAddress(fees.ExclusiveRelayer).Hex(),
+			dataExclusivityDeadline: strconv.FormatInt(exclusivityDeadline, 10),
+		},
+	}
+
+	txs, err := a.transactions(ctx, quote)
+	if err != nil {
+		return nil, err
+	}
+	client, err := a.dial(ctx, req.Route.From)
+	if err != nil {
+		return nil, err
+	}
+	gas, err := engine.GasFee(ctx, client, source, req.Sender, txs, depositGasFallback)
+	if err != nil {
+		return nil, err
+	}
+	quote.Fees = []engine.Fee{{Name: "relayer", Token: token.Symbol, Chain: req.Route.From, Amount: relayFee, Included: true}, gas}
+	return quote, nil
+}
+
+// BuildTransaction returns the deposit on the source chain, preceded by an approval of the
+// SpokePool for ERC-20 tokens whose allowance does not cover the amount.
+func (a *Adapter) BuildTransaction(ctx context.Context, quote *engine.Quote) ([]engine.Transaction, error) {
+	if quote.Expired(time.Now()) {
+		return nil, errors.New("quote expired")
+	}
+	return a.transactions(ctx, quote)
+}
+
+// transactions returns the transactions that execute a quote.
+func (a *Adapter) transactions(ctx context.Context, quote *engine.Quote) ([]engine.Transaction, error) {
+	req := quote.Request
+	input, output, err := a.tokens(req.Route)
+	if err != nil {
+		return nil, err
+	}
+	source, destination, err := a.chains(req.Route)
+	if err != nil {
+		return nil, err
+	}
+	token, err := a.reg.Token(req.Route.Token)
+	if err != nil {
+		return nil, err
+	}
+	out, ok := new(big.Int).SetString(quote.Data[dataOutputAmount], 10)
+	if !ok {
+		return nil, errors.New("quote has no Across output amount")
+	}
+	var deadlines [3]uint32
+	for i, key := range []string{dataQuoteTimestamp, dataFillDeadline, dataExclusivityDeadline} {
+		v, err := strconv.ParseUint(quote.Data[key], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("quote has an invalid %s: %v", key, err)
+		}
+		deadlines[i] = uint32(v)
+	}
+	data, err := spokePoolABI.Pack("depositV3", req.Sender, req.Receiver(), input, output, req.Amount, out,
+		new(big.Int).SetUint64(destination.ChainID), common.HexToAddress(quote.Data[dataExclusiveRelayer]),
+		deadlines[0], deadlines[1], deadlines[2], []byte{})
+	if err != nil {
+		return nil, err
+	}
+	address, _ := a.bridge.Contract(req.Route.From, ContractSpokePool)
+	spokePool := common.HexToAddress(address)
+	deposit := engine.Transaction{
+		Description: fmt.Sprintf("Deposit %s to Across for %s", token.FormatAmount(req.Route.From, req.Amount), req.Route.To),
+		ChainID:     source.ChainID,
+		To:          spokePool,
+		Value:       new(big.Int),
+		Data:        data,
+	}
+
+	if d, _ := token.On(req.Route.From); d.Native {
+		deposit.Value = new(big.Int).Set(req.Amount)
+		return []engine.Transaction{deposit}, nil
+	}
+	client, err := a.dial(ctx, req.Route.From)
+	if err != nil {
+		return nil, err
+	}
+	var txs []engine.Transaction
+	approval, err := engine.Approval(ctx, client, source.ChainID, input, req.Sender, spokePool, req.Amount,
+		fmt.Sprintf("Approve %s for %s", token.FormatAmount(req.Route.From, req.Amount), a.bridge.Name))
+	if err != nil {
+		return nil, err
+	}
+	if approval != nil {
+		txs = append(txs, *approval)
+	}
+	return append(txs, deposit), nil
+}
+
+// TrackStatus follows a transfer: pending until the deposit is mined, in flight until a relayer
+// fills it, and completed with the fill transaction. Deposits not filled before their deadline
+// fail and are refunded on the source chain.
+func (a *Adapter) TrackStatus(ctx context.Context, transfer engine.Transfer) (*engine.Status, error) {
+	depositID, err := a.depositID(ctx, transfer)
+	if errors.Is(err, errSourceFailed) {
+		return &engine.Status{State: engine.StateFailed, Message: err.Error()}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if depositID == nil {
+		return &engine.Status{State: engine.StatePending}, nil
+	}
+	source, _, err := a.chains(transfer.Route)
+	if err != nil {
+		return nil, err
+	}
+
+	var status struct {
+		Status string `json:"status"`
+		FillTx string `json:"fillTx"`
+	}
+	query := url.Values{"originChainId": {strconv.FormatUint(source.ChainID, 10)}, "depositId": {depositID.String()}}
+	err = a.get(ctx, "deposit/status", query, &status)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.status == http.StatusNotFound {
+		// The API indexes deposits a few blocks after they are mined.
+		return &engine.Status{State: engine.StateInFlight}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch status.Status {
+	case "filled":
+		return &engine.Status{State: engine.StateCompleted, DestinationTxHash: common.HexToHash(status.FillTx)}, nil
+	case "expired":
+		return &engine.Status{State: engine.StateFailed, Message: "no relayer filled the deposit before its deadline; it will be refunded on " + transfer.Route.From}, nil
+	case "refunded":
+		return &engine.Status{State: engine.StateRefunded, Message: "the deposit was refunded on " + transfer.Route.From}, nil
+	default:
+		return &engine.Status{State: engine.StateInFlight, Message: status.Status}, nil
+	}
+}
+
+// EstimateTime returns the registry's time for the route; quotes use the fill time the Across API
+// expects instead.
+func (a *Adapter) EstimateTime(ctx context.Context, route registry.BridgeRoute) (time.Duration, error) {
+	routes, err := a.reg.Routes(registry.RouteFilter{Bridge: a.bridge.Name, Token: route.Token, From: route.From, To: route.To})
+	if err != nil {
+		return 0, err
+	}
+	if len(routes) == 0 {
+		return 0, fmt.Errorf("%w: %s %s from %s to %s", engine.ErrRouteNotSupported, a.bridge.Name, route.Token, route.From, route.To)
+	}
+	return routes[0].ETA, nil
+}
+
+// tokens returns the addresses of the route's token on the source and destination chains, with
+// WETH standing in for native ETH. Both chains need a SpokePool and the token the same decimals.
+// Routes of WETH itself are not supported, since Across delivers WETH as ETH.
+func (a *Adapter) tokens(route registry.BridgeRoute) (input, output common.Address, err error) {
+	unsupported := fmt.Errorf("%w: %s %s from %s to %s", engine.ErrRouteNotSupported, a.bridge.Name, route.Token, route.From, route.To)
+	if route.Bridge != "" && route.Bridge != a.bridge.Name || route.Token == wrappedNative {
+		return input, output, unsupported
+	}
+	_, fromPool := a.bridge.Contract(route.From, ContractSpokePool)
+	_, toPool := a.bridge.Contract(route.To, ContractSpokePool)
+	token, err := a.reg.Token(route.Token)
+	if !fromPool || !toPool || err != nil {
+		return input, output, unsupported
+	}
+	from, ok1 := a.address(token, route.From)
+	to, ok2 := a.address(token, route.To)
+	if !ok1 || !ok2 || decimals(token, route.From) != decimals(token, route.To) {
+		return input, output, unsupported
+	}
+	return from, to, nil
+}
+
+// address returns the ERC-20 contract of token on chain, or of WETH for the native currency.
+func (a *Adapter) address(token *registry.Token, chain string) (common.Address, bool) {
+	d, ok := token.On(chain)
+	if ok && d.Native {
+		weth, err := a.reg.Token(wrappedNative)
+		if err != nil {
+			return common.Address{}, false
+		}
+		d, ok = weth.On(chain)
+	}
+	if !ok || !common.IsHexAddress(d.Address) {
+		return common.Address{}, false
+	}
+	return common.HexToAddress(d.Address), true
+}
+
+// chains returns the route's source and destination chains.
+func (a *Adapter) chains(route registry.BridgeRoute) (source, destination *registry.Chain, err error) {
+	if source, err = a.reg.Chain(route.From); err != nil {
+		return nil, nil, err
+	}
+	if destination, err = a.reg.Chain(route.To); err != nil {
+		return nil, nil, err
+	}
+	return source, destination, nil
+}
+
+// depositID returns the Across deposit ID that the transfer's source transaction emitted, or nil
+// while the transaction is not mined.
+func (a *Adapter) depositID(ctx context.Context, transfer engine.Transfer) (*big.Int, error) {
+	client, err := a.dial(ctx, transfer.Route.From)
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := client.TransactionReceipt(ctx, transfer.SourceTxHash)
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deposit receipt on %s: %v", transfer.Route.From, err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("%w: %s on %s", errSourceFailed, transfer.SourceTxHash.Hex(), transfer.Route.From)
+	}
+	spokePool, _ := a.bridge.Contract(transfer.Route.From, ContractSpokePool)
+	for _, log := range receipt.Logs {
+		if log.Address == common.HexToAddress(spokePool) && len(log.Topics) == 4 &&
+			(log.Topics[0] == v3FundsDeposited || log.Topics[0] == fundsDeposited) {
+			return log.Topics[2].Big(), nil
+		}
+	}
+	return nil, fmt.Errorf("transaction %s emitted no deposit on the Across SpokePool of %s", transfer.SourceTxHash.Hex(), transfer.Route.From)
+}
+
+// apiError is an error response of the Across API.
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("Across API: %s (HTTP %d)", e.message, e.status)
+}
+
+// get calls an endpoint of the Across API and decodes its JSON response into v.
+func (a *Adapter) get(ctx context.Context, endpoint string, query url.Values, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.api+"/"+endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := a.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call the Across API: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read the Across API response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &e) != nil || e.Message == "" {
+			e.Message = http.StatusText(resp.StatusCode)
+		}
+		return &apiError{status: resp.StatusCode, message: e.Message}
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("invalid Across API response from %s: %v", endpoint, err)
+	}
+	return nil
+}
+
+// decimals returns the decimals of token on chain.
+func decimals(token *registry.Token, chain string) uint8 {
+	if d, ok := token.On(chain); ok && d.Decimals != 0 {
+		return d.Decimals
+	}
+	return token.Decimals
+}
+
+// formatLimit formats a deposit limit of the Across API, which is in base units on chain.
+func formatLimit(token *registry.Token, chain, limit string) string {
+	v, ok := new(big.Int).SetString(limit, 10)
+	if !ok {
+		return "an unknown amount"
+	}
+	return token.FormatAmount(chain, v)
+}
@@ -0,0 +1,273 @@
+package across
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	engine "github.com/xilverfang/syncora/internal/bridge-engine"
+	"github.com/xilverfang/syncora/internal/core/registry"
+)
+
+const (
+	fakeGasPrice = 100_000_000
+	fakeGas      = 90_000
+)
+
+var (
+	sender             = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	arbitrumPool       = common.HexToAddress("0xe35e9842fceaCA96570B734083f4a58e8F7C5f2A")
+	arbitrumWETH       = common.HexToAddress("0x82aF49447D8a07e3bd95BD0d56f35241523fBab1")
+	baseWETH           = common.HexToAddress("0x4200000000000000000000000000000000000006")
+	arbitrumUSDC       = common.HexToAddress("0xaf88d065e77c8cC2239327C5EDb3A432268e5831")
+	ethRoute           = registry.BridgeRoute{Bridge: "across", Token: "ETH", From: "arbitrum", To: "base"}
+	usdcRoute          = registry.BridgeRoute{Bridge: "across", Token: "USDC", From: "arbitrum", To: "ethereum"}
+	oneETH             = big.NewInt(1e18)
+	relayFee     int64 = 1e15
+)
+
+// fakeClient answers ERC-20 allowance calls with a fixed value, prices gas and returns known
+// receipts.
+type fakeClient struct {
+	allowance *big.Int
+	receipts  map[common.Hash]*types.Receipt
+}
+
+func (f *fakeClient) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	return common.LeftPadBytes(f.allowance.Bytes(), 32), nil
+}
+
+func (f *fakeClient) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	if r, ok := f.receipts[hash]; ok {
+		return r, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func (f *fakeClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(fakeGasPrice), nil
+}
+
+func (f *fakeClient) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	return fakeGas, nil
+}
+
+// fakeAPI serves the Across API endpoints the adapter calls from handlers set by each test.
+type fakeAPI struct {
+	fees   func(w http.ResponseWriter, r *http.Request)
+	status func(w http.ResponseWriter, r *http.Request)
+}
+
+func newAdapter(t *testing.T, client *fakeClient, api *fakeAPI) *Adapter {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/suggested-fees", func(w http.ResponseWriter, r *http.Request) { api.fees(w, r) })
+	mux.HandleFunc("/api/deposit/status", func(w http.ResponseWriter, r *http.Request) { api.status(w, r) })
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	reg, err := registry.Default()
+	if err != nil {
+		t.Fatalf("Default: %v", err)
+	}
+	a, err := New(reg, func(ctx context.Context, chain string) (engine.Client, error) { return client, nil }, server.URL+"/api/")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return a
+}
+
+// suggestedFees answers like the Across API for a quote made now on Arbitrum that charges
+// the given relayer fee.
+func suggestedFees(total int64) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now().Unix()
+		json.NewEncoder(w).Encode(map[string]any{
+			"totalRelayFee":        map[string]string{"pct": "1000000000000000", "total": strconv.FormatInt(total, 10)},
+			"timestamp":            strconv.FormatInt(now, 10),
+			"fillDeadline":         strconv.FormatInt(now+4*3600, 10),
+			"exclusiveRelayer":     "0x0000000000000000000000000000000000000000",
+			"exclusivityDeadline":  0,
+			"spokePoolAddress":     arbitrumPool.Hex(),
+			"isAmountTooLow":       false,
+			"estimatedFillTimeSec": 4,
+			"limits":               map[string]string{"minDeposit": "100000", "maxDeposit": "1000000000000000000000"},
+		})
+	}
+}
+
+func TestSupportedRoutes(t *testing.T) {
+	a := newAdapter(t, &fakeClient{}, &fakeAPI{})
+	routes, err := a.SupportedRoutes(context.Background())
+	if err != nil {
+		t.Fatalf("SupportedRoutes: %v", err)
+	}
+	has := func(token, from, to string) bool {
+		for _, r := range routes {
+			if r.Token == token && r.From == from && r.To == to {
+				return true
+			}
+		}
+		return false
+	}
+	if !has("ETH", "arbitrum", "base") || !has("ETH", "optimism", "ethereum") || !has("USDC", "polygon", "arbitrum") {
+		t.Fatalf("SupportedRoutes lacks L2 routes: %v", routes)
+	}
+	if has("WETH", "arbitrum", "base") || has("ETH", "arbitrum", "polygon") {
+		t.Fatalf("SupportedRoutes has unsupported routes: %v", routes)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ETH", func(t *testing.T) {
+		api := &fakeAPI{fees: func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			if q.Get("inputToken") != arbitrumWETH.Hex() || q.Get("outputToken") != baseWETH.Hex() ||
+				q.Get("originChainId") != "42161" || q.Get("destinationChainId") != "8453" ||
+				q.Get("amount") != oneETH.String() || q.Get("depositor") != sender.Hex() {
+				http.Error(w, `{"message": "unexpected query `+r.URL.RawQuery+`"}`, http.StatusBadRequest)
+				return
+			}
+			suggestedFees(relayFee)(w, r)
+		}}
+		a := newAdapter(t, &fakeClient{}, api)
+		quote, err := a.Quote(ctx, engine.QuoteRequest{Route: ethRoute, Amount: oneETH, Sender: sender, SlippageBPS: 50})
+		if err != nil {
+			t.Fatalf("Quote: %v", err)
+		}
+		want := new(big.Int).Sub(oneETH, big.NewInt(relayFee))
+		if quote.AmountOut.Cmp(want) != 0 || quote.MinAmountOut.Cmp(want) != 0 || quote.ETA != 4*time.Second || quote.Expired(time.Now()) {
+			t.Fatalf("unexpected quote %+v", quote)
+		}
+		if len(quote.Fees) != 2 || quote.Fees[0].Name != "relayer" || quote.Fees[0].Amount.Int64() != relayFee || !quote.Fees[0].Included || quote.Fees[1].Included ||
+			quote.Fees[1].Token != "ETH" || quote.Fees[1].Chain != "arbitrum" || quote.Fees[1].Amount.Int64() != fakeGasPrice*fakeGas {
+			t.Fatalf("unexpected fees %+v", quote.Fees)
+		}
+
+		txs, err := a.BuildTransaction(ctx, quote)
+		if err != nil {
+			t.Fatalf("BuildTransaction: %v", err)
+		}
+		if len(txs) != 1 || txs[0].To != arbitrumPool || txs[0].Value.Cmp(oneETH) != 0 || txs[0].ChainID != 42161 {
+			t.Fatalf("unexpected transactions %+v", txs)
+		}
+		args, err := spokePoolABI.Methods["depositV3"].Inputs.Unpack(txs[0].Data[4:])
+		if err != nil {
+			t.Fatalf("unpack depositV3: %v", err)
+		}
+		if args[0] != sender || args[1] != sender || args[2] != arbitrumWETH || args[3] != baseWETH ||
+			args[5].(*big.Int).Cmp(want) != 0 || args[6].(*big.Int).Int64() != 8453 {
+			t.Fatalf("unexpected depositV3 arguments %v", args)
+		}
+		if txs[0].Description != "Deposit 1 ETH to Across for base" {
+			t.Fatalf("Description = %q", txs[0].Description)
+		}
+	})
+
+	t.Run("USDC", func(t *testing.T) {
+		a := newAdapter(t, &fakeClient{allowance: big.NewInt(0)}, &fakeAPI{fees: suggestedFees(50_000)})
+		amount := big.NewInt(100_000_000)
+		quote, err := a.Quote(ctx, engine.QuoteRequest{Route: usdcRoute, Amount: amount, Sender: sender})
+		if err != nil {
+			t.Fatalf("Quote: %v", err)
+		}
+		if quote.Fees[1].Amount.Int64() != 2*fakeGasPrice*fakeGas {
+			t.Fatalf("gas fee = %s, want an approval and a deposit", quote.Fees[1].Amount)
+		}
+		txs, err := a.BuildTransaction(ctx, quote)
+		if err != nil {
+			t.Fatalf("BuildTransaction: %v", err)
+		}
+		if len(txs) != 2 || txs[0].To != arbitrumUSDC || txs[1].To != arbitrumPool || txs[1].Value.Sign() != 0 ||
+			!bytes.Equal(txs[1].Data[:4], spokePoolABI.Methods["depositV3"].ID) {
+			t.Fatalf("unexpected transactions %+v", txs)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for name, fees := range map[string]func(w http.ResponseWriter, r *http.Request){
+			"amount too low": func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"isAmountTooLow": true, "limits": {"minDeposit": "2000000000000000"}}`))
+			},
+			"spoke pool mismatch": func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"spokePoolAddress": "0x2222222222222222222222222222222222222222", "totalRelayFee": {"total": "1"}}`))
+			},
+			"API error": func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, `{"message": "Unsupported token"}`, http.StatusBadRequest)
+			},
+		} {
+			a := newAdapter(t, &fakeClient{}, &fakeAPI{fees: fees})
+			if _, err := a.Quote(ctx, engine.QuoteRequest{Route: ethRoute, Amount: oneETH, Sender: sender}); err == nil {
+				t.Errorf("%s: expected error", name)
+			}
+		}
+		a := newAdapter(t, &fakeClient{}, &fakeAPI{fees: suggestedFees(relayFee)})
+		weth := registry.BridgeRoute{Bridge: "across", Token: "WETH", From: "arbitrum", To: "base"}
+		if _, err := a.Quote(ctx, engine.QuoteRequest{Route: weth, Amount: oneETH, Sender: sender}); !errors.Is(err, engine.ErrRouteNotSupported) {
+			t.Errorf("WETH: expected ErrRouteNotSupported, got %v", err)
+		}
+	})
+}
+
+func TestTrackStatus(t *testing.T) {
+	ctx := context.Background()
+	source := common.HexToHash("0x01")
+	fill := common.HexToHash("0x02")
+	var answer string
+	api := &fakeAPI{status: func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("depositId") != "77" || r.URL.Query().Get("originChainId") != "42161" {
+			http.Error(w, `{"message": "unexpected query"}`, http.StatusBadRequest)
+			return
+		}
+		if answer == "" {
+			http.Error(w, `{"message": "Deposit not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"status": "` + answer + `", "fillTx": "` + fill.Hex() + `"}`))
+	}}
+	client := &fakeClient{receipts: map[common.Hash]*types.Receipt{}}
+	a := newAdapter(t, client, api)
+	transfer := engine.Transfer{Route: ethRoute, SourceTxHash: source, Sender: sender, Recipient: sender, Amount: oneETH}
+	check := func(want engine.TransferState, wantHash common.Hash) {
+		t.Helper()
+		status, err := a.TrackStatus(ctx, transfer)
+		if err != nil {
+			t.Fatalf("TrackStatus: %v", err)
+		}
+		if status.State != want || status.DestinationTxHash != wantHash {
+			t.Fatalf("TrackStatus = %+v, want %s with %s", status, want, wantHash)
+		}
+	}
+
+	check(engine.StatePending, common.Hash{})
+	log := &types.Log{
+		Address: arbitrumPool,
+		Topics:  []common.Hash{fundsDeposited, common.BigToHash(big.NewInt(8453)), common.BigToHash(big.NewInt(77)), common.BytesToHash(sender.Bytes())},
+	}
+	client.receipts[source] = &types.Receipt{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{log}}
+	check(engine.StateInFlight, common.Hash{}) // not indexed by the API yet
+	answer = "pending"
+	check(engine.StateInFlight, common.Hash{})
+	answer = "filled"
+	check(engine.StateCompleted, fill)
+	answer = "expired"
+	check(engine.StateFailed, common.Hash{})
+
+	log.Topics[0] = v3FundsDeposited
+	answer = "filled"
+	check(engine.StateCompleted, fill)
+	client.receipts[source].Status = types.ReceiptStatusFailed
+	check(engine.StateFailed, common.Hash{})
+}
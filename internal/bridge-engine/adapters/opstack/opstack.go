@@ -0,0 +1,341 @@
+// Package opstack implements deposits through the standard bridge of OP Stack chains, such as OP
+// Mainnet and Base, from Ethereum to the L2. Withdrawals to Ethereum take a week and must be
+// proven and finalized there, so they are not supported.
+//
+// A bridge in the registry is served by this package when its contracts on the L1 include a
+// standard_bridge and a portal. Tokens with their own bridge contract, such as DAI on OP Mainnet,
+// name it <symbol>_bridge in lower case, e.g. dai_bridge; it must implement depositERC20To like the
+// standard bridge.
+package opstack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	engine "github.com/xilverfang/syncora/internal/bridge-engine"
+	"github.com/xilverfang/syncora/internal/core/registry"
+)
+
+// Contract names in the registry.
+const (
+	ContractStandardBridge = "standard_bridge"
+	ContractPortal         = "portal"
+)
+
+// minGasLimit is the L2 gas a deposit may use to finalize, the value the OP Stack bridge
+// interfaces use for token deposits.
+const minGasLimit uint32 = 200_000
+
+// depositGasFallback is the L1 gas counted for a deposit that cannot be estimated before its
+// approval is mined; token deposits through the standard bridge use less.
+const depositGasFallback = 250_000
+
+// depositTxType is the EIP-2718 type of L2 deposit transactions.
+const depositTxType = 0x7E
+
+var bridgeABI = engine.MustParseABI(`[
+	{"type": "function", "name": "depositETHTo", "stateMutability": "payable",
+	 "inputs": [{"name": "_to", "type": "address"}, {"name": "_minGasLimit", "type": "uint32"}, {"name": "_extraData", "type": "bytes"}],
+	 "outputs": []},
+	{"type": "function", "name": "depositERC20To", "stateMutability": "nonpayable",
+	 "inputs": [{"name": "_l1Token", "type": "address"}, {"name": "_l2Token", "type": "address"}, {"name": "_to", "type": "address"},
+	            {"name": "_amount", "type": "uint256"}, {"name": "_minGasLimit", "type": "uint32"}, {"name": "_extraData", "type": "bytes"}],
+	 "outputs": []}
+]`)
+
+// transactionDeposited is the topic of the OptimismPortal event
+// TransactionDeposited(address indexed from, address indexed to, uint256 indexed version, bytes opaqueData).
+var transactionDeposited = crypto.Keccak256Hash([]byte("TransactionDeposited(address,address,uint256,bytes)"))
+
+// errSourceFailed is returned when the deposit transaction on the L1 reverted.
+var errSourceFailed = errors.New("deposit transaction failed")
+
+// Adapter deposits to one OP Stack chain through its standard bridge.
+type Adapter struct {
+	reg    *registry.Registry
+	bridge *registry.Bridge
+	l1     string // chain holding the bridge contracts
+	dial   engine.Dialer
+}
+
+var _ engine.BridgeAdapter = (*Adapter)(nil)
+
+// Adapters returns an adapter for every bridge in the registry that has standard_bridge and
+// portal contracts.
+func Adapters(reg *registry.Registry, dial engine.Dialer) []engine.BridgeAdapter {
+	var adapters []engine.BridgeAdapter
+	for _, b := range reg.Bridges() {
+		if a, err := New(reg, b.Name, dial); err == nil {
+			adapters = append(adapters, a)
+		}
+	}
+	return adapters
+}
+
+// New returns the adapter for the named bridge of the registry.
+func New(reg *registry.Registry, name string, dial engine.Dialer) (*Adapter, error) {
+	b, err := reg.Bridge(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, chain := range slices.Sorted(maps.Keys(b.Contracts)) {
+		_, hasBridge := b.Contract(chain, ContractStandardBridge)
+		_, hasPortal := b.Contract(chain, ContractPortal)
+		if hasBridge && hasPortal {
+			return &Adapter{reg: reg, bridge: b, l1: chain, dial: dial}, nil
+		}
+	}
+	return nil, fmt.Errorf("bridge %s has no %s and %s contracts", name, ContractStandardBridge, ContractPortal)
+}
+
+// Name returns the bridge's registry name.
+func (a *Adapter) Name() string {
+	return a.bridge.Name
+}
+
+// SupportedRoutes returns the bridge's registry routes from the L1.
+func (a *Adapter) SupportedRoutes(ctx context.Context) ([]registry.BridgeRoute, error) {
+	return a.reg.Routes(registry.RouteFilter{Bridge: a.bridge.Name, From: a.l1})
+}
+
+// Quote prices a deposit. Deposits have no bridge fee; the L1 gas of the deposit pays for its
+// execution on the L2, so the full amount arrives. That gas is estimated as the quote's fee.
+func (a *Adapter) Quote(ctx context.Context, req engine.QuoteRequest) (*engine.Quote, error) {
+	if req.Amount == nil || req.Amount.Sign() <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	eta, err := a.EstimateTime(ctx, req.Route)
+	if err != nil {
+		return nil, err
+	}
+	txs, err := a.transactions(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := a.reg.Chain(a.l1)
+	if err != nil {
+		return nil, err
+	}
+	client, err := a.dial(ctx, a.l1)
+	if err != nil {
+		return nil, err
+	}
+	gas, err := engine.GasFee(ctx, client, chain, req.Sender, txs, depositGasFallback)
+	if err != nil {
+		return nil, err
+	}
+	return &engine.Quote{
+		Request:      req,
+		AmountOut:    new(big.Int).Set(req.Amount),
+		MinAmountOut: new(big.Int).Set(req.Amount),
+		Fees:         []engine.Fee{gas},
+		ETA:          eta,
+	}, nil
+}
+
+// BuildTransaction returns the deposit on the L1, preceded by an approval of the bridge for ERC-20
+// tokens whose allowance does not cover the amount.
+func (a *Adapter) BuildTransaction(ctx context.Context, quote *engine.Quote) ([]engine.Transaction, error) {
+	if quote.Expired(time.Now()) {
+		return nil, errors.New("quote expired")
+	}
+	return a.transactions(ctx, quote.Request)
+}
+
+// transactions returns the transactions that execute a deposit.
+func (a *Adapter) transactions(ctx context.Context, req engine.QuoteRequest) ([]engine.Transaction, error) {
+	token, err := a.reg.Token(req.Route.Token)
+	if err != nil {
+		return nil, err
+	}
+	l1, l2, err := a.deployments(req.Route)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := a.reg.Chain(a.l1)
+	if err != nil {
+		return nil, err
+	}
+	description := fmt.Sprintf("Deposit %s to %s", token.FormatAmount(a.l1, req.Amount), req.Route.To)
+
+	if l1.Native {
+		standard, _ := a.bridge.Contract(a.l1, ContractStandardBridge)
+		data, err := bridgeABI.Pack("depositETHTo", req.Receiver(), minGasLimit, []byte{})
+		if err != nil {
+			return nil, err
+		}
+		return []engine.Transaction{{Description: description, ChainID: chain.ChainID, To: common.HexToAddress(standard), Value: new(big.Int).Set(req.Amount), Data: data}}, nil
+	}
+
+	bridge := common.HexToAddress(a.tokenBridge(token.Symbol))
+	l1Token, l2Token := common.HexToAddress(l1.Address), common.HexToAddress(l2.Address)
+	client, err := a.dial(ctx, a.l1)
+	if err != nil {
+		return nil, err
+	}
+	var txs []engine.Transaction
+	approval, err := engine.Approval(ctx, client, chain.ChainID, l1Token, req.Sender, bridge, req.Amount,
+		fmt.Sprintf("Approve %s for %s", token.FormatAmount(a.l1, req.Amount), a.bridge.Name))
+	if err != nil {
+		return nil, err
+	}
+	if approval != nil {
+		txs = append(txs, *approval)
+	}
+	data, err := bridgeABI.Pack("depositERC20To", l1Token, l2Token, req.Receiver(), req.Amount, minGasLimit, []byte{})
+	if err != nil {
+		return nil, err
+	}
+	return append(txs, engine.Transaction{Description: description, ChainID: chain.ChainID, To: bridge, Value: new(big.Int), Data: data}), nil
+}
+
+// TrackStatus follows a deposit: pending until the L1 transaction is mined, in flight until the
+// deposit transaction it derives on the L2 is mined, and completed or failed with that transaction.
+func (a *Adapter) TrackStatus(ctx context.Context, transfer engine.Transfer) (*engine.Status, error) {
+	l2Hash, err := a.depositHash(ctx, transfer)
+	if errors.Is(err, errSourceFailed) {
+		return &engine.Status{State: engine.StateFailed, Message: err.Error()}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if l2Hash == (common.Hash{}) {
+		return &engine.Status{State: engine.StatePending}, nil
+	}
+	client, err := a.dial(ctx, transfer.Route.To)
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := client.TransactionReceipt(ctx, l2Hash)
+	if errors.Is(err, ethereum.NotFound) {
+		return &engine.Status{State: engine.StateInFlight, DestinationTxHash: l2Hash}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deposit receipt on %s: %v", transfer.Route.To, err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return &engine.Status{State: engine.StateFailed, DestinationTxHash: l2Hash,
+			Message: "the deposit failed on " + transfer.Route.To + "; it can be replayed through the L2 cross-domain messenger"}, nil
+	}
+	return &engine.Status{State: engine.StateCompleted, DestinationTxHash: l2Hash}, nil
+}
+
+// EstimateTime returns the registry's time for the route: deposits are included on the L2 once
+// the L1 block is seen by its sequencer.
+func (a *Adapter) EstimateTime(ctx context.Context, route registry.BridgeRoute) (time.Duration, error) {
+	routes, err := a.reg.Routes(registry.RouteFilter{Bridge: a.bridge.Name, Token: route.Token, From: route.From, To: route.To})
+	if err != nil {
+		return 0, err
+	}
+	if len(routes) == 0 || route.From != a.l1 {
+		return 0, fmt.Errorf("%w: %s %s from %s to %s", engine.ErrRouteNotSupported, a.bridge.Name, route.Token, route.From, route.To)
+	}
+	return routes[0].ETA, nil
+}
+
+// deployments returns the route's token on the L1 and the L2, which must both be native or both
+// be contracts.
+func (a *Adapter) deployments(route registry.BridgeRoute) (l1, l2 registry.Deployment, err error) {
+	token, err := a.reg.Token(route.Token)
+	if err != nil {
+		return l1, l2, err
+	}
+	l1, ok1 := token.On(a.l1)
+	l2, ok2 := token.On(route.To)
+	if route.From != a.l1 || !ok1 || !ok2 || l1.Native != l2.Native {
+		return l1, l2, fmt.Errorf("%w: %s %s from %s to %s", engine.ErrRouteNotSupported, a.bridge.Name, route.Token, route.From, route.To)
+	}
+	return l1, l2, nil
+}
+
+// tokenBridge returns the contract that deposits an ERC-20 token: its own bridge if it has one,
+// and the standard bridge otherwise.
+func (a *Adapter) tokenBridge(symbol string) string {
+	if address, ok := a.bridge.Contract(a.l1, strings.ToLower(symbol)+"_bridge"); ok {
+		return address
+	}
+	address, _ := a.bridge.Contract(a.l1, ContractStandardBridge)
+	return address
+}
+
+// depositHash returns the hash of the L2 deposit transaction derived from the transfer's L1
+// transaction, or the zero hash while the L1 transaction is not mined.
+func (a *Adapter) depositHash(ctx context.Context, transfer engine.Transfer) (common.Hash, error) {
+	client, err := a.dial(ctx, a.l1)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	receipt, err := client.TransactionReceipt(ctx, transfer.SourceTxHash)
+	if errors.Is(err, ethereum.NotFound) {
+		return common.Hash{}, nil
+	}
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get deposit receipt on %s: %v", a.l1, err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return common.Hash{}, fmt.Errorf("%w: %s on %s", errSourceFailed, transfer.SourceTxHash.Hex(), a.l1)
+	}
+	portal, _ := a.bridge.Contract(a.l1, ContractPortal)
+	for _, log := range receipt.Logs {
+		if log.Address == common.HexToAddress(portal) && len(log.Topics) == 4 && log.Topics[0] == transactionDeposited {
+			return DepositTxHash(log)
+		}
+	}
+	return common.Hash{}, fmt.Errorf("transaction %s emitted no deposit on the %s portal", transfer.SourceTxHash.Hex(), a.bridge.Name)
+}
+
+// DepositTxHash computes the hash of the L2 deposit transaction that an OptimismPortal
+// TransactionDeposited log derives, as specified for user deposits: a type 0x7E transaction whose
+// source hash commits to the L1 block hash and the log's index in the block.
+func DepositTxHash(log *types.Log) (common.Hash, error) {
+	if len(log.Topics) != 4 || log.Topics[0] != transactionDeposited {
+		return common.Hash{}, errors.New("not a TransactionDeposited log")
+	}
+	if log.Topics[3] != (common.Hash{}) {
+		return common.Hash{}, fmt.Errorf("unsupported deposit version %s", log.Topics[3].Big())
+	}
+
+	// The data is the ABI encoding of opaqueData: its offset, its length, then
+	// abi.encodePacked(mint uint256, value uint256, gasLimit uint64, isCreation bool, data bytes).
+	if len(log.Data) < 64 {
+		return common.Hash{}, errors.New("truncated deposit data")
+	}
+	length := new(big.Int).SetBytes(log.Data[32:64])
+	if !length.IsUint64() || length.Uint64() < 73 || uint64(len(log.Data)-64) < length.Uint64() {
+		return common.Hash{}, errors.New("truncated deposit data")
+	}
+	opaque := log.Data[64 : 64+length.Uint64()]
+	mint := new(big.Int).SetBytes(opaque[0:32])
+	value := new(big.Int).SetBytes(opaque[32:64])
+	gas := new(big.Int).SetBytes(opaque[64:72]).Uint64()
+	isCreation := opaque[72] != 0
+	data := opaque[73:]
+
+	var to []byte // empty for contract creation
+	if !isCreation {
+		to = common.BytesToAddress(log.Topics[2].Bytes()).Bytes()
+	}
+	from := common.BytesToAddress(log.Topics[1].Bytes())
+
+	var logIndex common.Hash
+	new(big.Int).SetUint64(uint64(log.Index)).FillBytes(logIndex[:])
+	depositID := crypto.Keccak256(log.BlockHash.Bytes(), logIndex.Bytes())
+	sourceHash := crypto.Keccak256Hash(make([]byte, 32), depositID) // domain 0: user deposits
+
+	enc, err := rlp.EncodeToBytes([]any{sourceHash, from, to, mint, value, gas, false, data})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash([]byte{depositTxType}, enc), nil
+}
@@ -0,0 +1,277 @@
+package opstack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	engine "github.com/xilverfang/syncora/internal/bridge-engine"
+	"github.com/xilverfang/syncora/internal/core/registry"
+)
+
+// fakeClient answers ERC-20 allowance calls with a fixed value and returns known receipts. Gas is
+// priced at 10 gwei and every call uses 100000 gas, except token deposits, which cannot be
+// estimated before their approval.
+type fakeClient struct {
+	allowance *big.Int
+	receipts  map[common.Hash]*types.Receipt
+}
+
+const (
+	fakeGasPrice = 10_000_000_000
+	fakeGas      = 100_000
+)
+
+func (f *fakeClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(fakeGasPrice), nil
+}
+
+func (f *fakeClient) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	if bytes.HasPrefix(msg.Data, bridgeABI.Methods["depositERC20To"].ID) {
+		return 0, errors.New("execution reverted: ERC20: insufficient allowance")
+	}
+	return fakeGas, nil
+}
+
+func (f *fakeClient) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	return common.LeftPadBytes(f.allowance.Bytes(), 32), nil
+}
+
+func (f *fakeClient) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	if r, ok := f.receipts[hash]; ok {
+		return r, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func newAdapter(t *testing.T, name string, client *fakeClient) *Adapter {
+	t.Helper()
+	reg, err := registry.Default()
+	if err != nil {
+		t.Fatalf("Default: %v", err)
+	}
+	a, err := New(reg, name, func(ctx context.Context, chain string) (engine.Client, error) { return client, nil })
+	if err != nil {
+		t.Fatalf("New(%s): %v", name, err)
+	}
+	return a
+}
+
+func TestAdapters(t *testing.T) {
+	reg, err := registry.Default()
+	if err != nil {
+		t.Fatalf("Default: %v", err)
+	}
+	var names []string
+	for _, a := range Adapters(reg, nil) {
+		names = append(names, a.Name())
+	}
+	if len(names) != 2 || names[0] != "optimism-bridge" || names[1] != "base-bridge" {
+		t.Fatalf("Adapters = %v", names)
+	}
+	if _, err := New(reg, "hop", nil); err == nil {
+		t.Fatal("expected error for a bridge without OP Stack contracts")
+	}
+
+	a := newAdapter(t, "base-bridge", &fakeClient{})
+	routes, err := a.SupportedRoutes(context.Background())
+	if err != nil || len(routes) != 2 {
+		t.Fatalf("SupportedRoutes = %v, %v", routes, err)
+	}
+	for _, r := range routes {
+		if r.From != "ethereum" || r.To != "base" {
+			t.Fatalf("unexpected route %+v", r)
+		}
+	}
+}
+
+func TestBuildTransaction(t *testing.T) {
+	ctx := context.Background()
+	sender := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	amount := big.NewInt(1_500_000_000_000_000_000)
+
+	t.Run("ETH", func(t *testing.T) {
+		a := newAdapter(t, "optimism-bridge", &fakeClient{})
+		quote, err := a.Quote(ctx, engine.QuoteRequest{
+			Route:  registry.BridgeRoute{Bridge: "optimism-bridge", Token: "ETH", From: "ethereum", To: "optimism"},
+			Amount: amount,
+			Sender: sender,
+		})
+		if err != nil {
+			t.Fatalf("Quote: %v", err)
+		}
+		if quote.MinAmountOut.Cmp(amount) != 0 || len(quote.Fees) != 1 || quote.ETA == 0 {
+			t.Fatalf("unexpected quote %+v", quote)
+		}
+		if fee := quote.Fees[0]; fee.Name != "source gas" || fee.Token != "ETH" || fee.Chain != "ethereum" ||
+			fee.Amount.Cmp(big.NewInt(fakeGasPrice*fakeGas)) != 0 {
+			t.Fatalf("unexpected gas fee %+v", fee)
+		}
+		txs, err := a.BuildTransaction(ctx, quote)
+		if err != nil {
+			t.Fatalf("BuildTransaction: %v", err)
+		}
+		if len(txs) != 1 || txs[0].Value.Cmp(amount) != 0 || txs[0].ChainID != 1 ||
+			txs[0].To != common.HexToAddress("0x99C9fc46f92E8a1c0deC1b1747d010903E884bE1") ||
+			!bytes.Equal(txs[0].Data[:4], bridgeABI.Methods["depositETHTo"].ID) {
+			t.Fatalf("unexpected transactions %+v", txs)
+		}
+		if txs[0].Description != "Deposit 1.5 ETH to optimism" {
+			t.Fatalf("Description = %q", txs[0].Description)
+		}
+	})
+
+	t.Run("DAI", func(t *testing.T) {
+		a := newAdapter(t, "optimism-bridge", &fakeClient{allowance: big.NewInt(1)})
+		quote, err := a.Quote(ctx, engine.QuoteRequest{
+			Route:  registry.BridgeRoute{Bridge: "optimism-bridge", Token: "DAI", From: "ethereum", To: "optimism"},
+			Amount: amount,
+			Sender: sender,
+		})
+		if err != nil {
+			t.Fatalf("Quote: %v", err)
+		}
+		// The approval is estimated; the deposit, which needs it, counts as the fallback.
+		if want := big.NewInt(fakeGasPrice * (fakeGas + depositGasFallback)); quote.Fees[0].Amount.Cmp(want) != 0 {
+			t.Fatalf("gas fee = %s, want %s", quote.Fees[0].Amount, want)
+		}
+		txs, err := a.BuildTransaction(ctx, quote)
+		if err != nil {
+			t.Fatalf("BuildTransaction: %v", err)
+		}
+		daiBridge := common.HexToAddress("0x10E6593CDda8c58a1d0f14C5164B376352a55f2F")
+		if len(txs) != 2 {
+			t.Fatalf("got %d transactions, want an approval and a deposit", len(txs))
+		}
+		if txs[0].To != common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F") || txs[0].Value.Sign() != 0 {
+			t.Fatalf("unexpected approval %+v", txs[0])
+		}
+		if txs[1].To != daiBridge || !bytes.Equal(txs[1].Data[:4], bridgeABI.Methods["depositERC20To"].ID) {
+			t.Fatalf("unexpected deposit %+v", txs[1])
+		}
+
+		// An allowance that covers the amount needs no approval.
+		a = newAdapter(t, "optimism-bridge", &fakeClient{allowance: amount})
+		if txs, err := a.BuildTransaction(ctx, quote); err != nil || len(txs) != 1 {
+			t.Fatalf("BuildTransaction with allowance = %d transactions, %v", len(txs), err)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		a := newAdapter(t, "optimism-bridge", &fakeClient{})
+		for _, route := range []registry.BridgeRoute{
+			{Bridge: "optimism-bridge", Token: "ETH", From: "optimism", To: "ethereum"},
+			{Bridge: "optimism-bridge", Token: "USDC", From: "ethereum", To: "optimism"},
+		} {
+			if _, err := a.Quote(ctx, engine.QuoteRequest{Route: route, Amount: amount, Sender: sender}); err == nil {
+				t.Errorf("Quote(%s from %s): expected error", route.Token, route.From)
+			}
+		}
+		route := registry.BridgeRoute{Bridge: "optimism-bridge", Token: "ETH", From: "ethereum", To: "optimism"}
+		if _, err := a.Quote(ctx, engine.QuoteRequest{Route: route, Amount: new(big.Int), Sender: sender}); err == nil {
+			t.Error("Quote(0): expected error")
+		}
+	})
+}
+
+func TestTrackStatus(t *testing.T) {
+	ctx := context.Background()
+	portal := common.HexToAddress("0xbEb5Fc579115071764c7423A4f12eDde41f106Ed")
+	sender := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	source := common.HexToHash("0x01")
+
+	// opaqueData of an ETH deposit: mint, value, gas limit, isCreation and no calldata.
+	opaque := append(common.LeftPadBytes(big.NewInt(1e18).Bytes(), 32), common.LeftPadBytes(big.NewInt(1e18).Bytes(), 32)...)
+	opaque = append(opaque, 0, 0, 0, 0, 0, 0x01, 0x86, 0xa0, 0)
+	data := append(common.LeftPadBytes([]byte{32}, 32), common.LeftPadBytes([]byte{byte(len(opaque))}, 32)...)
+	data = append(data, common.RightPadBytes(opaque, 96)...)
+	log := &types.Log{
+		Address:   portal,
+		Topics:    []common.Hash{transactionDeposited, common.BytesToHash(sender.Bytes()), common.BytesToHash(sender.Bytes()), {}},
+		Data:      data,
+		BlockHash: common.HexToHash("0xabc"),
+		Index:     7,
+	}
+	l2Hash, err := DepositTxHash(log)
+	if err != nil {
+		t.Fatalf("DepositTxHash: %v", err)
+	}
+
+	client := &fakeClient{receipts: map[common.Hash]*types.Receipt{}}
+	a := newAdapter(t, "optimism-bridge", client)
+	transfer := engine.Transfer{
+		Route:        registry.BridgeRoute{Bridge: "optimism-bridge", Token: "ETH", From: "ethereum", To: "optimism"},
+		SourceTxHash: source,
+	}
+	check := func(want engine.TransferState, wantHash common.Hash) {
+		t.Helper()
+		status, err := a.TrackStatus(ctx, transfer)
+		if err != nil {
+			t.Fatalf("TrackStatus: %v", err)
+		}
+		if status.State != want || status.DestinationTxHash != wantHash {
+			t.Fatalf("TrackStatus = %+v, want %s with %s", status, want, wantHash)
+		}
+	}
+
+	check(engine.StatePending, common.Hash{})
+	client.receipts[source] = &types.Receipt{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{log}}
+	check(engine.StateInFlight, l2Hash)
+	client.receipts[l2Hash] = &types.Receipt{Status: types.ReceiptStatusSuccessful}
+	check(engine.StateCompleted, l2Hash)
+	client.receipts[l2Hash].Status = types.ReceiptStatusFailed
+	check(engine.StateFailed, l2Hash)
+	client.receipts[source].Status = types.ReceiptStatusFailed
+	check(engine.StateFailed, common.Hash{})
+
+	log.Topics[3] = common.HexToHash("0x01")
+	if _, err := DepositTxHash(log); err == nil {
+		t.Fatal("expected error for an unknown deposit version")
+	}
+}
+
+// TestDepositTxHash checks the derivation against a vector computed independently of this package,
+// with the deposit encoding of the OP Stack specification written out by hand: an ETH deposit
+// through the standard bridge of OP Mainnet, relayed by the aliased L1CrossDomainMessenger to the
+// L2CrossDomainMessenger with 100 bytes of calldata, logged at index 467 of its block.
+func TestDepositTxHash(t *testing.T) {
+	mint := new(big.Int).Mul(big.NewInt(15), big.NewInt(1e17))
+	calldata := make([]byte, 100)
+	for i := range calldata {
+		calldata[i] = byte(0x40 + i)
+	}
+	opaque := append(common.LeftPadBytes(mint.Bytes(), 32), common.LeftPadBytes(mint.Bytes(), 32)...)
+	opaque = append(opaque, common.LeftPadBytes(big.NewInt(287_136).Bytes(), 8)...)
+	opaque = append(opaque, 0) // not a contract creation
+	opaque = append(opaque, calldata...)
+	data := append(common.LeftPadBytes([]byte{32}, 32), common.LeftPadBytes(big.NewInt(int64(len(opaque))).Bytes(), 32)...)
+	data = append(data, common.RightPadBytes(opaque, (len(opaque)+31)/32*32)...)
+
+	log := &types.Log{
+		Address: common.HexToAddress("0xbEb5Fc579115071764c7423A4f12eDde41f106Ed"),
+		Topics: []common.Hash{
+			transactionDeposited,
+			common.BytesToHash(common.HexToAddress("0x36BDE71C97B33Cc4729cf772aE268934f7AB70B2").Bytes()),
+			common.BytesToHash(common.HexToAddress("0x4200000000000000000000000000000000000007").Bytes()),
+			{},
+		},
+		Data:      data,
+		BlockHash: common.HexToHash("0x9d2fc26b1a1f6cbd3c4a1be4e8a0e3ad6a2b7c50f2b2d2d4c8b9ae31e6c2f0a1"),
+		Index:     467,
+	}
+	if want := common.HexToHash("0xb3813568d9991fc951961fcb4c784893574240a28925604d09fc577c55bb7c32"); transactionDeposited != want {
+		t.Fatalf("TransactionDeposited topic = %s, want %s", transactionDeposited, want)
+	}
+	got, err := DepositTxHash(log)
+	if err != nil {
+		t.Fatalf("DepositTxHash: %v", err)
+	}
+	if want := common.HexToHash("0x24dd5814c7613e944f6134a3ea221872450cbb1ced785158d6b84b19f2bbdfc9"); got != want {
+		t.Fatalf("DepositTxHash = %s, want %s", got, want)
+	}
+}
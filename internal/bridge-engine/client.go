@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/xilverfang/syncora/internal/core/registry"
+)
+
+// Client is the part of an Ethereum JSON-RPC client that adapters use. *ethclient.Client
+// implements it.
+type Client interface {
+	ethereum.ContractCaller
+	ethereum.GasEstimator
+	ethereum.GasPricer
+	TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error)
+}
+
+// Dialer returns a client for a chain, named as in the registry.
+type Dialer func(ctx context.Context, chain string) (Client, error)
+
+// erc20ABI holds the ERC-20 functions adapters call.
+var erc20ABI = MustParseABI(`[
+	{"type": "function", "name": "allowance", "stateMutability": "view",
+	 "inputs": [{"name": "owner", "type": "address"}, {"name": "spender", "type": "address"}],
+	 "outputs": [{"name": "", "type": "uint256"}]},
+	{"type": "function", "name": "approve", "stateMutability": "nonpayable",
+	 "inputs": [{"name": "spender", "type": "address"}, {"name": "amount", "type": "uint256"}],
+	 "outputs": [{"name": "", "type": "bool"}]},
+	{"type": "function", "name": "balanceOf", "stateMutability": "view",
+	 "inputs": [{"name": "owner", "type": "address"}],
+	 "outputs": [{"name": "", "type": "uint256"}]}
+]`)
+
+// MustParseABI parses a contract ABI in JSON, panicking on errors. It is meant for ABIs defined in
+// package variables.
+func MustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// BalanceOf returns the balance of an ERC-20 token held by owner, in base units.
+func BalanceOf(ctx context.Context, c Client, token, owner common.Address) (*big.Int, error) {
+	return callUint(ctx, c, token, "balanceOf", owner)
+}
+
+// Approval returns a transaction on chainID that approves spender to transfer amount of an ERC-20
+// token from owner, or nil if the allowance already covers amount. The approval is for amount
+// only, never unlimited.
+func Approval(ctx context.Context, c Client, chainID uint64, token, owner, spender common.Address, amount *big.Int, description string) (*Transaction, error) {
+	allowance, err := callUint(ctx, c, token, "allowance", owner, spender)
+	if err != nil {
+		return nil, err
+	}
+	if allowance.Cmp(amount) >= 0 {
+		return nil, nil
+	}
+	data, err := erc20ABI.Pack("approve", spender, amount)
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{Description: description, ChainID: chainID, To: token, Value: new(big.Int), Data: data}, nil
+}
+
+// GasFee estimates what sending txs costs in gas on chain, at the current gas price, as a fee in
+// the chain's native currency. Transactions that cannot be estimated before the previous ones are
+// mined, such as a deposit waiting for its approval, count as fallbackGas.
+func GasFee(ctx context.Context, c Client, chain *registry.Chain, from common.Address, txs []Transaction, fallbackGas uint64) (Fee, error) {
+	price, err := c.SuggestGasPrice(ctx)
+	if err != nil {
+		return Fee{}, fmt.Errorf("failed to get gas price on %s: %v", chain.Name, err)
+	}
+	var gas uint64
+	for _, tx := range txs {
+		if tx.Gas != 0 {
+			gas += tx.Gas
+			continue
+		}
+		estimate, err := c.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &tx.To, Value: tx.Value, Data: tx.Data})
+		if err != nil {
+			estimate = fallbackGas
+		}
+		gas += estimate
+	}
+	return Fee{
+		Name:   "source gas",
+		Token:  chain.NativeCurrency.Symbol,
+		Chain:  chain.Name,
+		Amount: new(big.Int).Mul(price, new(big.Int).SetUint64(gas)),
+	}, nil
+}
+
+// callUint calls a view function of an ERC-20 token that returns a uint256.
+func callUint(ctx context.Context, c Client, token common.Address, method string, args ...any) (*big.Int, error) {
+	values, err := call(ctx, c, erc20ABI, token, method, args...)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*big.Int), nil
+}
+
+// call calls a view function of a contract and returns its unpacked results.
+func call(ctx context.Context, c Client, contract abi.ABI, to common.Address, method string, args ...any) ([]any, error) {
+	data, err := contract.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.CallContract(ctx, ethereum.CallMsg{To: &to, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s on %s: %v", method, to.Hex(), err)
+	}
+	values, err := contract.Unpack(method, out)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s result from %s: %v", method, to.Hex(), err)
+	}
+	return values, nil
+}
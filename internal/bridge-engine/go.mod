@@ -1,3 +1,34 @@
 module github.com/xilverfang/syncora/internal/bridge-engine
 
-go 1.22.1
+go 1.24.4
+
+replace github.com/xilverfang/syncora/internal/core/amount => ../core/amount
+
+replace github.com/xilverfang/syncora/internal/core/registry => ../core/registry
+
+replace github.com/xilverfang/syncora/shared => ../../shared
+
+require (
+	github.com/ethereum/go-ethereum v1.15.11
+	github.com/xilverfang/syncora/internal/core/registry v0.0.0-00010101000000-000000000000
+)
+
+require (
+	github.com/bits-and-blooms/bitset v1.20.0 // indirect
+	github.com/consensys/bavard v0.1.27 // indirect
+	github.com/consensys/gnark-crypto v0.16.0 // indirect
+	github.com/crate-crypto/go-eth-kzg v1.3.0 // indirect
+	github.com/crate-crypto/go-ipa v0.0.0-20240724233137-53bbb0ceb27a // indirect
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.0.1 // indirect
+	github.com/ethereum/c-kzg-4844/v2 v2.1.0 // indirect
+	github.com/ethereum/go-verkle v0.2.2 // indirect
+	github.com/holiman/uint256 v1.3.2 // indirect
+	github.com/mmcloughlin/addchain v0.4.0 // indirect
+	github.com/supranational/blst v0.3.14 // indirect
+	github.com/xilverfang/syncora/internal/core/amount v0.0.0-00010101000000-000000000000 // indirect
+	github.com/xilverfang/syncora/shared v0.0.0-00010101000000-000000000000 // indirect
+	golang.org/x/crypto v0.35.0 // indirect
+	golang.org/x/sync v0.11.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	rsc.io/tmplfunc v0.0.3 // indirect
+)
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xilverfang/syncora/internal/core/registry"
+)
+
+// DefaultMaxHops is the most legs a plan has unless the caller allows more.
+const DefaultMaxHops = 3
+
+// Plan is a way to move a token between two chains that may have no direct route: a sequence of
+// bridge transfers, each leg starting on the chain where the previous one arrives. Fees and times
+// are the indicative values of the routes; each leg is quoted when it is sent.
+type Plan struct {
+	Legs   []registry.BridgeRoute
+	FeeBPS uint32        // share of the amount lost to fees over all legs, in basis points
+	ETA    time.Duration // sum of the legs' times
+}
+
+// From returns the chain the plan starts on.
+func (p Plan) From() string { return p.Legs[0].From }
+
+// To returns the chain the plan arrives on.
+func (p Plan) To() string { return p.Legs[len(p.Legs)-1].To }
+
+// String describes the plan's path, e.g. "arbitrum -[hop]-> ethereum -[base-bridge]-> base".
+func (p Plan) String() string {
+	var b strings.Builder
+	b.WriteString(p.From())
+	for _, leg := range p.Legs {
+		fmt.Fprintf(&b, " -[%s]-> %s", leg.Bridge, leg.To)
+	}
+	return b.String()
+}
+
+// Plans searches the routes the adapters support for plans that carry token from one chain to
+// another in at most maxHops legs. See FindPlans for the order. It returns ErrRouteNotSupported if
+// there is none.
+func (r *Registry) Plans(ctx context.Context, token, from, to string, maxHops int) ([]Plan, error) {
+	var routes []registry.BridgeRoute
+	for _, a := range r.Adapters() {
+		supported, err := a.SupportedRoutes(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s routes: %w", a.Name(), err)
+		}
+		routes = append(routes, supported...)
+	}
+	plans := FindPlans(routes, token, from, to, maxHops)
+	if len(plans) == 0 {
+		return nil, fmt.Errorf("%w: no bridge adapters carry %s from %s to %s in at most %d legs", ErrRouteNotSupported, token, from, to, maxHops)
+	}
+	return plans, nil
+}
+
+// FindPlans returns the plans over routes that carry token from one chain to another in at most
+// maxHops legs without visiting a chain twice, best first: lowest fee, then fastest, then fewest
+// legs.
+func FindPlans(routes []registry.BridgeRoute, token, from, to string, maxHops int) []Plan {
+	// The graph has a node per chain and an edge per route of the token.
+	edges := make(map[string][]registry.BridgeRoute)
+	for _, route := range routes {
+		if route.Token == token && route.From != route.To {
+			edges[route.From] = append(edges[route.From], route)
+		}
+	}
+
+	var plans []Plan
+	var legs []registry.BridgeRoute
+	visited := map[string]bool{from: true}
+	var search func(chain string)
+	search = func(chain string) {
+		if chain == to {
+			plans = append(plans, newPlan(slices.Clone(legs)))
+			return
+		}
+		if len(legs) == maxHops {
+			return
+		}
+		for _, route := range edges[chain] {
+			if visited[route.To] {
+				continue
+			}
+			visited[route.To] = true
+			legs = append(legs, route)
+			search(route.To)
+			legs = legs[:len(legs)-1]
+			visited[route.To] = false
+		}
+	}
+	if from != to {
+		search(from)
+	}
+
+	slices.SortStableFunc(plans, func(a, b Plan) int {
+		return cmp.Or(cmp.Compare(a.FeeBPS, b.FeeBPS), cmp.Compare(a.ETA, b.ETA), cmp.Compare(len(a.Legs), len(b.Legs)))
+	})
+	return plans
+}
+
+// newPlan sums the fees and times of legs. Each leg's fee applies to what the previous legs
+// deliver, so the fees compound; the total is rounded up.
+func newPlan(legs []registry.BridgeRoute) Plan {
+	plan := Plan{Legs: legs}
+	kept := 1.0 // share of the amount that arrives
+	for _, leg := range legs {
+		kept *= 1 - float64(leg.FeeBPS)/10_000
+		plan.ETA += leg.ETA
+	}
+	plan.FeeBPS = uint32(10_000 - int(kept*10_000+1e-9))
+	return plan
+}
@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xilverfang/syncora/internal/core/registry"
+)
+
+func TestFindPlans(t *testing.T) {
+	route := func(bridge, from, to string, fee uint32, eta time.Duration) registry.BridgeRoute {
+		return registry.BridgeRoute{Bridge: bridge, Token: "DAI", From: from, To: to, FeeBPS: fee, ETA: eta}
+	}
+	routes := []registry.BridgeRoute{
+		route("arbitrum-bridge", "arbitrum", "ethereum", 0, 7*24*time.Hour),
+		route("hop", "arbitrum", "ethereum", 10, 10*time.Minute),
+		route("base-bridge", "ethereum", "base", 0, 3*time.Minute),
+		route("optimism-bridge", "ethereum", "optimism", 0, 3*time.Minute),
+		route("hop", "optimism", "base", 10, 5*time.Minute),
+		route("hop", "base", "arbitrum", 10, 5*time.Minute),
+		{Bridge: "hop", Token: "USDC", From: "arbitrum", To: "base", FeeBPS: 5, ETA: time.Minute},
+	}
+
+	plans := FindPlans(routes, "DAI", "arbitrum", "base", DefaultMaxHops)
+	want := []string{
+		"arbitrum -[arbitrum-bridge]-> ethereum -[base-bridge]-> base",
+		"arbitrum -[hop]-> ethereum -[base-bridge]-> base",
+		"arbitrum -[arbitrum-bridge]-> ethereum -[optimism-bridge]-> optimism -[hop]-> base",
+		"arbitrum -[hop]-> ethereum -[optimism-bridge]-> optimism -[hop]-> base",
+	}
+	if len(plans) != len(want) {
+		t.Fatalf("got %d plans, want %d: %v", len(plans), len(want), plans)
+	}
+	for i, p := range plans {
+		if p.String() != want[i] {
+			t.Errorf("plan %d = %s, want %s", i, p, want[i])
+		}
+		if p.From() != "arbitrum" || p.To() != "base" {
+			t.Errorf("plan %d goes from %s to %s", i, p.From(), p.To())
+		}
+	}
+	if last := plans[3]; last.FeeBPS != 20 || last.ETA != 18*time.Minute {
+		t.Errorf("last plan fee = %d bps, ETA = %s; want 20 bps, 18m", last.FeeBPS, last.ETA)
+	}
+
+	if plans := FindPlans(routes, "DAI", "arbitrum", "base", 2); len(plans) != 2 {
+		t.Errorf("got %d plans of at most 2 legs, want 2", len(plans))
+	}
+	if plans := FindPlans(routes, "DAI", "arbitrum", "base", 1); len(plans) != 0 {
+		t.Errorf("got %d plans of 1 leg, want none", len(plans))
+	}
+	if plans := FindPlans(routes, "DAI", "base", "base", DefaultMaxHops); len(plans) != 0 {
+		t.Errorf("got %d plans to the source chain, want none", len(plans))
+	}
+}
+
+func TestPlans(t *testing.T) {
+	ctx := context.Background()
+	r, err := NewRegistry(
+		&fakeAdapter{name: "arbitrum-bridge", routes: []registry.BridgeRoute{{Bridge: "arbitrum-bridge", Token: "ETH", From: "arbitrum", To: "ethereum"}}},
+		&fakeAdapter{name: "base-bridge", routes: []registry.BridgeRoute{{Bridge: "base-bridge", Token: "ETH", From: "ethereum", To: "base"}}},
+	)
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	plans, err := r.Plans(ctx, "ETH", "arbitrum", "base", DefaultMaxHops)
+	if err != nil {
+		t.Fatalf("Plans: %v", err)
+	}
+	if len(plans) != 1 || len(plans[0].Legs) != 2 {
+		t.Fatalf("Plans = %v, want one plan of 2 legs", plans)
+	}
+	if _, err := r.Plans(ctx, "ETH", "base", "arbitrum", DefaultMaxHops); !errors.Is(err, ErrRouteNotSupported) {
+		t.Fatalf("expected ErrRouteNotSupported, got %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/xilverfang/syncora/internal/core/registry"
+)
+
+// maxPriceAge is the age beyond which a price feed answer is refused. Chainlink USD feeds on
+// Ethereum update at least once a day.
+const maxPriceAge = 25 * time.Hour
+
+// ErrNoPrice is returned for tokens without a usable USD price feed.
+var ErrNoPrice = errors.New("no USD price")
+
+// aggregatorABI holds the Chainlink aggregator functions Prices calls.
+var aggregatorABI = MustParseABI(`[
+	{"type": "function", "name": "decimals", "stateMutability": "view",
+	 "inputs": [], "outputs": [{"name": "", "type": "uint8"}]},
+	{"type": "function", "name": "latestRoundData", "stateMutability": "view",
+	 "inputs": [],
+	 "outputs": [{"name": "roundId", "type": "uint80"}, {"name": "answer", "type": "int256"}, {"name": "startedAt", "type": "uint256"},
+	             {"name": "updatedAt", "type": "uint256"}, {"name": "answeredInRound", "type": "uint80"}]}
+]`)
+
+// Prices reads USD prices of registry tokens from their Chainlink price feeds. Each price is read
+// once and cached for the life of the Prices. It is safe for concurrent use.
+type Prices struct {
+	reg  *registry.Registry
+	dial Dialer
+
+	mu    sync.Mutex
+	cache map[string]*big.Rat // by token symbol
+}
+
+// NewPrices returns a Prices reading the feeds of reg's tokens through dial.
+func NewPrices(reg *registry.Registry, dial Dialer) *Prices {
+	return &Prices{reg: reg, dial: dial, cache: make(map[string]*big.Rat)}
+}
+
+// USD returns the price of one whole token in USD.
+func (p *Prices) USD(ctx context.Context, symbol string) (*big.Rat, error) {
+	token, err := p.reg.Token(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("%w for %s: %v", ErrNoPrice, symbol, err)
+	}
+	p.mu.Lock()
+	price, ok := p.cache[token.Symbol]
+	p.mu.Unlock()
+	if ok {
+		return new(big.Rat).Set(price), nil
+	}
+
+	feed := token.PriceFeed
+	if feed == nil {
+		return nil, fmt.Errorf("%w for %s: the registry lists no price feed", ErrNoPrice, token.Symbol)
+	}
+	client, err := p.dial(ctx, feed.Chain)
+	if err != nil {
+		return nil, err
+	}
+	address := common.HexToAddress(feed.Address)
+	round, err := call(ctx, client, aggregatorABI, address, "latestRoundData")
+	if err != nil {
+		return nil, err
+	}
+	decimals, err := call(ctx, client, aggregatorABI, address, "decimals")
+	if err != nil {
+		return nil, err
+	}
+	answer, updatedAt := round[1].(*big.Int), round[3].(*big.Int)
+	if answer.Sign() <= 0 {
+		return nil, fmt.Errorf("%w for %s: the feed answered %s", ErrNoPrice, token.Symbol, answer)
+	}
+	if age := time.Since(time.Unix(updatedAt.Int64(), 0)); age > maxPriceAge {
+		return nil, fmt.Errorf("%w for %s: the feed was last updated %s ago", ErrNoPrice, token.Symbol, age.Round(time.Minute))
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals[0].(uint8))), nil)
+	price = new(big.Rat).SetFrac(answer, scale)
+
+	p.mu.Lock()
+	p.cache[token.Symbol] = price
+	p.mu.Unlock()
+	return new(big.Rat).Set(price), nil
+}
+
+// AmountUSD returns the value in USD of an amount in base units of a token on a chain.
+func (p *Prices) AmountUSD(ctx context.Context, symbol, chain string, amount *big.Int) (*big.Rat, error) {
+	token, err := p.reg.Token(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("%w for %s: %v", ErrNoPrice, symbol, err)
+	}
+	d, ok := token.On(chain)
+	if !ok {
+		d.Decimals = token.Decimals
+	}
+	price, err := p.USD(ctx, token.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.Decimals)), nil)
+	return price.Mul(price, new(big.Rat).SetFrac(amount, scale)), nil
+}
+
+// FeesUSD returns the total of fees in USD. It fails with ErrNoPrice if any fee is paid in a token
+// without a price.
+func (p *Prices) FeesUSD(ctx context.Context, fees []Fee) (*big.Rat, error) {
+	total := new(big.Rat)
+	for _, fee := range fees {
+		usd, err := p.AmountUSD(ctx, fee.Token, fee.Chain, fee.Amount)
+		if err != nil {
+			return nil, err
+		}
+		total.Add(total, usd)
+	}
+	return total, nil
+}
+
+// QuoteUSD values a quote in USD: fee is the total of its fees, and net is what arrives minus the
+// fees paid on top of the amount sent, which AmountOut does not reflect.
+func (p *Prices) QuoteUSD(ctx context.Context, q *Quote) (fee, net *big.Rat, err error) {
+	if fee, err = p.FeesUSD(ctx, q.Fees); err != nil {
+		return nil, nil, err
+	}
+	var extra []Fee
+	for _, f := range q.Fees {
+		if !f.Included {
+			extra = append(extra, f)
+		}
+	}
+	extraUSD, err := p.FeesUSD(ctx, extra)
+	if err != nil {
+		return nil, nil, err
+	}
+	route := q.Request.Route
+	net, err = p.AmountUSD(ctx, route.Token, route.To, q.AmountOut)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fee, net.Sub(net, extraUSD), nil
+}
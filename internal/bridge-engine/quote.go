@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xilverfang/syncora/internal/core/registry"
+)
+
+// ErrTimeout is returned for adapters that did not answer within the timeout of QuoteAll.
+var ErrTimeout = errors.New("timed out")
+
+// QuoteResult is one bridge's answer to QuoteAll: a quote or the error that prevented it.
+type QuoteResult struct {
+	Bridge   string
+	Quote    *Quote
+	FeeUSD   *big.Rat // total of Quote.Fees in USD, nil if unknown
+	NetUSD   *big.Rat // Quote.AmountOut minus the fees not included in it, in USD; nil if unknown
+	PriceErr error    // why FeeUSD and NetUSD are unknown
+	Err      error
+}
+
+// Ranking orders quote results from best to worst.
+type Ranking string
+
+// Rankings of quote results. Quotes always come before failures.
+const (
+	RankReceived Ranking = "received" // most received net of fees paid on top, see Rank
+	RankFee      Ranking = "fee"      // lowest total fee in USD first, quotes of unknown fee last
+	RankETA      Ranking = "eta"      // fastest first
+)
+
+// Rankings lists the rankings in the order they are documented.
+var Rankings = []Ranking{RankReceived, RankFee, RankETA}
+
+// ParseRanking returns the ranking named s.
+func ParseRanking(s string) (Ranking, error) {
+	r := Ranking(strings.ToLower(s))
+	if !slices.Contains(Rankings, r) {
+		return "", fmt.Errorf("invalid ranking %q (expected received, fee or eta)", s)
+	}
+	return r, nil
+}
+
+// QuoteAll asks every adapter that supports the token and chains of req.Route for a quote, all at
+// once, giving each timeout to list its routes and answer; req.Route.Bridge is ignored. When prices
+// is not nil, the fees of each quote are converted to USD, which is best effort. Adapters that
+// fail or time out are reported as results with Err set, so that one bridge never hides the
+// others. QuoteAll fails only if no adapter supports the route.
+func (r *Registry) QuoteAll(ctx context.Context, req QuoteRequest, timeout time.Duration, prices *Prices) ([]QuoteResult, error) {
+	adapters := r.Adapters()
+	type answer struct {
+		result    QuoteResult
+		supported bool
+	}
+	answers := make(chan answer, len(adapters))
+	for _, a := range adapters {
+		go func() {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			result, supported := quote(ctx, a, req, prices)
+			if errors.Is(result.Err, context.DeadlineExceeded) {
+				result.Err = fmt.Errorf("%w after %s", ErrTimeout, timeout)
+			}
+			answers <- answer{result, supported}
+		}()
+	}
+
+	var results []QuoteResult
+	for range adapters {
+		if a := <-answers; a.supported {
+			results = append(results, a.result)
+		}
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%w: no bridge adapter carries %s from %s to %s", ErrRouteNotSupported, req.Route.Token, req.Route.From, req.Route.To)
+	}
+	slices.SortFunc(results, func(a, b QuoteResult) int { return cmp.Compare(a.Bridge, b.Bridge) })
+	return results, nil
+}
+
+// quote asks one adapter for a quote, returning when ctx is done even if the adapter does not.
+// Supported is false if the adapter listed its routes and the requested one is not among them.
+func quote(ctx context.Context, a BridgeAdapter, req QuoteRequest, prices *Prices) (result QuoteResult, supported bool) {
+	type answer struct {
+		result    QuoteResult
+		supported bool
+	}
+	done := make(chan answer, 1)
+	go func() {
+		result := QuoteResult{Bridge: a.Name()}
+		routes, err := a.SupportedRoutes(ctx)
+		if err != nil {
+			result.Err = fmt.Errorf("failed to list routes: %w", err)
+			done <- answer{result, true}
+			return
+		}
+		i := slices.IndexFunc(routes, func(s registry.BridgeRoute) bool {
+			return s.Token == req.Route.Token && s.From == req.Route.From && s.To == req.Route.To
+		})
+		if i < 0 {
+			done <- answer{result, false}
+			return
+		}
+		req.Route = routes[i]
+		if result.Quote, result.Err = a.Quote(ctx, req); result.Err == nil && prices != nil {
+			result.FeeUSD, result.NetUSD, result.PriceErr = prices.QuoteUSD(ctx, result.Quote)
+		}
+		done <- answer{result, true}
+	}()
+
+	select {
+	case a := <-done:
+		return a.result, a.supported
+	case <-ctx.Done():
+		return QuoteResult{Bridge: a.Name(), Err: ctx.Err()}, true
+	}
+}
+
+// Rank sorts results from best to worst by the given ranking: quotes first, then failures, with
+// ties broken by bridge name. RankReceived compares NetUSD when every quote has one, so that a
+// bridge charging gas or fees on top does not win on the amount alone, and AmountOut otherwise.
+func Rank(results []QuoteResult, by Ranking) {
+	net := !slices.ContainsFunc(results, func(r QuoteResult) bool { return !failed(r) && r.NetUSD == nil })
+	slices.SortStableFunc(results, func(a, b QuoteResult) int {
+		if failed(a) || failed(b) {
+			return cmp.Or(compareBool(failed(a), failed(b)), cmp.Compare(a.Bridge, b.Bridge))
+		}
+		var c int
+		switch by {
+		case RankReceived:
+			if net {
+				c = b.NetUSD.Cmp(a.NetUSD)
+			} else {
+				c = b.Quote.AmountOut.Cmp(a.Quote.AmountOut)
+			}
+		case RankFee:
+			c = compareFees(a.FeeUSD, b.FeeUSD)
+		case RankETA:
+			c = cmp.Compare(a.Quote.ETA, b.Quote.ETA)
+		}
+		return cmp.Or(c, cmp.Compare(a.Bridge, b.Bridge))
+	})
+}
+
+// failed reports whether r has no quote.
+func failed(r QuoteResult) bool {
+	return r.Err != nil || r.Quote == nil
+}
+
+// compareBool orders false before true.
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// compareFees orders fees from lowest to highest, with unknown fees last.
+func compareFees(a, b *big.Rat) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return a.Cmp(b)
+}
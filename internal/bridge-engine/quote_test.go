@@ -0,0 +1,195 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/xilverfang/syncora/internal/core/registry"
+)
+
+// quotingAdapter answers quotes for its routes after a delay, ignoring cancellation.
+type quotingAdapter struct {
+	fakeAdapter
+	delay     time.Duration
+	amountOut int64
+	fee       int64 // in wei of ETH on ethereum
+	included  bool  // the fee is taken from the amount
+	eta       time.Duration
+	err       error
+}
+
+func (q *quotingAdapter) Quote(ctx context.Context, req QuoteRequest) (*Quote, error) {
+	time.Sleep(q.delay)
+	if q.err != nil {
+		return nil, q.err
+	}
+	return &Quote{
+		Request:      req,
+		AmountOut:    big.NewInt(q.amountOut),
+		MinAmountOut: big.NewInt(q.amountOut),
+		Fees:         []Fee{{Name: "bridge", Token: "ETH", Chain: "ethereum", Amount: big.NewInt(q.fee), Included: q.included}},
+		ETA:          q.eta,
+	}, nil
+}
+
+func TestQuoteAll(t *testing.T) {
+	ctx := context.Background()
+	route := registry.BridgeRoute{Token: "ETH", From: "ethereum", To: "base"}
+	routes := func(bridge string) fakeAdapter {
+		r := route
+		r.Bridge = bridge
+		return fakeAdapter{name: bridge, routes: []registry.BridgeRoute{r}}
+	}
+	r, err := NewRegistry(
+		&quotingAdapter{fakeAdapter: routes("across"), amountOut: 990, fee: 1e16, included: true, eta: 2 * time.Minute},
+		&quotingAdapter{fakeAdapter: routes("base-bridge"), amountOut: 1000, fee: 3e16, eta: 3 * time.Minute},
+		&quotingAdapter{fakeAdapter: routes("stargate"), amountOut: 995, fee: 2e15, eta: time.Minute},
+		&quotingAdapter{fakeAdapter: routes("hop"), err: errors.New("liquidity exhausted")},
+		&quotingAdapter{fakeAdapter: routes("cctp"), delay: time.Second, amountOut: 2000},
+		&quotingAdapter{fakeAdapter: fakeAdapter{name: "polygon-pos-bridge"}},
+	)
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	reg, err := registry.Default()
+	if err != nil {
+		t.Fatalf("Default: %v", err)
+	}
+	prices := NewPrices(reg, func(ctx context.Context, chain string) (Client, error) {
+		return &feedClient{answer: 2000_00000000, updatedAt: time.Now()}, nil
+	})
+
+	start := time.Now()
+	results, err := r.QuoteAll(ctx, QuoteRequest{Route: route, Amount: big.NewInt(1000)}, 100*time.Millisecond, prices)
+	if err != nil {
+		t.Fatalf("QuoteAll: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("QuoteAll waited %s for a slow adapter", elapsed)
+	}
+	byBridge := make(map[string]QuoteResult)
+	for _, res := range results {
+		byBridge[res.Bridge] = res
+	}
+	if len(results) != 5 {
+		t.Fatalf("got %d results, want 5 without the adapter lacking the route", len(results))
+	}
+	if err := byBridge["cctp"].Err; !errors.Is(err, ErrTimeout) {
+		t.Fatalf("cctp: expected ErrTimeout, got %v", err)
+	}
+	if err := byBridge["hop"].Err; err == nil {
+		t.Fatal("hop: expected its error")
+	}
+	if res := byBridge["across"]; res.Err != nil || res.Quote.Request.Route.Bridge != "across" {
+		t.Fatalf("across: %+v", res)
+	}
+	if fee := byBridge["across"].FeeUSD; fee == nil || fee.Cmp(big.NewRat(20, 1)) != 0 {
+		t.Fatalf("across fee = %v USD, want 20", fee)
+	}
+	// The fee of across is taken from the amount, so only what arrives counts; base-bridge charges $60 on top.
+	if net := byBridge["across"].NetUSD; net == nil || net.Cmp(big.NewRat(990*2000, 1e18)) != 0 {
+		t.Fatalf("across net = %v USD, want the value of 990 wei", net)
+	}
+	if net := byBridge["base-bridge"].NetUSD; net == nil || net.Cmp(new(big.Rat).Sub(big.NewRat(1000*2000, 1e18), big.NewRat(60, 1))) != 0 {
+		t.Fatalf("base-bridge net = %v USD, want the value of 1000 wei minus 60", net)
+	}
+
+	for _, tt := range []struct {
+		by   Ranking
+		want []string
+	}{
+		{RankReceived, []string{"across", "stargate", "base-bridge", "cctp", "hop"}},
+		{RankFee, []string{"stargate", "across", "base-bridge", "cctp", "hop"}},
+		{RankETA, []string{"stargate", "across", "base-bridge", "cctp", "hop"}},
+	} {
+		Rank(results, tt.by)
+		var got []string
+		for _, res := range results {
+			got = append(got, res.Bridge)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Rank(%s) = %v, want %v", tt.by, got, tt.want)
+		}
+	}
+
+	// Without a price for every quote, received ranks by the amount alone.
+	for i := range results {
+		if results[i].Bridge == "stargate" {
+			results[i].NetUSD, results[i].PriceErr = nil, ErrNoPrice
+		}
+	}
+	Rank(results, RankReceived)
+	if results[0].Bridge != "base-bridge" || results[1].Bridge != "stargate" {
+		t.Errorf("Rank(received) without prices starts with %s, %s, want base-bridge, stargate", results[0].Bridge, results[1].Bridge)
+	}
+
+	if _, err := r.QuoteAll(ctx, QuoteRequest{Route: registry.BridgeRoute{Token: "DAI", From: "ethereum", To: "base"}, Amount: big.NewInt(1)}, time.Second, nil); !errors.Is(err, ErrRouteNotSupported) {
+		t.Fatalf("expected ErrRouteNotSupported, got %v", err)
+	}
+	if _, err := ParseRanking("cheapest"); err == nil {
+		t.Fatal("expected error for an unknown ranking")
+	}
+}
+
+func TestPrices(t *testing.T) {
+	ctx := context.Background()
+	reg, err := registry.Default()
+	if err != nil {
+		t.Fatalf("Default: %v", err)
+	}
+	client := &feedClient{answer: 100_050000, updatedAt: time.Now()} // 1.0005 with 8 decimals
+	calls := 0
+	prices := NewPrices(reg, func(ctx context.Context, chain string) (Client, error) {
+		calls++
+		return client, nil
+	})
+
+	fees := []Fee{
+		{Token: "USDC", Chain: "base", Amount: big.NewInt(2_000_000)},                                    // 2 USDC
+		{Token: "DAI", Chain: "optimism", Amount: new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)}, // 1 DAI
+	}
+	total, err := prices.FeesUSD(ctx, fees)
+	if err != nil {
+		t.Fatalf("FeesUSD: %v", err)
+	}
+	if want := big.NewRat(30015, 10000); total.Cmp(want) != 0 {
+		t.Fatalf("FeesUSD = %s, want %s", total.FloatString(4), want.FloatString(4))
+	}
+	if _, err := prices.USD(ctx, "usdc"); err != nil || calls != 2 {
+		t.Fatalf("USD(usdc) after caching: %v, %d feed reads", err, calls)
+	}
+
+	stale := NewPrices(reg, func(ctx context.Context, chain string) (Client, error) {
+		return &feedClient{answer: 1, updatedAt: time.Now().Add(-48 * time.Hour)}, nil
+	})
+	if _, err := stale.USD(ctx, "ETH"); !errors.Is(err, ErrNoPrice) {
+		t.Fatalf("expected ErrNoPrice for a stale feed, got %v", err)
+	}
+	if _, err := prices.FeesUSD(ctx, []Fee{{Token: "POL", Chain: "polygon", Amount: big.NewInt(1)}}); !errors.Is(err, ErrNoPrice) {
+		t.Fatalf("expected ErrNoPrice for an unknown token, got %v", err)
+	}
+}
+
+// feedClient answers Chainlink aggregator calls with a fixed price of 8 decimals.
+type feedClient struct {
+	Client
+	answer    int64
+	updatedAt time.Time
+}
+
+func (f *feedClient) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	method, err := aggregatorABI.MethodById(msg.Data)
+	if err != nil {
+		return nil, err
+	}
+	if method.Name == "decimals" {
+		return method.Outputs.Pack(uint8(8))
+	}
+	updated := big.NewInt(f.updatedAt.Unix())
+	return method.Outputs.Pack(big.NewInt(1), big.NewInt(f.answer), updated, updated, big.NewInt(1))
+}
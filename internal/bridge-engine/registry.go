@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/xilverfang/syncora/internal/core/registry"
+)
+
+var (
+	// ErrUnknownBridge is returned when no adapter is registered under a name.
+	ErrUnknownBridge = errors.New("unknown bridge")
+	// ErrRouteNotSupported is returned when an adapter does not support a route.
+	ErrRouteNotSupported = errors.New("route not supported")
+)
+
+// Registry holds the bridge adapters available to the CLI, by name.
+type Registry struct {
+	mu       sync.RWMutex
+	adapters map[string]BridgeAdapter
+}
+
+// NewRegistry returns a registry holding the given adapters.
+func NewRegistry(adapters ...BridgeAdapter) (*Registry, error) {
+	r := &Registry{adapters: make(map[string]BridgeAdapter)}
+	for _, a := range adapters {
+		if err := r.Register(a); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Register adds an adapter under its name, which must be unique.
+func (r *Registry) Register(a BridgeAdapter) error {
+	name := a.Name()
+	if name == "" || name != strings.ToLower(name) {
+		return fmt.Errorf("invalid bridge adapter name %q", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.adapters[name]; dup {
+		return fmt.Errorf("bridge adapter %s is already registered", name)
+	}
+	r.adapters[name] = a
+	return nil
+}
+
+// Adapter returns the adapter registered under name, ignoring case.
+func (r *Registry) Adapter(name string) (BridgeAdapter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	a, ok := r.adapters[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s (available: %s)", ErrUnknownBridge, name, strings.Join(r.names(), ", "))
+	}
+	return a, nil
+}
+
+// Names returns the names of the registered adapters, sorted.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.names()
+}
+
+func (r *Registry) names() []string {
+	names := make([]string, 0, len(r.adapters))
+	for name := range r.adapters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Adapters returns the registered adapters sorted by name.
+func (r *Registry) Adapters() []BridgeAdapter {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	adapters := make([]BridgeAdapter, 0, len(r.adapters))
+	for _, name := range r.names() {
+		adapters = append(adapters, r.adapters[name])
+	}
+	return adapters
+}
+
+// Resolve returns the adapter for route.Bridge after checking that it supports the route.
+func (r *Registry) Resolve(ctx context.Context, route registry.BridgeRoute) (BridgeAdapter, error) {
+	a, err := r.Adapter(route.Bridge)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := Supports(ctx, a, route)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s from %s to %s", ErrRouteNotSupported, a.Name(), route.Token, route.From, route.To)
+	}
+	return a, nil
+}
+
+// Supports reports whether a lists route among its supported routes. Fee and ETA are ignored.
+func Supports(ctx context.Context, a BridgeAdapter, route registry.BridgeRoute) (bool, error) {
+	routes, err := a.SupportedRoutes(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to list %s routes: %w", a.Name(), err)
+	}
+	return slices.ContainsFunc(routes, func(s registry.BridgeRoute) bool {
+		return s.Token == route.Token && s.From == route.From && s.To == route.To
+	}), nil
+}
@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xilverfang/syncora/internal/core/registry"
+)
+
+// fakeAdapter supports a fixed set of routes and implements nothing else.
+type fakeAdapter struct {
+	name   string
+	routes []registry.BridgeRoute
+}
+
+func (f *fakeAdapter) Name() string { return f.name }
+
+func (f *fakeAdapter) SupportedRoutes(ctx context.Context) ([]registry.BridgeRoute, error) {
+	return f.routes, nil
+}
+
+func (f *fakeAdapter) Quote(ctx context.Context, req QuoteRequest) (*Quote, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeAdapter) BuildTransaction(ctx context.Context, quote *Quote) ([]Transaction, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeAdapter) TrackStatus(ctx context.Context, transfer Transfer) (*Status, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeAdapter) EstimateTime(ctx context.Context, route registry.BridgeRoute) (time.Duration, error) {
+	return time.Minute, nil
+}
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	route := registry.BridgeRoute{Bridge: "hop", Token: "ETH", From: "ethereum", To: "arbitrum"}
+	hop := &fakeAdapter{name: "hop", routes: []registry.BridgeRoute{route}}
+
+	r, err := NewRegistry(hop, &fakeAdapter{name: "across"})
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	if err := r.Register(&fakeAdapter{name: "hop"}); err == nil {
+		t.Fatal("expected error registering a duplicate name")
+	}
+	if err := r.Register(&fakeAdapter{name: "Stargate"}); err == nil {
+		t.Fatal("expected error registering an upper-case name")
+	}
+	if names := r.Names(); len(names) != 2 || names[0] != "across" || names[1] != "hop" {
+		t.Fatalf("Names = %v", names)
+	}
+
+	if a, err := r.Adapter("HOP"); err != nil || a != hop {
+		t.Fatalf("Adapter(HOP) = %v, %v", a, err)
+	}
+	if _, err := r.Adapter("synapse"); !errors.Is(err, ErrUnknownBridge) {
+		t.Fatalf("expected ErrUnknownBridge, got %v", err)
+	}
+
+	if a, err := r.Resolve(ctx, route); err != nil || a != hop {
+		t.Fatalf("Resolve = %v, %v", a, err)
+	}
+	reverse := registry.BridgeRoute{Bridge: "hop", Token: "ETH", From: "arbitrum", To: "ethereum"}
+	if _, err := r.Resolve(ctx, reverse); !errors.Is(err, ErrRouteNotSupported) {
+		t.Fatalf("expected ErrRouteNotSupported, got %v", err)
+	}
+}
+
+func TestQuoteExpired(t *testing.T) {
+	now := time.Now()
+	if (&Quote{}).Expired(now) {
+		t.Error("a quote without expiry expired")
+	}
+	q := &Quote{ExpiresAt: now.Add(time.Minute)}
+	if q.Expired(now) || !q.Expired(now.Add(time.Minute)) {
+		t.Error("quote expiry not honored")
+	}
+}
@@ -0,0 +1,184 @@
+// Package amount converts between decimal strings typed by people, such as "1.5", "1.5e3" or
+// "30 gwei", and exact integer amounts in base units, such as wei. Amounts never pass through
+// floating point, so 18-decimal token amounts are not rounded.
+package amount
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MaxInput is the input that selects the whole balance.
+const MaxInput = "max"
+
+// maxExponent bounds the power of ten an amount may be scaled by, so that inputs such as "1e999999"
+// are rejected instead of allocating huge numbers. It is far above any token supply.
+const maxExponent = 96
+
+// Ether denominations accepted by ParseUnits, as powers of ten of wei.
+var units = map[string]uint8{
+	"wei":    0,
+	"kwei":   3,
+	"mwei":   6,
+	"gwei":   9,
+	"szabo":  12,
+	"finney": 15,
+	"ether":  18,
+}
+
+var (
+	// ErrInvalid is returned for input that is not a non-negative decimal number.
+	ErrInvalid = errors.New("invalid amount")
+	// ErrPrecision is returned when an amount has more decimal places than the token supports.
+	ErrPrecision = errors.New("too many decimal places")
+)
+
+var (
+	// decimalPattern matches a non-negative decimal number with an optional exponent.
+	decimalPattern = regexp.MustCompile(`^([0-9]*)(?:\.([0-9]*))?(?:[eE]([+-]?[0-9]+))?$`)
+	// unitPattern splits a number from the denomination that follows it.
+	unitPattern = regexp.MustCompile(`^\s*([0-9.]+(?:[eE][+-]?[0-9]+)?)\s*([A-Za-z]*)\s*$`)
+)
+
+// Amount is a parsed amount: either a number of base units or "max", the whole balance, which the
+// caller resolves once the balance is known.
+type Amount struct {
+	value *big.Int
+	max   bool
+}
+
+// Max is the amount that selects the whole balance.
+var Max = Amount{max: true}
+
+// Exact returns the amount of v base units.
+func Exact(v *big.Int) Amount {
+	return Amount{value: new(big.Int).Set(v)}
+}
+
+// IsMax reports whether a selects the whole balance.
+func (a Amount) IsMax() bool {
+	return a.max
+}
+
+// Int returns the amount in base units, or nil if it is Max.
+func (a Amount) Int() *big.Int {
+	if a.max || a.value == nil {
+		return nil
+	}
+	return new(big.Int).Set(a.value)
+}
+
+// Of returns the amount in base units, with Max resolved to balance.
+func (a Amount) Of(balance *big.Int) *big.Int {
+	if a.max {
+		return new(big.Int).Set(balance)
+	}
+	return a.Int()
+}
+
+// Parse converts s, such as "1.5", "0.000000000000000001", "1.5e3" or "max", to base units of a
+// token with the given decimals. An amount that does not fit the decimals exactly is an error
+// rather than being rounded.
+func Parse(s string, decimals uint8) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, MaxInput) {
+		return Max, nil
+	}
+	v, err := parseDecimal(s, int(decimals))
+	if err != nil {
+		return Amount{}, err
+	}
+	return Amount{value: v}, nil
+}
+
+// ParseUnits converts s, a number followed by an ether denomination such as "30 gwei", "0.1ether"
+// or "21000 wei", to wei. A number without a denomination is in defaultUnit.
+func ParseUnits(s, defaultUnit string) (*big.Int, error) {
+	m := unitPattern.FindStringSubmatch(s)
+	if m == nil {
+		return parseDecimal(strings.TrimSpace(s), 0) // reports why the number is invalid
+	}
+	number, unit := m[1], strings.ToLower(m[2])
+	if unit == "" {
+		unit = defaultUnit
+	}
+	exp, ok := units[unit]
+	if !ok {
+		return nil, fmt.Errorf("%w %q: unknown unit %s (expected wei, kwei, mwei, gwei, szabo, finney or ether)", ErrInvalid, s, unit)
+	}
+	return parseDecimal(number, int(exp))
+}
+
+// parseDecimal converts a decimal string to an integer scaled by 10^decimals.
+func parseDecimal(s string, decimals int) (*big.Int, error) {
+	m := decimalPattern.FindStringSubmatch(s)
+	if m == nil || m[1] == "" && m[2] == "" {
+		if strings.HasPrefix(s, "-") {
+			return nil, fmt.Errorf("%w %q: must not be negative", ErrInvalid, s)
+		}
+		return nil, fmt.Errorf("%w %q: expected a decimal number such as 1.5 or 1.5e3", ErrInvalid, s)
+	}
+	intPart, fracPart := m[1], m[2]
+
+	exp := decimals - len(fracPart)
+	if m[3] != "" {
+		e, err := strconv.Atoi(m[3])
+		if err != nil || e < -maxExponent || e > maxExponent {
+			return nil, fmt.Errorf("%w %q: exponent out of range", ErrInvalid, s)
+		}
+		exp += e
+	}
+
+	digits, _ := new(big.Int).SetString("0"+intPart+fracPart, 10)
+	if exp >= 0 {
+		if exp > maxExponent {
+			return nil, fmt.Errorf("%w %q: exponent out of range", ErrInvalid, s)
+		}
+		return digits.Mul(digits, pow10(exp)), nil
+	}
+	// More decimal places than the base unit resolves are fine only if they are zeros.
+	q, r := new(big.Int).QuoRem(digits, pow10(-exp), new(big.Int))
+	if r.Sign() != 0 {
+		return nil, fmt.Errorf("%w in %s: at most %d", ErrPrecision, s, decimals)
+	}
+	return q, nil
+}
+
+// Format writes v base units of a token with the given decimals as an exact decimal string
+// without trailing zeros, e.g. 1500000 with 6 decimals as "1.5".
+func Format(v *big.Int, decimals uint8) string {
+	if v == nil {
+		return "0"
+	}
+	digits := new(big.Int).Abs(v).String()
+	sign := ""
+	if v.Sign() < 0 {
+		sign = "-"
+	}
+	d := int(decimals)
+	if len(digits) <= d {
+		digits = strings.Repeat("0", d-len(digits)+1) + digits
+	}
+	intPart, fracPart := digits[:len(digits)-d], strings.TrimRight(digits[len(digits)-d:], "0")
+	if fracPart == "" {
+		return sign + intPart
+	}
+	return sign + intPart + "." + fracPart
+}
+
+// FormatUnits writes wei in an ether denomination, e.g. 30000000000 in gwei as "30 gwei".
+func FormatUnits(wei *big.Int, unit string) string {
+	exp, ok := units[unit]
+	if !ok {
+		return Format(wei, 0) + " wei"
+	}
+	return Format(wei, exp) + " " + unit
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,98 @@
+package amount
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in       string
+		decimals uint8
+		want     string
+	}{
+		{"1.5", 18, "1500000000000000000"},
+		{"0.000000000000000001", 18, "1"},
+		{"1.5e3", 6, "1500000000"},
+		{"2E-6", 6, "2"},
+		{".5", 6, "500000"},
+		{"7.", 0, "7"},
+		{" 1.10000000 ", 6, "1100000"},
+		{"0", 18, "0"},
+	}
+	for _, tt := range tests {
+		a, err := Parse(tt.in, tt.decimals)
+		if err != nil || a.IsMax() || a.Int().String() != tt.want {
+			t.Errorf("Parse(%q, %d) = %v, %v; want %s", tt.in, tt.decimals, a.Int(), err, tt.want)
+		}
+	}
+
+	if a, err := Parse("MAX", 18); err != nil || !a.IsMax() || a.Int() != nil || a.Of(big.NewInt(42)).Int64() != 42 {
+		t.Errorf("Parse(MAX) = %+v, %v; want Max", a, err)
+	}
+
+	for _, in := range []string{"", ".", "-1", "1,5", "0x10", "1e", "1e97", "1.5 ETH", "NaN", "Inf"} {
+		if _, err := Parse(in, 18); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q): expected ErrInvalid, got %v", in, err)
+		}
+	}
+	if _, err := Parse("0.0000001", 6); !errors.Is(err, ErrPrecision) {
+		t.Errorf("expected ErrPrecision for 7 decimal places of a 6-decimal token, got %v", err)
+	}
+}
+
+func TestParseUnits(t *testing.T) {
+	tests := []struct {
+		in, unit, want string
+	}{
+		{"30 gwei", "wei", "30000000000"},
+		{"1.5gwei", "wei", "1500000000"},
+		{"0.1 ether", "gwei", "100000000000000000"},
+		{"21000", "wei", "21000"},
+		{"2", "gwei", "2000000000"},
+		{"1e3 GWEI", "wei", "1000000000000"},
+		{"1e3", "gwei", "1000000000000"},
+	}
+	for _, tt := range tests {
+		got, err := ParseUnits(tt.in, tt.unit)
+		if err != nil || got.String() != tt.want {
+			t.Errorf("ParseUnits(%q, %s) = %v, %v; want %s", tt.in, tt.unit, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"30 gwie", "0.5 wei", "-1 gwei", "gwei"} {
+		if _, err := ParseUnits(in, "gwei"); err == nil {
+			t.Errorf("ParseUnits(%q): expected error", in)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		in       string
+		decimals uint8
+		want     string
+	}{
+		{"1500000000000000000", 18, "1.5"},
+		{"1", 18, "0.000000000000000001"},
+		{"1500000000", 6, "1500"},
+		{"0", 6, "0"},
+		{"-2500", 3, "-2.5"},
+		{"42", 0, "42"},
+	}
+	for _, tt := range tests {
+		v, _ := new(big.Int).SetString(tt.in, 10)
+		if got := Format(v, tt.decimals); got != tt.want {
+			t.Errorf("Format(%s, %d) = %s, want %s", tt.in, tt.decimals, got, tt.want)
+		}
+		// Formatting and parsing round-trip exactly.
+		if v.Sign() >= 0 {
+			if a, err := Parse(tt.want, tt.decimals); err != nil || a.Int().Cmp(v) != 0 {
+				t.Errorf("Parse(Format(%s)) = %v, %v", tt.in, a.Int(), err)
+			}
+		}
+	}
+	if got := FormatUnits(big.NewInt(30_000_000_000), "gwei"); got != "30 gwei" {
+		t.Errorf("FormatUnits = %s, want 30 gwei", got)
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/xilverfang/syncora/internal/core/amount
+
+go 1.24.4
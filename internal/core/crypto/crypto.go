@@ -1,84 +1,221 @@
 package crypto
 
 import (
+	"bytes"
 	"context"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/ecdsa"
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"os"
+	"log/slog"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"golang.org/x/crypto/argon2"
+
+	"github.com/xilverfang/syncora/internal/core/logging"
+)
+
+const (
+	argon2KeyLen = 32
+
+	// Upper bounds guard against stored parameters that would exhaust the host.
+	maxArgon2Time   = 64
+	maxArgon2Memory = 4 * 1024 * 1024 // 4 GiB
+)
+
+// logger receives the package's diagnostics; see SetLogger.
+var logger = logging.Discard()
+
+// SetLogger sets the logger for crypto operations. Nothing is logged until it is called. Addresses
+// are logged shortened and key material never. Call it before using the package.
+func SetLogger(l *slog.Logger) {
+	logger = l
+}
+
+// ErrAuthentication is returned when a sealed key fails authentication, which almost always means
+// the passphrase is wrong.
+var ErrAuthentication = errors.New("authentication failed: wrong passphrase or tampered key")
+
+// KDFParams holds the Argon2id cost parameters used to derive an account's encryption key.
+type KDFParams struct {
+	Time    uint32 // Number of passes
+	Memory  uint32 // Memory in KiB
+	Threads uint8  // Degree of parallelism
+}
+
+var (
+	// KDFInteractive is the default profile, tuned for prompts that should finish in well under a second.
+	KDFInteractive = KDFParams{Time: 3, Memory: 64 * 1024, Threads: 4}
+	// KDFSensitive trades unlock latency for resistance to offline guessing, e.g. for treasury keys.
+	KDFSensitive = KDFParams{Time: 4, Memory: 256 * 1024, Threads: 4}
+	// KDFTest is deliberately weak and only suitable for tests and fuzzing.
+	KDFTest = KDFParams{Time: 1, Memory: 8 * 1024, Threads: 1}
+	// KDFLegacy matches the parameters that were hardcoded before they were stored per account.
+	KDFLegacy = KDFParams{Time: 1, Memory: 32 * 1024, Threads: 4}
 )
 
+// DefaultKDFProfile is the profile used when none is specified.
+const DefaultKDFProfile = "interactive"
+
+// KDFProfile returns the Argon2id parameters for a named profile.
+func KDFProfile(name string) (KDFParams, error) {
+	switch strings.ToLower(name) {
+	case "interactive":
+		return KDFInteractive, nil
+	case "sensitive":
+		return KDFSensitive, nil
+	case "test":
+		return KDFTest, nil
+	default:
+		return KDFParams{}, fmt.Errorf("unknown KDF profile: %s (expected interactive, sensitive or test)", name)
+	}
+}
+
+// Validate checks that the parameters are usable and within sane bounds.
+func (p KDFParams) Validate() error {
+	if p.Time == 0 || p.Time > maxArgon2Time {
+		return fmt.Errorf("invalid argon2 time: %d", p.Time)
+	}
+	if p.Threads == 0 {
+		return fmt.Errorf("invalid argon2 threads: %d", p.Threads)
+	}
+	if p.Memory < 8*uint32(p.Threads) || p.Memory > maxArgon2Memory {
+		return fmt.Errorf("invalid argon2 memory: %d KiB", p.Memory)
+	}
+	return nil
+}
+
 const (
-	argon2Time    = 1
-	argon2Memory  = 32 * 1024 // Reduced for fuzzing
-	argon2Threads = 4
-	argon2KeyLen  = 32
+	gcmNonceSize = 12
+	saltSize     = 16
 )
 
 // KeyVersion represents the encryption key version.
 type KeyVersion uint8
 
 const (
+	// KeyVersion1 seals keys with AES-256-CFB and a separate HMAC-SHA256.
 	KeyVersion1 KeyVersion = 1
+	// KeyVersion2 seals keys with AES-256-GCM, binding the address and key version as associated data.
+	KeyVersion2 KeyVersion = 2
+
+	// CurrentKeyVersion is the version used for newly encrypted keys.
+	CurrentKeyVersion = KeyVersion2
 )
 
-// deriveKey uses Argon2 to derive an encryption key from a passphrase, salt, and version.
-func deriveKey(passphrase, salt []byte, version KeyVersion) (key, macKey []byte) {
-	saltWithVersion := append(salt, byte(version))
-	derived := argon2.IDKey(passphrase, saltWithVersion, argon2Time, argon2Memory, argon2Threads, argon2KeyLen*2)
+// NeedsUpgrade reports whether a key sealed with the given version should be re-encrypted with CurrentKeyVersion.
+func NeedsUpgrade(version KeyVersion) bool {
+	return version < CurrentKeyVersion
+}
+
+// deriveKey uses Argon2 to derive an encryption key from a passphrase, salt, version, and cost parameters.
+func deriveKey(passphrase, salt []byte, version KeyVersion, params KDFParams) (key, macKey []byte) {
+	saltWithVersion := make([]byte, 0, len(salt)+1)
+	saltWithVersion = append(saltWithVersion, salt...)
+	saltWithVersion = append(saltWithVersion, byte(version))
+	derived := argon2.IDKey(passphrase, saltWithVersion, params.Time, params.Memory, params.Threads, argon2KeyLen*2)
 	return derived[:argon2KeyLen], derived[argon2KeyLen:]
 }
 
-// EncryptPrivateKey encrypts a private key and returns the encrypted key, address, and salt.
-func EncryptPrivateKey(ctx context.Context, privateKeyHex string, passphrase []byte, version KeyVersion) (string, string, []byte, error) {
+// newSalt returns a random salt for key derivation.
+func newSalt() ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %v", err)
+	}
+	return salt, nil
+}
+
+// associatedData binds a v2 ciphertext to the account address and key version.
+func associatedData(address string, version KeyVersion) []byte {
+	return append(common.HexToAddress(address).Bytes(), byte(version))
+}
+
+// EncryptPrivateKey encrypts a hex private key with a key derived using the given KDF parameters
+// and returns the encrypted key, address, and salt. It is a wrapper around EncryptPrivateKeyBytes.
+func EncryptPrivateKey(ctx context.Context, privateKeyHex string, passphrase []byte, version KeyVersion, params KDFParams) (string, string, []byte, error) {
+	keyHex := []byte(privateKeyHex)
+	defer zero(keyHex)
+	return EncryptPrivateKeyBytes(ctx, keyHex, passphrase, version, params)
+}
+
+// EncryptPrivateKeyBytes is EncryptPrivateKey for a hex private key held in a buffer the caller
+// can clear. The decoded key is held only in buffers that are zeroed before it returns.
+func EncryptPrivateKeyBytes(ctx context.Context, privateKeyHex, passphrase []byte, version KeyVersion, params KDFParams) (string, string, []byte, error) {
 	select {
 	case <-ctx.Done():
 		return "", "", nil, ctx.Err()
 	default:
 	}
-	fmt.Fprintln(os.Stderr, "Crypto: Starting EncryptPrivateKey")
-	privateKeyHex = strings.TrimPrefix(privateKeyHex, "0x")
+	if err := params.Validate(); err != nil {
+		return "", "", nil, err
+	}
+	logger.Debug("encrypting private key")
+	privateKeyHex = bytes.TrimPrefix(privateKeyHex, []byte("0x"))
 	if len(privateKeyHex) != 64 {
 		return "", "", nil, fmt.Errorf("invalid private key length: %d", len(privateKeyHex))
 	}
 
-	_, err := hex.DecodeString(privateKeyHex)
+	plaintext := make([]byte, hex.DecodedLen(len(privateKeyHex)))
+	defer zero(plaintext)
+	if _, err := hex.Decode(plaintext, privateKeyHex); err != nil {
+		return "", "", nil, fmt.Errorf("failed to decode private key: %v", err)
+	}
+
+	privateKey, err := crypto.ToECDSA(plaintext)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to parse private key: %v", err)
+	}
+	address := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+	zeroKey(privateKey)
+
+	salt, err := newSalt()
 	if err != nil {
 		return "", "", nil, err
 	}
 
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	encryptedKey, err := sealKey(plaintext, passphrase, salt, address, version, params)
 	if err != nil {
-		return "", "", nil, fmt.Errorf("failed to parse private key: %v", err)
+		return "", "", nil, err
 	}
 
-	salt := make([]byte, 16)
-	if _, err := rand.Read(salt); err != nil {
-		return "", "", nil, fmt.Errorf("failed to generate salt: %v", err)
+	logger.Info("private key encrypted", logging.Address(address))
+	return encryptedKey, address, salt, nil
+}
+
+// sealKey encrypts raw private key bytes with the scheme for the given version.
+func sealKey(plaintext, passphrase, salt []byte, address string, version KeyVersion, params KDFParams) (string, error) {
+	switch version {
+	case KeyVersion1:
+		return sealV1(plaintext, passphrase, salt, params)
+	case KeyVersion2:
+		return sealV2(plaintext, passphrase, salt, address, params)
+	default:
+		return "", fmt.Errorf("unsupported key version: %d", version)
 	}
+}
+
+// sealV1 encrypts plaintext with AES-256-CFB and appends an HMAC-SHA256 over the IV and ciphertext.
+func sealV1(plaintext, passphrase, salt []byte, params KDFParams) (string, error) {
+	key, macKey := deriveKey(passphrase, salt, KeyVersion1, params)
+	defer zero(key)
+	defer zero(macKey)
 
-	key, macKey := deriveKey(passphrase, salt, version)
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", "", nil, fmt.Errorf("failed to create cipher: %v", err)
+		return "", fmt.Errorf("failed to create cipher: %v", err)
 	}
 
 	iv := make([]byte, aes.BlockSize)
 	if _, err := rand.Read(iv); err != nil {
-		return "", "", nil, fmt.Errorf("failed to generate IV: %v", err)
-	}
-
-	plaintext, err := hex.DecodeString(privateKeyHex)
-	if err != nil {
-		return "", "", nil, fmt.Errorf("failed to decode private key: %v", err)
+		return "", fmt.Errorf("failed to generate IV: %v", err)
 	}
 
 	stream := cipher.NewCFBEncrypter(block, iv)
@@ -90,51 +227,246 @@ func EncryptPrivateKey(ctx context.Context, privateKeyHex string, passphrase []b
 	mac.Write(data)
 	hmacSum := mac.Sum(nil)
 
-	encryptedKey := hex.EncodeToString(append(data, hmacSum...))
-	address := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+	return hex.EncodeToString(append(data, hmacSum...)), nil
+}
 
-	fmt.Fprintln(os.Stderr, "Crypto: Private key encrypted, address:", address[:10]+"...")
-	return encryptedKey, address, salt, nil
+// sealV2 encrypts plaintext with AES-256-GCM, authenticating the address and key version as associated data.
+func sealV2(plaintext, passphrase, salt []byte, address string, params KDFParams) (string, error) {
+	key, _ := deriveKey(passphrase, salt, KeyVersion2, params)
+	defer zero(key)
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcmNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, plaintext, associatedData(address, KeyVersion2))
+	return hex.EncodeToString(sealed), nil
+}
+
+// newGCM returns an AES-256-GCM AEAD for the given key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+	aead, err := cipher.NewGCMWithNonceSize(block, gcmNonceSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %v", err)
+	}
+	return aead, nil
 }
 
-// DecryptPrivateKey decrypts an encrypted private key using the provided passphrase, salt, and version.
-func DecryptPrivateKey(ctx context.Context, encryptedKey string, passphrase, salt []byte, version KeyVersion) (string, error) {
+// DecryptPrivateKey decrypts an encrypted private key using the provided passphrase, salt, version,
+// and the KDF parameters stored with the key. The address is authenticated as associated data for
+// KeyVersion2 and ignored for KeyVersion1.
+func DecryptPrivateKey(ctx context.Context, encryptedKey, address string, passphrase, salt []byte, version KeyVersion, params KDFParams) (string, error) {
 	select {
 	case <-ctx.Done():
 		return "", ctx.Err()
 	default:
 	}
-	fmt.Fprintln(os.Stderr, "Crypto: Starting DecryptPrivateKey")
+	if err := params.Validate(); err != nil {
+		return "", err
+	}
+	logger.Debug("decrypting private key", logging.Address(address))
+	plaintext, err := openKey(encryptedKey, address, passphrase, salt, version, params)
+	if err != nil {
+		return "", err
+	}
+	defer zero(plaintext)
+
+	privateKeyHex := hex.EncodeToString(plaintext)
+	logger.Info("private key decrypted", logging.Address(address))
+	return privateKeyHex, nil
+}
+
+// openKey decodes and decrypts an encrypted key with the scheme for the given version,
+// returning the raw private key bytes. Callers must zero the result.
+func openKey(encryptedKey, address string, passphrase, salt []byte, version KeyVersion, params KDFParams) ([]byte, error) {
 	data, err := hex.DecodeString(encryptedKey)
 	if err != nil {
-		return "", fmt.Errorf("failed to decode encrypted key: %v", err)
+		return nil, fmt.Errorf("failed to decode encrypted key: %v", err)
+	}
+
+	switch version {
+	case KeyVersion1:
+		return openV1(data, passphrase, salt, params)
+	case KeyVersion2:
+		return openV2(data, passphrase, salt, address, params)
+	default:
+		return nil, fmt.Errorf("unsupported key version: %d", version)
+	}
+}
+
+// ChangePassphrase decrypts an encrypted key with the old passphrase and re-encrypts it under the
+// new passphrase with a fresh salt, CurrentKeyVersion, and newParams. It returns the new encrypted
+// key and salt. The plaintext key never leaves this function and is zeroed before it returns.
+func ChangePassphrase(ctx context.Context, encryptedKey, address string, oldPassphrase, salt []byte, version KeyVersion, params KDFParams, newPassphrase []byte, newParams KDFParams) (string, []byte, error) {
+	select {
+	case <-ctx.Done():
+		return "", nil, ctx.Err()
+	default:
+	}
+	if err := params.Validate(); err != nil {
+		return "", nil, err
+	}
+	if err := newParams.Validate(); err != nil {
+		return "", nil, err
+	}
+	logger.Debug("changing passphrase", logging.Address(address))
+
+	plaintext, err := openKey(encryptedKey, address, oldPassphrase, salt, version, params)
+	if err != nil {
+		return "", nil, err
+	}
+	defer zero(plaintext)
+
+	// v1 ciphertexts are not bound to an address, so check the key really belongs to the account.
+	privateKey, err := crypto.ToECDSA(plaintext)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse private key: %v", err)
+	}
+	derived := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+	zeroKey(privateKey)
+	if !strings.EqualFold(derived, address) {
+		return "", nil, fmt.Errorf("decrypted key does not match address %s", address)
+	}
+
+	freshSalt, err := newSalt()
+	if err != nil {
+		return "", nil, err
+	}
+
+	newEncryptedKey, err := sealKey(plaintext, newPassphrase, freshSalt, derived, CurrentKeyVersion, newParams)
+	if err != nil {
+		return "", nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return "", nil, ctx.Err()
+	default:
 	}
+	logger.Info("passphrase changed", logging.Address(address))
+	return newEncryptedKey, freshSalt, nil
+}
+
+// openV1 verifies the HMAC and decrypts a KeyVersion1 ciphertext.
+func openV1(data, passphrase, salt []byte, params KDFParams) ([]byte, error) {
 	if len(data) < aes.BlockSize+sha256.Size {
-		return "", fmt.Errorf("encrypted key too short")
+		return nil, fmt.Errorf("encrypted key too short")
 	}
 
 	iv := data[:aes.BlockSize]
-	ciphertext := data[aes.BlockSize:len(data)-sha256.Size]
+	ciphertext := data[aes.BlockSize : len(data)-sha256.Size]
 	receivedHmac := data[len(data)-sha256.Size:]
 
-	key, macKey := deriveKey(passphrase, salt, version)
+	key, macKey := deriveKey(passphrase, salt, KeyVersion1, params)
+	defer zero(key)
+	defer zero(macKey)
+
 	mac := hmac.New(sha256.New, macKey)
 	mac.Write(data[:len(data)-sha256.Size])
 	expectedHmac := mac.Sum(nil)
 	if !hmac.Equal(receivedHmac, expectedHmac) {
-		return "", fmt.Errorf("HMAC verification failed")
+		return nil, fmt.Errorf("%w (HMAC verification failed)", ErrAuthentication)
 	}
 
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", fmt.Errorf("failed to create cipher: %v", err)
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
 	}
 
 	stream := cipher.NewCFBDecrypter(block, iv)
 	plaintext := make([]byte, len(ciphertext))
 	stream.XORKeyStream(plaintext, ciphertext)
+	return plaintext, nil
+}
 
-	privateKeyHex := hex.EncodeToString(plaintext)
-	fmt.Fprintln(os.Stderr, "Crypto: Private key decrypted")
-	return privateKeyHex, nil
-}
\ No newline at end of file
+// openV2 authenticates and decrypts a KeyVersion2 ciphertext bound to the given address.
+func openV2(data, passphrase, salt []byte, address string, params KDFParams) ([]byte, error) {
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("invalid address: %s", address)
+	}
+	if len(data) < gcmNonceSize+16 {
+		return nil, fmt.Errorf("encrypted key too short")
+	}
+
+	key, _ := deriveKey(passphrase, salt, KeyVersion2, params)
+	defer zero(key)
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, ciphertext := data[:gcmNonceSize], data[gcmNonceSize:]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, associatedData(address, KeyVersion2))
+	if err != nil {
+		return nil, ErrAuthentication
+	}
+	return plaintext, nil
+}
+
+// zeroKey overwrites the scalar of an ECDSA private key.
+func zeroKey(k *ecdsa.PrivateKey) {
+	if k == nil || k.D == nil {
+		return
+	}
+	words := k.D.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	k.D.SetInt64(0)
+}
+
+// zero overwrites b with zeros.
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// GenerateEncryptedKey creates a new random secp256k1 private key and encrypts it under the
+// passphrase. It returns the encrypted key, address, and salt like EncryptPrivateKey; the
+// plaintext key never leaves this function.
+func GenerateEncryptedKey(ctx context.Context, passphrase []byte, version KeyVersion, params KDFParams) (string, string, []byte, error) {
+	select {
+	case <-ctx.Done():
+		return "", "", nil, ctx.Err()
+	default:
+	}
+	if err := params.Validate(); err != nil {
+		return "", "", nil, err
+	}
+	logger.Debug("generating private key")
+
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to generate private key: %v", err)
+	}
+	defer zeroKey(privateKey)
+
+	plaintext := crypto.FromECDSA(privateKey)
+	defer zero(plaintext)
+	address := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+
+	salt, err := newSalt()
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	encryptedKey, err := sealKey(plaintext, passphrase, salt, address, version, params)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	logger.Info("private key generated", logging.Address(address))
+	return encryptedKey, address, salt, nil
+}
@@ -26,24 +26,26 @@ func FuzzEncryptDecryptPrivateKey(f *testing.F) {
 		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
 		defer cancel()
 
-		// Encrypt
-		encryptedKey, _, salt, err := EncryptPrivateKey(ctx, privateKeyHex, passphrase, KeyVersion1)
-		if err != nil {
-			if err == ctx.Err() {
-				t.Logf("Encryption timed out")
-			} else {
-				t.Logf("Encryption error: %v", err)
+		for _, version := range []KeyVersion{KeyVersion1, KeyVersion2} {
+			// Encrypt
+			encryptedKey, address, salt, err := EncryptPrivateKey(ctx, privateKeyHex, passphrase, version, KDFTest)
+			if err != nil {
+				if err == ctx.Err() {
+					t.Logf("Encryption timed out")
+				} else {
+					t.Logf("Encryption error: %v", err)
+				}
+				return
 			}
-			return
-		}
 
-		// Decrypt
-		_, err = DecryptPrivateKey(ctx, encryptedKey, passphrase, salt, KeyVersion1)
-		if err != nil {
-			if err == ctx.Err() {
-				t.Logf("Decryption timed out")
-			} else {
-				t.Errorf("Decryption error: %v", err)
+			// Decrypt
+			_, err = DecryptPrivateKey(ctx, encryptedKey, address, passphrase, salt, version, KDFTest)
+			if err != nil {
+				if err == ctx.Err() {
+					t.Logf("Decryption timed out")
+				} else {
+					t.Errorf("Decryption error (v%d): %v", version, err)
+				}
 			}
 		}
 	})
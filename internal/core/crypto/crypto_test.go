@@ -0,0 +1,171 @@
+package crypto
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testPrivateKeyHex = "fc288568c56dbf84a7af60cdb45f504ec32bac450cc042c27a420877637755ca"
+
+func TestKeyVersion2RoundTrip(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	encryptedKey, address, salt, err := EncryptPrivateKey(ctx, testPrivateKeyHex, []byte("testpass"), KeyVersion2, KDFTest)
+	if err != nil {
+		t.Fatalf("EncryptPrivateKey: %v", err)
+	}
+
+	got, err := DecryptPrivateKey(ctx, encryptedKey, address, []byte("testpass"), salt, KeyVersion2, KDFTest)
+	if err != nil {
+		t.Fatalf("DecryptPrivateKey: %v", err)
+	}
+	if got != testPrivateKeyHex {
+		t.Fatalf("decrypted key mismatch: got %s", got)
+	}
+
+	if _, err := DecryptPrivateKey(ctx, encryptedKey, address, []byte("wrongpass"), salt, KeyVersion2, KDFTest); !errors.Is(err, ErrAuthentication) {
+		t.Fatalf("expected ErrAuthentication for wrong passphrase, got %v", err)
+	}
+	if _, err := DecryptPrivateKey(ctx, encryptedKey, "0x0000000000000000000000000000000000000001", []byte("testpass"), salt, KeyVersion2, KDFTest); err == nil {
+		t.Fatal("expected mismatched address to fail authentication")
+	}
+}
+
+func TestEncryptPrivateKeyBytes(t *testing.T) {
+	ctx := context.Background()
+	encryptedKey, address, salt, err := EncryptPrivateKeyBytes(ctx, []byte("0x"+testPrivateKeyHex), []byte("testpass"), CurrentKeyVersion, KDFTest)
+	if err != nil {
+		t.Fatalf("EncryptPrivateKeyBytes: %v", err)
+	}
+	got, err := DecryptPrivateKey(ctx, encryptedKey, address, []byte("testpass"), salt, CurrentKeyVersion, KDFTest)
+	if err != nil || got != testPrivateKeyHex {
+		t.Fatalf("DecryptPrivateKey = %s, %v", got, err)
+	}
+	for _, bad := range []string{" " + testPrivateKeyHex[1:], testPrivateKeyHex[:62] + "zz", strings.Repeat("0", 64)} {
+		if _, _, _, err := EncryptPrivateKeyBytes(ctx, []byte(bad), []byte("testpass"), CurrentKeyVersion, KDFTest); err == nil {
+			t.Errorf("EncryptPrivateKeyBytes(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestKeyVersion1UpgradesToVersion2(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	encryptedKey, address, salt, err := EncryptPrivateKey(ctx, testPrivateKeyHex, []byte("testpass"), KeyVersion1, KDFTest)
+	if err != nil {
+		t.Fatalf("EncryptPrivateKey: %v", err)
+	}
+	if !NeedsUpgrade(KeyVersion1) || NeedsUpgrade(CurrentKeyVersion) {
+		t.Fatal("unexpected NeedsUpgrade result")
+	}
+
+	privateKeyHex, err := DecryptPrivateKey(ctx, encryptedKey, address, []byte("testpass"), salt, KeyVersion1, KDFTest)
+	if err != nil {
+		t.Fatalf("DecryptPrivateKey v1: %v", err)
+	}
+
+	upgradedKey, upgradedAddress, upgradedSalt, err := EncryptPrivateKey(ctx, privateKeyHex, []byte("testpass"), CurrentKeyVersion, KDFTest)
+	if err != nil {
+		t.Fatalf("EncryptPrivateKey v2: %v", err)
+	}
+	if upgradedAddress != address {
+		t.Fatalf("address changed on upgrade: %s != %s", upgradedAddress, address)
+	}
+
+	got, err := DecryptPrivateKey(ctx, upgradedKey, upgradedAddress, []byte("testpass"), upgradedSalt, CurrentKeyVersion, KDFTest)
+	if err != nil {
+		t.Fatalf("DecryptPrivateKey v2: %v", err)
+	}
+	if got != testPrivateKeyHex {
+		t.Fatalf("decrypted key mismatch after upgrade: got %s", got)
+	}
+}
+
+func TestDecryptUsesStoredKDFParams(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	params, err := KDFProfile("test")
+	if err != nil {
+		t.Fatalf("KDFProfile: %v", err)
+	}
+	if _, err := KDFProfile("bogus"); err == nil {
+		t.Fatal("expected unknown profile to fail")
+	}
+
+	encryptedKey, address, salt, err := EncryptPrivateKey(ctx, testPrivateKeyHex, []byte("testpass"), KeyVersion2, params)
+	if err != nil {
+		t.Fatalf("EncryptPrivateKey: %v", err)
+	}
+
+	other := params
+	other.Time++
+	if _, err := DecryptPrivateKey(ctx, encryptedKey, address, []byte("testpass"), salt, KeyVersion2, other); err == nil {
+		t.Fatal("expected decryption with different KDF parameters to fail")
+	}
+	if _, err := DecryptPrivateKey(ctx, encryptedKey, address, []byte("testpass"), salt, KeyVersion2, KDFParams{}); err == nil {
+		t.Fatal("expected zero KDF parameters to be rejected")
+	}
+}
+
+func TestChangePassphrase(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	encryptedKey, address, salt, err := EncryptPrivateKey(ctx, testPrivateKeyHex, []byte("oldpass1"), KeyVersion1, KDFTest)
+	if err != nil {
+		t.Fatalf("EncryptPrivateKey: %v", err)
+	}
+
+	if _, _, err := ChangePassphrase(ctx, encryptedKey, address, []byte("wrongpass"), salt, KeyVersion1, KDFTest, []byte("newpass1"), KDFTest); !errors.Is(err, ErrAuthentication) {
+		t.Fatalf("expected ErrAuthentication for wrong old passphrase, got %v", err)
+	}
+
+	newKey, newSalt, err := ChangePassphrase(ctx, encryptedKey, address, []byte("oldpass1"), salt, KeyVersion1, KDFTest, []byte("newpass1"), KDFTest)
+	if err != nil {
+		t.Fatalf("ChangePassphrase: %v", err)
+	}
+	if string(newSalt) == string(salt) {
+		t.Fatal("expected a fresh salt")
+	}
+
+	if _, err := DecryptPrivateKey(ctx, newKey, address, []byte("oldpass1"), newSalt, CurrentKeyVersion, KDFTest); err == nil {
+		t.Fatal("expected old passphrase to stop working")
+	}
+	got, err := DecryptPrivateKey(ctx, newKey, address, []byte("newpass1"), newSalt, CurrentKeyVersion, KDFTest)
+	if err != nil {
+		t.Fatalf("DecryptPrivateKey: %v", err)
+	}
+	if got != testPrivateKeyHex {
+		t.Fatalf("decrypted key mismatch: got %s", got)
+	}
+}
+
+func TestGenerateEncryptedKey(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	encryptedKey, address, salt, err := GenerateEncryptedKey(ctx, []byte("testpass"), CurrentKeyVersion, KDFTest)
+	if err != nil {
+		t.Fatalf("GenerateEncryptedKey: %v", err)
+	}
+
+	privateKeyHex, err := DecryptPrivateKey(ctx, encryptedKey, address, []byte("testpass"), salt, CurrentKeyVersion, KDFTest)
+	if err != nil {
+		t.Fatalf("DecryptPrivateKey: %v", err)
+	}
+	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	if err != nil {
+		t.Fatalf("HexToECDSA: %v", err)
+	}
+	if got := crypto.PubkeyToAddress(privateKey.PublicKey).Hex(); got != address {
+		t.Fatalf("address mismatch: %s != %s", got, address)
+	}
+}
@@ -2,13 +2,31 @@ module github.com/xilverfang/syncora/internal/core/crypto
 
 go 1.24.4
 
+replace github.com/xilverfang/syncora/internal/core/logging => ../logging
+
 require (
 	github.com/ethereum/go-ethereum v1.15.11
+	github.com/google/uuid v1.3.0
+	github.com/tyler-smith/go-bip39 v1.1.0
+	github.com/xilverfang/syncora/internal/core/logging v0.0.0-00010101000000-000000000000
 	golang.org/x/crypto v0.35.0
 )
 
 require (
+	github.com/bits-and-blooms/bitset v1.20.0 // indirect
+	github.com/consensys/bavard v0.1.27 // indirect
+	github.com/consensys/gnark-crypto v0.16.0 // indirect
+	github.com/crate-crypto/go-eth-kzg v1.3.0 // indirect
+	github.com/crate-crypto/go-ipa v0.0.0-20240724233137-53bbb0ceb27a // indirect
+	github.com/deckarep/golang-set/v2 v2.6.0 // indirect
 	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.0.1 // indirect
+	github.com/ethereum/c-kzg-4844/v2 v2.1.0 // indirect
+	github.com/ethereum/go-verkle v0.2.2 // indirect
+	github.com/fsnotify/fsnotify v1.6.0 // indirect
 	github.com/holiman/uint256 v1.3.2 // indirect
+	github.com/mmcloughlin/addchain v0.4.0 // indirect
+	github.com/supranational/blst v0.3.14 // indirect
+	golang.org/x/sync v0.11.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
+	rsc.io/tmplfunc v0.0.3 // indirect
 )
@@ -0,0 +1,258 @@
+package crypto
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// HDBasePath is the BIP-44 prefix for Ethereum accounts; the address index is appended to it.
+const HDBasePath = "m/44'/60'/0'/0"
+
+// MaxHDAccounts bounds how many accounts a single mnemonic import may derive.
+const MaxHDAccounts = 100
+
+// HDPath returns the BIP-44 derivation path for the given address index.
+func HDPath(index uint32) string {
+	return fmt.Sprintf("%s/%d", HDBasePath, index)
+}
+
+// EncryptedSeed is a BIP-39 seed sealed under a passphrase.
+type EncryptedSeed struct {
+	Fingerprint  string // Address of the BIP-32 master key; identifies the seed and is bound as associated data
+	EncryptedKey string
+	Salt         []byte
+}
+
+// DerivedAccount is an account derived from a seed, with its private key sealed on its own so it
+// can be unlocked like any imported key.
+type DerivedAccount struct {
+	Path         string
+	Address      string
+	EncryptedKey string
+	Salt         []byte
+}
+
+// hdKey is a BIP-32 extended private key.
+type hdKey struct {
+	key       []byte
+	chainCode []byte
+}
+
+func (k hdKey) zero() {
+	zero(k.key)
+	zero(k.chainCode)
+}
+
+// newMasterKey derives the BIP-32 master key from a seed.
+func newMasterKey(seed []byte) (hdKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	k := hdKey{key: sum[:32], chainCode: sum[32:]}
+	if _, err := crypto.ToECDSA(k.key); err != nil {
+		k.zero()
+		return hdKey{}, fmt.Errorf("invalid master key: %v", err)
+	}
+	return k, nil
+}
+
+// child derives the BIP-32 child private key at index; indexes >= 2^31 are hardened.
+func (k hdKey) child(index uint32) (hdKey, error) {
+	data := make([]byte, 0, 37)
+	if index >= 0x80000000 {
+		data = append(data, 0)
+		data = append(data, k.key...)
+	} else {
+		parent, err := crypto.ToECDSA(k.key)
+		if err != nil {
+			return hdKey{}, fmt.Errorf("invalid parent key: %v", err)
+		}
+		data = append(data, crypto.CompressPubkey(&parent.PublicKey)...)
+		zeroKey(parent)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+	defer zero(data)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	defer zero(sum[:32])
+
+	n := crypto.S256().Params().N
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(n) >= 0 {
+		return hdKey{}, fmt.Errorf("invalid child key at index %d", index)
+	}
+	childInt := il.Add(il, new(big.Int).SetBytes(k.key))
+	childInt.Mod(childInt, n)
+	if childInt.Sign() == 0 {
+		return hdKey{}, fmt.Errorf("invalid child key at index %d", index)
+	}
+
+	childKey := make([]byte, 32)
+	childInt.FillBytes(childKey)
+	words := childInt.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	return hdKey{key: childKey, chainCode: append([]byte(nil), sum[32:]...)}, nil
+}
+
+// derivePath walks a derivation path from the master key.
+func (k hdKey) derivePath(path accounts.DerivationPath) (hdKey, error) {
+	current := hdKey{key: append([]byte(nil), k.key...), chainCode: append([]byte(nil), k.chainCode...)}
+	for _, index := range path {
+		next, err := current.child(index)
+		current.zero()
+		if err != nil {
+			return hdKey{}, err
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// privateKey returns the ECDSA key for an extended key. Callers must zero it.
+func (k hdKey) privateKey() (*ecdsa.PrivateKey, error) {
+	return crypto.ToECDSA(k.key)
+}
+
+// NewMnemonic generates a random BIP-39 mnemonic with 12 or 24 words.
+func NewMnemonic(words int) ([]byte, error) {
+	var bits int
+	switch words {
+	case 12:
+		bits = 128
+	case 24:
+		bits = 256
+	default:
+		return nil, fmt.Errorf("unsupported mnemonic length: %d words (expected 12 or 24)", words)
+	}
+
+	entropy, err := bip39.NewEntropy(bits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate entropy: %v", err)
+	}
+	defer zero(entropy)
+
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate mnemonic: %v", err)
+	}
+	return []byte(mnemonic), nil
+}
+
+// NormalizeMnemonic lowercases a mnemonic and collapses its whitespace to single spaces.
+func NormalizeMnemonic(mnemonic string) string {
+	return strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+}
+
+// ImportMnemonic validates a BIP-39 mnemonic and derives count accounts along m/44'/60'/0'/0/i
+// starting at index start. The seed, which already incorporates the optional BIP-39 passphrase,
+// is sealed once under passphrase, and each derived private key is sealed separately.
+func ImportMnemonic(ctx context.Context, mnemonic, bip39Passphrase, passphrase []byte, start, count uint32, version KeyVersion, params KDFParams) (*EncryptedSeed, []DerivedAccount, error) {
+	select {
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	default:
+	}
+	if err := params.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if count == 0 || count > MaxHDAccounts {
+		return nil, nil, fmt.Errorf("invalid account count: %d (expected 1-%d)", count, MaxHDAccounts)
+	}
+	if uint64(start)+uint64(count) > 0x80000000 {
+		return nil, nil, fmt.Errorf("address index out of range: %d", start)
+	}
+	logger.Debug("importing mnemonic", "start", start, "count", count)
+
+	seed, err := bip39.NewSeedWithErrorChecking(NormalizeMnemonic(string(mnemonic)), string(bip39Passphrase))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid mnemonic: %v", err)
+	}
+	defer zero(seed)
+
+	master, err := newMasterKey(seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer master.zero()
+
+	masterKey, err := master.privateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	fingerprint := crypto.PubkeyToAddress(masterKey.PublicKey).Hex()
+	zeroKey(masterKey)
+
+	seedSalt, err := newSalt()
+	if err != nil {
+		return nil, nil, err
+	}
+	encryptedSeed, err := sealKey(seed, passphrase, seedSalt, fingerprint, version, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	derived := make([]DerivedAccount, 0, count)
+	for i := start; i < start+count; i++ {
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		default:
+		}
+
+		account, err := deriveAccount(master, i, passphrase, version, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		derived = append(derived, account)
+	}
+
+	logger.Info("mnemonic imported", "accounts", len(derived))
+	return &EncryptedSeed{Fingerprint: fingerprint, EncryptedKey: encryptedSeed, Salt: seedSalt}, derived, nil
+}
+
+// deriveAccount derives the account at the given address index and seals its private key.
+func deriveAccount(master hdKey, index uint32, passphrase []byte, version KeyVersion, params KDFParams) (DerivedAccount, error) {
+	path, err := accounts.ParseDerivationPath(HDPath(index))
+	if err != nil {
+		return DerivedAccount{}, fmt.Errorf("invalid derivation path: %v", err)
+	}
+
+	child, err := master.derivePath(path)
+	if err != nil {
+		return DerivedAccount{}, err
+	}
+	defer child.zero()
+
+	privateKey, err := child.privateKey()
+	if err != nil {
+		return DerivedAccount{}, fmt.Errorf("invalid derived key: %v", err)
+	}
+	address := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+	zeroKey(privateKey)
+
+	salt, err := newSalt()
+	if err != nil {
+		return DerivedAccount{}, err
+	}
+	encryptedKey, err := sealKey(child.key, passphrase, salt, address, version, params)
+	if err != nil {
+		return DerivedAccount{}, err
+	}
+
+	return DerivedAccount{Path: path.String(), Address: address, EncryptedKey: encryptedKey, Salt: salt}, nil
+}
@@ -0,0 +1,62 @@
+package crypto
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+func TestImportMnemonicDerivesBIP44Accounts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	mnemonic := []byte("test test test test test test test test test test test junk")
+	seed, derived, err := ImportMnemonic(ctx, mnemonic, nil, []byte("testpass"), 0, 2, CurrentKeyVersion, KDFTest)
+	if err != nil {
+		t.Fatalf("ImportMnemonic: %v", err)
+	}
+	if seed.Fingerprint == "" || seed.EncryptedKey == "" {
+		t.Fatal("expected an encrypted seed")
+	}
+
+	want := []struct{ path, address string }{
+		{"m/44'/60'/0'/0/0", "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"},
+		{"m/44'/60'/0'/0/1", "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"},
+	}
+	if len(derived) != len(want) {
+		t.Fatalf("derived %d accounts, want %d", len(derived), len(want))
+	}
+	for i, w := range want {
+		if derived[i].Path != w.path || derived[i].Address != w.address {
+			t.Errorf("account %d: got %s %s, want %s %s", i, derived[i].Path, derived[i].Address, w.path, w.address)
+		}
+		if _, err := DecryptPrivateKey(ctx, derived[i].EncryptedKey, derived[i].Address, []byte("testpass"), derived[i].Salt, CurrentKeyVersion, KDFTest); err != nil {
+			t.Errorf("account %d: DecryptPrivateKey: %v", i, err)
+		}
+	}
+
+	if _, _, err := ImportMnemonic(ctx, []byte("test test test"), nil, []byte("testpass"), 0, 1, CurrentKeyVersion, KDFTest); err == nil {
+		t.Fatal("expected invalid mnemonic to fail")
+	}
+}
+
+func TestNewMnemonic(t *testing.T) {
+	for _, words := range []int{12, 24} {
+		mnemonic, err := NewMnemonic(words)
+		if err != nil {
+			t.Fatalf("NewMnemonic(%d): %v", words, err)
+		}
+		if got := len(strings.Fields(string(mnemonic))); got != words {
+			t.Fatalf("NewMnemonic(%d) returned %d words", words, got)
+		}
+		if !bip39.IsMnemonicValid(string(mnemonic)) {
+			t.Fatalf("NewMnemonic(%d) returned an invalid mnemonic", words)
+		}
+	}
+	if _, err := NewMnemonic(15); err == nil {
+		t.Fatal("expected unsupported length to fail")
+	}
+}
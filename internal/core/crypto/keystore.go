@@ -0,0 +1,117 @@
+package crypto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+
+	"github.com/xilverfang/syncora/internal/core/logging"
+)
+
+// keystoreAddress is the subset of a V3 keystore file used to cross-check the decrypted key.
+type keystoreAddress struct {
+	Address string `json:"address"`
+}
+
+// ImportKeystore decrypts an Ethereum Web3 Secret Storage (V3) keystore with its own password,
+// which may use either the scrypt or pbkdf2 KDF, and re-encrypts the key under passphrase.
+// It returns the encrypted key, address, and salt like EncryptPrivateKey.
+func ImportKeystore(ctx context.Context, keyJSON, keystorePassphrase, passphrase []byte, version KeyVersion, params KDFParams) (string, string, []byte, error) {
+	select {
+	case <-ctx.Done():
+		return "", "", nil, ctx.Err()
+	default:
+	}
+	if err := params.Validate(); err != nil {
+		return "", "", nil, err
+	}
+	logger.Debug("importing keystore")
+
+	var header keystoreAddress
+	if err := json.Unmarshal(keyJSON, &header); err != nil {
+		return "", "", nil, fmt.Errorf("invalid keystore file: %v", err)
+	}
+
+	key, err := keystore.DecryptKey(keyJSON, string(keystorePassphrase))
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to decrypt keystore: %v", err)
+	}
+	defer zeroKey(key.PrivateKey)
+
+	address := key.Address.Hex()
+	if header.Address != "" && !strings.EqualFold(common.HexToAddress(header.Address).Hex(), address) {
+		return "", "", nil, fmt.Errorf("keystore address %s does not match decrypted key %s", header.Address, address)
+	}
+
+	plaintext := crypto.FromECDSA(key.PrivateKey)
+	defer zero(plaintext)
+
+	salt, err := newSalt()
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	encryptedKey, err := sealKey(plaintext, passphrase, salt, address, version, params)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	logger.Info("keystore imported", logging.Address(address))
+	return encryptedKey, address, salt, nil
+}
+
+// ExportKeystore decrypts a stored key and writes it as a V3 keystore JSON document protected by
+// keystorePassphrase using scrypt. Light selects geth's light scrypt parameters instead of the
+// standard ones, trading brute-force resistance for speed.
+func ExportKeystore(ctx context.Context, encryptedKey, address string, passphrase, salt []byte, version KeyVersion, params KDFParams, keystorePassphrase []byte, light bool) ([]byte, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	logger.Debug("exporting keystore", logging.Address(address))
+
+	plaintext, err := openKey(encryptedKey, address, passphrase, salt, version, params)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(plaintext)
+
+	privateKey, err := crypto.ToECDSA(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %v", err)
+	}
+	defer zeroKey(privateKey)
+
+	keyAddress := crypto.PubkeyToAddress(privateKey.PublicKey)
+	if !strings.EqualFold(keyAddress.Hex(), address) {
+		return nil, fmt.Errorf("decrypted key does not match address %s", address)
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate keystore id: %v", err)
+	}
+
+	scryptN, scryptP := keystore.StandardScryptN, keystore.StandardScryptP
+	if light {
+		scryptN, scryptP = keystore.LightScryptN, keystore.LightScryptP
+	}
+
+	keyJSON, err := keystore.EncryptKey(&keystore.Key{Id: id, Address: keyAddress, PrivateKey: privateKey}, string(keystorePassphrase), scryptN, scryptP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt keystore: %v", err)
+	}
+
+	logger.Info("keystore exported", logging.Address(address))
+	return keyJSON, nil
+}
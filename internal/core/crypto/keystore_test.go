@@ -0,0 +1,81 @@
+package crypto
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// pbkdf2KeystoreVector is the pbkdf2 test vector from the Web3 Secret Storage definition.
+const pbkdf2KeystoreVector = `{
+	"crypto": {
+		"cipher": "aes-128-ctr",
+		"cipherparams": {"iv": "6087dab2f9fdbbfaddc31a909735c1e6"},
+		"ciphertext": "5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46",
+		"kdf": "pbkdf2",
+		"kdfparams": {
+			"c": 262144,
+			"dklen": 32,
+			"prf": "hmac-sha256",
+			"salt": "ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd"
+		},
+		"mac": "517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2"
+	},
+	"id": "3198bc9c-6672-5ab3-d995-4942343ae5b6",
+	"version": 3
+}`
+
+const pbkdf2KeystorePrivateKey = "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"
+
+func TestImportKeystorePBKDF2(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	encryptedKey, address, salt, err := ImportKeystore(ctx, []byte(pbkdf2KeystoreVector), []byte("testpassword"), []byte("testpass"), CurrentKeyVersion, KDFTest)
+	if err != nil {
+		t.Fatalf("ImportKeystore: %v", err)
+	}
+
+	got, err := DecryptPrivateKey(ctx, encryptedKey, address, []byte("testpass"), salt, CurrentKeyVersion, KDFTest)
+	if err != nil {
+		t.Fatalf("DecryptPrivateKey: %v", err)
+	}
+	if got != pbkdf2KeystorePrivateKey {
+		t.Fatalf("imported key mismatch: got %s", got)
+	}
+
+	if _, _, _, err := ImportKeystore(ctx, []byte(pbkdf2KeystoreVector), []byte("wrong"), []byte("testpass"), CurrentKeyVersion, KDFTest); err == nil {
+		t.Fatal("expected wrong keystore password to fail")
+	}
+}
+
+func TestExportKeystoreRoundTrip(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	encryptedKey, address, salt, err := EncryptPrivateKey(ctx, testPrivateKeyHex, []byte("testpass"), CurrentKeyVersion, KDFTest)
+	if err != nil {
+		t.Fatalf("EncryptPrivateKey: %v", err)
+	}
+
+	keyJSON, err := ExportKeystore(ctx, encryptedKey, address, []byte("testpass"), salt, CurrentKeyVersion, KDFTest, []byte("keystorepass"), true)
+	if err != nil {
+		t.Fatalf("ExportKeystore: %v", err)
+	}
+
+	reEncrypted, reAddress, reSalt, err := ImportKeystore(ctx, keyJSON, []byte("keystorepass"), []byte("testpass"), CurrentKeyVersion, KDFTest)
+	if err != nil {
+		t.Fatalf("ImportKeystore: %v", err)
+	}
+	if reAddress != address {
+		t.Fatalf("address mismatch: %s != %s", reAddress, address)
+	}
+
+	got, err := DecryptPrivateKey(ctx, reEncrypted, reAddress, []byte("testpass"), reSalt, CurrentKeyVersion, KDFTest)
+	if err != nil {
+		t.Fatalf("DecryptPrivateKey: %v", err)
+	}
+	if got != testPrivateKeyHex {
+		t.Fatalf("round-tripped key mismatch: got %s", got)
+	}
+}
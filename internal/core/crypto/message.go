@@ -0,0 +1,57 @@
+package crypto
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// signatureLength is the length of an [R || S || V] Ethereum signature.
+const signatureLength = 65
+
+// PersonalMessageHash returns the EIP-191 (version 0x45, "personal_sign") hash of msg.
+func PersonalMessageHash(msg []byte) []byte {
+	return accounts.TextHash(msg)
+}
+
+// TypedDataHash parses an EIP-712 typed data JSON document and returns its signing hash.
+func TypedDataHash(typedDataJSON []byte) ([]byte, error) {
+	var typedData apitypes.TypedData
+	if err := json.Unmarshal(typedDataJSON, &typedData); err != nil {
+		return nil, fmt.Errorf("invalid typed data: %v", err)
+	}
+	if typedData.PrimaryType == "" {
+		return nil, fmt.Errorf("invalid typed data: missing primaryType")
+	}
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash typed data: %v", err)
+	}
+	return hash, nil
+}
+
+// RecoverAddress returns the address that produced sig over hash. The signature must be 65 bytes
+// [R || S || V] with V either 27/28, as wallets return it, or 0/1.
+func RecoverAddress(hash, sig []byte) (common.Address, error) {
+	if len(sig) != signatureLength {
+		return common.Address{}, fmt.Errorf("invalid signature length: %d", len(sig))
+	}
+	normalized := make([]byte, signatureLength)
+	copy(normalized, sig)
+	if normalized[64] >= 27 {
+		normalized[64] -= 27
+	}
+	if normalized[64] > 1 {
+		return common.Address{}, fmt.Errorf("invalid signature recovery id: %d", sig[64])
+	}
+
+	pub, err := crypto.SigToPub(hash, normalized)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to recover signer: %v", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
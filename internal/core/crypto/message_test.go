@@ -0,0 +1,99 @@
+package crypto
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+// mailTypedData is the example from the EIP-712 specification.
+const mailTypedData = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func TestTypedDataHashMatchesEIP712Example(t *testing.T) {
+	hash, err := TypedDataHash([]byte(mailTypedData))
+	if err != nil {
+		t.Fatalf("TypedDataHash: %v", err)
+	}
+	if got, want := hex.EncodeToString(hash), "be609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"; got != want {
+		t.Fatalf("hash = %s, want %s", got, want)
+	}
+}
+
+func TestSignAndRecoverMessages(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	encryptedKey, address, salt, err := EncryptPrivateKey(ctx, testPrivateKeyHex, []byte("testpass"), CurrentKeyVersion, KDFTest)
+	if err != nil {
+		t.Fatalf("EncryptPrivateKey: %v", err)
+	}
+	signer, err := Unlock(ctx, encryptedKey, address, []byte("testpass"), salt, CurrentKeyVersion, KDFTest)
+	if err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	defer signer.Lock()
+
+	msg := []byte("bridge 1 ETH to arbitrum")
+	sig, err := signer.SignPersonalMessage(ctx, msg)
+	if err != nil {
+		t.Fatalf("SignPersonalMessage: %v", err)
+	}
+	if sig[64] != 27 && sig[64] != 28 {
+		t.Fatalf("unexpected V: %d", sig[64])
+	}
+	recovered, err := RecoverAddress(PersonalMessageHash(msg), sig)
+	if err != nil {
+		t.Fatalf("RecoverAddress: %v", err)
+	}
+	if recovered != signer.Address() {
+		t.Fatalf("recovered %s, want %s", recovered.Hex(), signer.Address().Hex())
+	}
+
+	sig, err = signer.SignTypedData(ctx, []byte(mailTypedData))
+	if err != nil {
+		t.Fatalf("SignTypedData: %v", err)
+	}
+	hash, _ := TypedDataHash([]byte(mailTypedData))
+	recovered, err = RecoverAddress(hash, sig)
+	if err != nil {
+		t.Fatalf("RecoverAddress: %v", err)
+	}
+	if recovered != signer.Address() {
+		t.Fatalf("recovered %s, want %s", recovered.Hex(), signer.Address().Hex())
+	}
+
+	if recovered, err = RecoverAddress(PersonalMessageHash([]byte("tampered")), sig); err == nil && recovered == signer.Address() {
+		t.Fatal("expected signature over a different message not to recover the signer")
+	}
+}
@@ -0,0 +1,161 @@
+package crypto
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/xilverfang/syncora/internal/core/logging"
+)
+
+// Signer signs transactions for a single unlocked account without exposing its private key.
+type Signer interface {
+	// Address returns the account the signer signs for.
+	Address() common.Address
+	// SignTx signs a legacy, access-list, or dynamic-fee transaction for chainID and returns the
+	// signed transaction and its raw encoding, ready for eth_sendRawTransaction.
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, []byte, error)
+	// SignPersonalMessage signs msg as an EIP-191 personal message and returns a 65-byte signature with V in {27, 28}.
+	SignPersonalMessage(ctx context.Context, msg []byte) ([]byte, error)
+	// SignTypedData signs an EIP-712 typed data JSON document and returns a 65-byte signature with V in {27, 28}.
+	SignTypedData(ctx context.Context, typedDataJSON []byte) ([]byte, error)
+	// Lock zeroes the key; the signer cannot be used afterwards.
+	Lock()
+}
+
+// LocalSigner is a Signer backed by a decrypted key held in process memory.
+type LocalSigner struct {
+	mu      sync.Mutex
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+var _ Signer = (*LocalSigner)(nil)
+
+// Unlock decrypts a stored key and returns a signer for it. The decrypted key must match address.
+func Unlock(ctx context.Context, encryptedKey, address string, passphrase, salt []byte, version KeyVersion, params KDFParams) (*LocalSigner, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	logger.Debug("unlocking account", logging.Address(address))
+
+	plaintext, err := openKey(encryptedKey, address, passphrase, salt, version, params)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(plaintext)
+
+	key, err := crypto.ToECDSA(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %v", err)
+	}
+
+	keyAddress := crypto.PubkeyToAddress(key.PublicKey)
+	if !strings.EqualFold(keyAddress.Hex(), address) {
+		zeroKey(key)
+		return nil, fmt.Errorf("decrypted key does not match address %s", address)
+	}
+
+	logger.Info("account unlocked", logging.Address(address))
+	return &LocalSigner{key: key, address: keyAddress}, nil
+}
+
+// Address returns the account the signer signs for.
+func (s *LocalSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx signs tx with EIP-155 replay protection for chainID. Typed transactions must either
+// carry the same chain ID or none at all.
+func (s *LocalSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, []byte, error) {
+	select {
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	default:
+	}
+	if chainID == nil || chainID.Sign() <= 0 {
+		return nil, nil, fmt.Errorf("a positive chain ID is required for replay protection")
+	}
+
+	switch tx.Type() {
+	case types.LegacyTxType, types.AccessListTxType, types.DynamicFeeTxType:
+	default:
+		return nil, nil, fmt.Errorf("unsupported transaction type: %d", tx.Type())
+	}
+	if tx.Type() != types.LegacyTxType && tx.ChainId().Sign() != 0 && tx.ChainId().Cmp(chainID) != 0 {
+		return nil, nil, fmt.Errorf("transaction chain ID %s does not match %s", tx.ChainId(), chainID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key == nil {
+		return nil, nil, fmt.Errorf("signer is locked")
+	}
+
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode transaction: %v", err)
+	}
+	return signed, raw, nil
+}
+
+// SignPersonalMessage signs msg as an EIP-191 personal message.
+func (s *LocalSigner) SignPersonalMessage(ctx context.Context, msg []byte) ([]byte, error) {
+	return s.signHash(ctx, PersonalMessageHash(msg))
+}
+
+// SignTypedData signs an EIP-712 typed data JSON document.
+func (s *LocalSigner) SignTypedData(ctx context.Context, typedDataJSON []byte) ([]byte, error) {
+	hash, err := TypedDataHash(typedDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	return s.signHash(ctx, hash)
+}
+
+// signHash signs a prefixed hash. It is unexported so callers cannot sign raw transaction hashes
+// while bypassing the domain separation of EIP-191 and EIP-712.
+func (s *LocalSigner) signHash(ctx context.Context, hash []byte) ([]byte, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key == nil {
+		return nil, fmt.Errorf("signer is locked")
+	}
+
+	sig, err := crypto.Sign(hash, s.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %v", err)
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// Lock zeroes the key. It is safe to call more than once.
+func (s *LocalSigner) Lock() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	zeroKey(s.key)
+	s.key = nil
+}
@@ -0,0 +1,68 @@
+package crypto
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestSignerSignsSupportedTransactionTypes(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	encryptedKey, address, salt, err := EncryptPrivateKey(ctx, testPrivateKeyHex, []byte("testpass"), CurrentKeyVersion, KDFTest)
+	if err != nil {
+		t.Fatalf("EncryptPrivateKey: %v", err)
+	}
+	signer, err := Unlock(ctx, encryptedKey, address, []byte("testpass"), salt, CurrentKeyVersion, KDFTest)
+	if err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	defer signer.Lock()
+
+	chainID := big.NewInt(11155111)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	txs := map[string]types.TxData{
+		"legacy":      &types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(1e9), Gas: 21000, To: &to, Value: big.NewInt(1)},
+		"access-list": &types.AccessListTx{ChainID: chainID, Nonce: 2, GasPrice: big.NewInt(1e9), Gas: 21000, To: &to, Value: big.NewInt(1)},
+		"dynamic-fee": &types.DynamicFeeTx{ChainID: chainID, Nonce: 3, GasTipCap: big.NewInt(1e9), GasFeeCap: big.NewInt(2e9), Gas: 21000, To: &to, Value: big.NewInt(1)},
+	}
+	for name, data := range txs {
+		signed, raw, err := signer.SignTx(ctx, types.NewTx(data), chainID)
+		if err != nil {
+			t.Fatalf("%s: SignTx: %v", name, err)
+		}
+		if signed.ChainId().Cmp(chainID) != 0 {
+			t.Errorf("%s: chain ID %s, want %s", name, signed.ChainId(), chainID)
+		}
+		sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+		if err != nil {
+			t.Fatalf("%s: Sender: %v", name, err)
+		}
+		if sender != signer.Address() {
+			t.Errorf("%s: sender %s, want %s", name, sender.Hex(), signer.Address().Hex())
+		}
+
+		var decoded types.Transaction
+		if err := decoded.UnmarshalBinary(raw); err != nil {
+			t.Fatalf("%s: UnmarshalBinary: %v", name, err)
+		}
+		if decoded.Hash() != signed.Hash() {
+			t.Errorf("%s: raw encoding does not round-trip", name)
+		}
+	}
+
+	wrongChain := types.NewTx(&types.DynamicFeeTx{ChainID: big.NewInt(1), Gas: 21000, To: &to})
+	if _, _, err := signer.SignTx(ctx, wrongChain, chainID); err == nil {
+		t.Error("expected mismatched chain ID to fail")
+	}
+
+	signer.Lock()
+	if _, _, err := signer.SignTx(ctx, types.NewTx(txs["legacy"]), chainID); err == nil {
+		t.Error("expected locked signer to fail")
+	}
+}
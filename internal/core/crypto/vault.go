@@ -0,0 +1,100 @@
+package crypto
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// vaultKeySize is the size of a vault key, an AES-256 key.
+const vaultKeySize = 32
+
+// vaultAddress takes the place of the account address in the associated data of a sealed vault
+// key. No stored account has the zero address.
+var vaultAddress = common.Address{}.Hex()
+
+// vaultAssociatedData binds a sealed vault body to its purpose.
+var vaultAssociatedData = []byte("syncora vault")
+
+// NewVaultKey returns a random key for encrypting a vault with SealVault, and that key sealed
+// under passphrase like a private key, with the salt used. Callers must zero the key.
+func NewVaultKey(ctx context.Context, passphrase []byte, params KDFParams) ([]byte, string, []byte, error) {
+	select {
+	case <-ctx.Done():
+		return nil, "", nil, ctx.Err()
+	default:
+	}
+	if err := params.Validate(); err != nil {
+		return nil, "", nil, err
+	}
+	key := make([]byte, vaultKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, "", nil, fmt.Errorf("failed to generate vault key: %v", err)
+	}
+	salt, err := newSalt()
+	if err != nil {
+		zero(key)
+		return nil, "", nil, err
+	}
+	sealedKey, err := sealV2(key, passphrase, salt, vaultAddress, params)
+	if err != nil {
+		zero(key)
+		return nil, "", nil, err
+	}
+	logger.Debug("vault key generated")
+	return key, sealedKey, salt, nil
+}
+
+// OpenVaultKey decrypts a vault key sealed by NewVaultKey. It returns ErrAuthentication if the
+// passphrase is wrong. Callers must zero the key.
+func OpenVaultKey(ctx context.Context, sealedKey string, passphrase, salt []byte, params KDFParams) ([]byte, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	key, err := openKey(sealedKey, vaultAddress, passphrase, salt, KeyVersion2, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != vaultKeySize {
+		zero(key)
+		return nil, fmt.Errorf("invalid vault key length: %d", len(key))
+	}
+	return key, nil
+}
+
+// SealVault encrypts a vault body with AES-256-GCM under a key from NewVaultKey.
+func SealVault(key, plaintext []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcmNonceSize, gcmNonceSize+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, vaultAssociatedData), nil
+}
+
+// OpenVault decrypts a vault body sealed by SealVault. It returns ErrAuthentication if the body
+// was not sealed under key or was modified.
+func OpenVault(key, sealed []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcmNonceSize+aead.Overhead() {
+		return nil, ErrAuthentication
+	}
+	plaintext, err := aead.Open(nil, sealed[:gcmNonceSize], sealed[gcmNonceSize:], vaultAssociatedData)
+	if err != nil {
+		return nil, ErrAuthentication
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,52 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestVaultRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	key, sealedKey, salt, err := NewVaultKey(ctx, []byte("testpass"), KDFTest)
+	if err != nil {
+		t.Fatalf("NewVaultKey: %v", err)
+	}
+	opened, err := OpenVaultKey(ctx, sealedKey, []byte("testpass"), salt, KDFTest)
+	if err != nil || !bytes.Equal(opened, key) {
+		t.Fatalf("OpenVaultKey = %x, %v, want %x", opened, err, key)
+	}
+	if _, err := OpenVaultKey(ctx, sealedKey, []byte("wrongpass"), salt, KDFTest); !errors.Is(err, ErrAuthentication) {
+		t.Fatalf("OpenVaultKey with the wrong passphrase: %v", err)
+	}
+
+	body := []byte(`{"accounts":[]}`)
+	sealed, err := SealVault(key, body)
+	if err != nil {
+		t.Fatalf("SealVault: %v", err)
+	}
+	if bytes.Contains(sealed, body) {
+		t.Fatal("sealed vault contains the plaintext")
+	}
+	got, err := OpenVault(key, sealed)
+	if err != nil || !bytes.Equal(got, body) {
+		t.Fatalf("OpenVault = %q, %v", got, err)
+	}
+
+	sealed[len(sealed)-1] ^= 1
+	if _, err := OpenVault(key, sealed); !errors.Is(err, ErrAuthentication) {
+		t.Fatalf("OpenVault of a modified body: %v", err)
+	}
+	if _, err := OpenVault(key, sealed[:4]); !errors.Is(err, ErrAuthentication) {
+		t.Fatalf("OpenVault of a truncated body: %v", err)
+	}
+	other, _, _, err := NewVaultKey(ctx, []byte("testpass"), KDFTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err := OpenVault(other, sealed); !errors.Is(err, ErrAuthentication) {
+		t.Fatalf("OpenVault with another key: %v", err)
+	}
+}
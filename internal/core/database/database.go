@@ -2,13 +2,20 @@ package database
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
 	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xilverfang/syncora/internal/core/crypto"
+	"github.com/xilverfang/syncora/internal/core/logging"
 )
 
 const (
@@ -16,141 +23,250 @@ const (
 	dbTimeout = 5 * time.Second
 )
 
+// logger receives the package's diagnostics; see SetLogger.
+var logger = logging.Discard()
+
+// SetLogger sets the logger for the account stores and migrations. Nothing is logged until it is
+// called. Call it before opening a store.
+func SetLogger(l *slog.Logger) {
+	logger = l
+}
+
+// Storage backends selected with Config.Backend.
+const (
+	BackendPostgres = "postgres"
+	BackendSQLite   = "sqlite"
+	BackendFile     = "file"
+)
+
 // Account represents a stored account.
 type Account struct {
-	Alias        string
-	Address      string
-	EncryptedKey string
-	Salt         string
-	KeyVersion   uint8
+	Alias        string `json:"alias"`
+	Address      string `json:"address"`
+	EncryptedKey string `json:"encrypted_key"`
+	Salt         string `json:"salt"`
+	KeyVersion   uint8  `json:"key_version"`
+	KDFTime      uint32 `json:"kdf_time"`    // Argon2id passes
+	KDFMemory    uint32 `json:"kdf_memory"`  // Argon2id memory in KiB
+	KDFThreads   uint8  `json:"kdf_threads"` // Argon2id parallelism
+
+	SeedFingerprint string `json:"seed_fingerprint,omitempty"` // Seed the account was derived from, empty for imported keys
+	DerivationPath  string `json:"derivation_path,omitempty"`  // BIP-32 path within the seed, empty for imported keys
+
+	CreatedAt  time.Time `json:"created_at,omitzero"`   // Zero for accounts stored before it was recorded
+	LastUsedAt time.Time `json:"last_used_at,omitzero"` // Last successful unlock, zero if never
+	Tags       []string  `json:"tags,omitempty"`        // Normalized with NormalizeTags
+	Note       string    `json:"note,omitempty"`
 }
 
-// db is the global database connection.
-var db *sql.DB
+// Limits on account metadata.
+const (
+	MaxTags    = 16
+	MaxTagLen  = 32
+	MaxNoteLen = 256
+)
+
+// Seed represents a stored BIP-39 seed that accounts are derived from.
+type Seed struct {
+	Fingerprint   string `json:"fingerprint"`
+	EncryptedSeed string `json:"encrypted_seed"`
+	Salt          string `json:"salt"`
+	KeyVersion    uint8  `json:"key_version"`
+	KDFTime       uint32 `json:"kdf_time"`
+	KDFMemory     uint32 `json:"kdf_memory"`
+	KDFThreads    uint8  `json:"kdf_threads"`
+}
 
-// init initializes the database connection and ensures the correct schema.
-func init() {
+// Transfer is a bridge transfer sent from a stored account.
+type Transfer struct {
+	ID                string            `json:"id"`
+	Account           string            `json:"account"` // sender address
+	Bridge            string            `json:"bridge"`
+	Token             string            `json:"token"`
+	FromChain         string            `json:"from_chain"`
+	ToChain           string            `json:"to_chain"`
+	Amount            string            `json:"amount"`         // sent, in base units on FromChain
+	MinAmountOut      string            `json:"min_amount_out"` // guaranteed to arrive, in base units on ToChain
+	Recipient         string            `json:"recipient"`
+	TxHashes          []string          `json:"tx_hashes,omitempty"` // source chain transactions in order; the last one moves the funds
+	DestinationTxHash string            `json:"destination_tx_hash,omitempty"`
+	Status            string            `json:"status"`            // a bridge-engine transfer state, e.g. in_flight
+	PlanID            string            `json:"plan_id,omitempty"` // shared by the legs of a multi-leg transfer
+	Leg               int               `json:"leg,omitempty"`     // position in the plan, from 1
+	Data              map[string]string `json:"data,omitempty"`
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+}
+
+// Typed errors returned by stores, wrapped with details. Use errors.Is to check for them.
+var (
+	// ErrNotFound is returned when no account matches an alias or address.
+	ErrNotFound = errors.New("account not found")
+	// ErrConflict is returned when a record changed between being read and written.
+	ErrConflict = errors.New("account was modified or removed concurrently")
+	// ErrAliasInUse is returned when an alias already belongs to another account, ignoring case.
+	ErrAliasInUse = errors.New("alias already in use")
+	// ErrInvalid is returned for records that violate the schema.
+	ErrInvalid = errors.New("invalid record")
+	// ErrUnavailable is returned when the store cannot be opened or reached.
+	ErrUnavailable = errors.New("account store unavailable")
+	// ErrTransferNotFound is returned when no transfer has an ID.
+	ErrTransferNotFound = errors.New("transfer not found")
+)
+
+// AccountStore persists accounts, the seeds they are derived from and the bridge transfers sent
+// from them. Private keys and seeds are only ever handed to a store in encrypted form.
+type AccountStore interface {
+	// SaveAccount stores an account, replacing the key material and alias of any stored account
+	// with the same address. The metadata of an existing account is kept; a new account gets its
+	// Tags and Note, and CreatedAt if set or the current time otherwise.
+	SaveAccount(ctx context.Context, acc Account) error
+	// SaveSeedAccounts stores a seed and the accounts derived from it atomically.
+	SaveSeedAccounts(ctx context.Context, seed Seed, accounts []Account) error
+	// UpdateAccountKey replaces an account's key material if its ciphertext still equals previousEncryptedKey.
+	UpdateAccountKey(ctx context.Context, acc Account, previousEncryptedKey string) error
+	// ListAccounts returns all stored accounts.
+	ListAccounts(ctx context.Context) ([]Account, error)
+	// GetAccount returns an account by address, or by alias ignoring case.
+	GetAccount(ctx context.Context, identifier string) (*Account, error)
+	// RemoveAccount deletes an account by address, or by alias ignoring case.
+	RemoveAccount(ctx context.Context, identifier string) error
+	// RenameAccount changes the alias of an account selected by address or alias.
+	RenameAccount(ctx context.Context, identifier, alias string) error
+	// SetAccountMetadata replaces the tags and note of an account selected by address or alias.
+	SetAccountMetadata(ctx context.Context, identifier string, tags []string, note string) error
+	// MarkAccountUsed records when an account was last unlocked.
+	MarkAccountUsed(ctx context.Context, address string, at time.Time) error
+	// SaveTransfer stores a transfer, replacing the stored one with the same ID. UpdatedAt is set
+	// to the current time, and CreatedAt too for a new transfer if it is zero.
+	SaveTransfer(ctx context.Context, t Transfer) error
+	// GetTransfer returns a transfer by ID.
+	GetTransfer(ctx context.Context, id string) (*Transfer, error)
+	// ListTransfers returns the transfers sent from an account address, or from all accounts if
+	// account is empty, most recent first.
+	ListTransfers(ctx context.Context, account string) ([]Transfer, error)
+	// Close releases the store's resources.
+	Close() error
+}
+
+// Config selects and locates an account store.
+type Config struct {
+	Backend string // postgres, sqlite or file; empty selects postgres when URL is set and file otherwise
+	URL     string // PostgreSQL connection string
+	Path    string // SQLite database or vault file; empty uses a file in ~/.syncora
+
+	// AutoMigrate applies pending schema migrations when the store is opened. Without it, Open
+	// fails with ErrSchemaOutdated until 'syncora db migrate' has been run.
+	AutoMigrate bool
+
+	// VaultPassphrase supplies the passphrase of the file backend's encrypted vault; see
+	// NewFileStore. Without it, the file backend can only read vaults that are not encrypted yet.
+	VaultPassphrase func(ctx context.Context, create bool) ([]byte, error)
+	// VaultKDF holds the Argon2id parameters for the key of a new vault, crypto.KDFInteractive if zero.
+	VaultKDF crypto.KDFParams
+}
+
+// ConfigFromEnv reads SYNCORA_STORE, SYNCORA_DB_URL and SYNCORA_STORE_PATH, after checking that a
+// .env file in the working directory is not readable by others. SQLite databases are private to
+// one user, so they are migrated automatically; shared PostgreSQL databases are not.
+func ConfigFromEnv() (Config, error) {
 	// Check .env permissions
 	if err := checkEnvPermissions(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return Config{}, err
 	}
-
-	connStr := os.Getenv("SYNCORA_DB_URL")
-	if connStr == "" {
-		fmt.Fprintln(os.Stderr, "Error: SYNCORA_DB_URL not set")
-		os.Exit(1)
+	cfg := Config{
+		Backend: os.Getenv("SYNCORA_STORE"),
+		URL:     os.Getenv("SYNCORA_DB_URL"),
+		Path:    os.Getenv("SYNCORA_STORE_PATH"),
 	}
+	cfg.AutoMigrate = cfg.backend() == BackendSQLite
+	return cfg, nil
+}
 
-	var err error
-	db, err = sql.Open("postgres", connStr)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: failed to open database: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Configure connection pool
-	db.SetMaxOpenConns(10)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(time.Hour)
-
-	// Test connection
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
-	defer cancel()
-	if err := db.PingContext(ctx); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: failed to ping database: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Create accounts table if it doesn't exist
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS accounts (
-			address TEXT PRIMARY KEY,
-			alias TEXT NOT NULL,
-			encrypted_key TEXT NOT NULL,
-			CONSTRAINT valid_hex_encrypted_key CHECK (encrypted_key ~ '^[0-9a-fA-F]+$')
-		)
-	`)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: failed to create accounts table: %v\n", err)
-		os.Exit(1)
+// WithDefaults returns cfg with empty fields taken from defaults, such as a configuration profile
+// that the environment overrides. AutoMigrate follows the resulting backend as in ConfigFromEnv.
+func (cfg Config) WithDefaults(defaults Config) Config {
+	if cfg.Backend == "" {
+		cfg.Backend = defaults.Backend
 	}
-
-	// Migrate schema to add salt and key_version if missing
-	err = migrateSchema(ctx)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: failed to migrate schema: %v\n", err)
-		os.Exit(1)
+	if cfg.URL == "" {
+		cfg.URL = defaults.URL
 	}
-
-	// Enable audit logging
-	_, err = db.Exec(`CREATE EXTENSION IF NOT EXISTS pgaudit`)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to enable pgaudit: %v\n", err)
+	if cfg.Path == "" {
+		cfg.Path = defaults.Path
 	}
-
-	fmt.Fprintln(os.Stderr, "Database: Initialized successfully")
+	cfg.AutoMigrate = cfg.backend() == BackendSQLite
+	return cfg
 }
 
-// migrateSchema adds missing columns (salt, key_version) to the accounts table.
-func migrateSchema(ctx context.Context) error {
-	// Check if salt column exists
-	var count int
-	err := db.QueryRowContext(ctx, `
-		SELECT COUNT(*)
-		FROM information_schema.columns
-		WHERE table_name = 'accounts' AND column_name = 'salt'
-	`).Scan(&count)
-	if err != nil {
-		return fmt.Errorf("failed to check schema: %v", err)
+// backend returns the configured backend, defaulting to postgres when URL is set and file otherwise.
+func (cfg Config) backend() string {
+	if cfg.Backend != "" {
+		return cfg.Backend
 	}
+	if cfg.URL != "" {
+		return BackendPostgres
+	}
+	return BackendFile
+}
 
-	if count == 0 {
-		fmt.Fprintln(os.Stderr, "Database: Adding salt column")
-		_, err = db.ExecContext(ctx, `
-			ALTER TABLE accounts
-			ADD COLUMN salt TEXT NOT NULL DEFAULT '',
-			ADD CONSTRAINT valid_hex_salt CHECK (salt ~ '^[0-9a-fA-F]+$')
-		`)
+// Open opens the account store described by cfg. The caller must Close it.
+func Open(ctx context.Context, cfg Config) (AccountStore, error) {
+	switch backend := cfg.backend(); backend {
+	case BackendPostgres:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("%w: SYNCORA_DB_URL not set", ErrUnavailable)
+		}
+		return NewPostgresStore(ctx, cfg.URL, cfg.AutoMigrate)
+	case BackendSQLite:
+		path, err := storePath(cfg.Path, "syncora.db")
 		if err != nil {
-			return fmt.Errorf("failed to add salt column: %v", err)
+			return nil, err
 		}
+		return NewSQLiteStore(ctx, path, cfg.AutoMigrate)
+	case BackendFile:
+		path, err := storePath(cfg.Path, "accounts")
+		if err != nil {
+			return nil, err
+		}
+		return NewFileStore(path, cfg.VaultPassphrase, cfg.VaultKDF)
+	default:
+		return nil, fmt.Errorf("%w: unknown backend %q (expected %s, %s or %s)", ErrUnavailable, backend, BackendPostgres, BackendSQLite, BackendFile)
 	}
+}
 
-	// Check if key_version column exists
-	err = db.QueryRowContext(ctx, `
-		SELECT COUNT(*)
-		FROM information_schema.columns
-		WHERE table_name = 'accounts' AND column_name = 'key_version'
-	`).Scan(&count)
-	if err != nil {
-		return fmt.Errorf("failed to check schema: %v", err)
-	}
-
-	if count == 0 {
-		fmt.Fprintln(os.Stderr, "Database: Adding key_version column")
-		_, err = db.ExecContext(ctx, `
-			ALTER TABLE accounts
-			ADD COLUMN key_version SMALLINT NOT NULL DEFAULT 1,
-			ADD CONSTRAINT valid_key_version CHECK (key_version >= 1)
-		`)
+// OpenMigrator connects to the SQL database described by cfg without checking its schema. The
+// vault file backend has no schema and is rejected.
+func OpenMigrator(ctx context.Context, cfg Config) (Migrator, error) {
+	switch backend := cfg.backend(); backend {
+	case BackendPostgres:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("%w: SYNCORA_DB_URL not set", ErrUnavailable)
+		}
+		return connectPostgres(ctx, cfg.URL)
+	case BackendSQLite:
+		path, err := storePath(cfg.Path, "syncora.db")
 		if err != nil {
-			return fmt.Errorf("failed to add key_version column: %v", err)
+			return nil, err
 		}
+		return connectSQLite(path)
+	case BackendFile:
+		return nil, fmt.Errorf("the %s backend has no schema to migrate", BackendFile)
+	default:
+		return nil, fmt.Errorf("%w: unknown backend %q (expected %s or %s)", ErrUnavailable, backend, BackendPostgres, BackendSQLite)
 	}
+}
 
-	// Update existing rows with default salt (empty for now, requires re-import)
-	_, err = db.ExecContext(ctx, `
-		UPDATE accounts
-		SET salt = ''
-		WHERE salt IS NULL
-	`)
+// storePath returns path, or name inside ~/.syncora if path is empty.
+func storePath(path, name string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
 	if err != nil {
-		return fmt.Errorf("failed to update existing rows: %v", err)
+		return "", fmt.Errorf("%w: failed to locate home directory: %v", ErrUnavailable, err)
 	}
-
-	fmt.Fprintln(os.Stderr, "Database: Schema migration completed")
-	return nil
+	return filepath.Join(home, ".syncora", name), nil
 }
 
 // checkEnvPermissions ensures .env file has secure permissions (0600).
@@ -169,103 +285,130 @@ func checkEnvPermissions() error {
 	return nil
 }
 
-// SaveAccount stores an account with its encrypted private key, salt, and key version.
-func SaveAccount(alias, address, encryptedKey, salt string, keyVersion uint8) error {
-	fmt.Fprintln(os.Stderr, "Database: Starting SaveAccount")
-	if !common.IsHexAddress(address) {
-		return fmt.Errorf("invalid address: %s", address)
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
-	defer cancel()
+// DefaultAlias returns the alias given to an account stored without one: "account-" followed by
+// the first eight hex digits of its address.
+func DefaultAlias(address string) string {
+	return "account-" + strings.ToLower(strings.TrimPrefix(common.HexToAddress(address).Hex(), "0x")[:8])
+}
 
-	_, err := db.ExecContext(ctx, `
-		INSERT INTO accounts (address, alias, encrypted_key, salt, key_version)
-		VALUES ($1, $2, $3, $4, $5)
-		ON CONFLICT (address) DO UPDATE
-		SET alias = $2, encrypted_key = $3, salt = $4, key_version = $5
-	`, address, alias, encryptedKey, salt, keyVersion)
-	if err != nil {
-		return fmt.Errorf("failed to save account: %v", err)
+// ValidateAlias rejects empty aliases and aliases that could be mistaken for an address, since
+// identifiers are matched against addresses first.
+func ValidateAlias(alias string) error {
+	if alias == "" {
+		return fmt.Errorf("%w: alias must not be empty", ErrInvalid)
+	}
+	if common.IsHexAddress(alias) {
+		return fmt.Errorf("%w: alias %s looks like an address", ErrInvalid, alias)
 	}
-
-	fmt.Fprintln(os.Stderr, "Database: Account saved")
 	return nil
 }
 
-// ListAccounts retrieves all stored accounts.
-func ListAccounts() ([]Account, error) {
-	fmt.Fprintln(os.Stderr, "Database: Starting ListAccounts")
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
-	defer cancel()
+// NormalizeTags lowercases, sorts and deduplicates tags, and rejects tags that are empty, longer
+// than MaxTagLen, or contain anything but letters, digits, '-' and '_'.
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || len(tag) > MaxTagLen || strings.Trim(tag, "abcdefghijklmnopqrstuvwxyz0123456789-_") != "" {
+			return nil, fmt.Errorf("%w: tag %q must be 1-%d letters, digits, '-' or '_'", ErrInvalid, tag, MaxTagLen)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > MaxTags {
+		return nil, fmt.Errorf("%w: at most %d tags per account", ErrInvalid, MaxTags)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
 
-	rows, err := db.QueryContext(ctx, `SELECT address, alias, encrypted_key, salt, key_version FROM accounts`)
+// validateMetadata checks that tags are normalized and the note is within MaxNoteLen.
+func validateMetadata(tags []string, note string) error {
+	normalized, err := NormalizeTags(tags)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query accounts: %v", err)
+		return err
 	}
-	defer rows.Close()
-
-	var accounts []Account
-	for rows.Next() {
-		var acc Account
-		if err := rows.Scan(&acc.Address, &acc.Alias, &acc.EncryptedKey, &acc.Salt, &acc.KeyVersion); err != nil {
-			return nil, fmt.Errorf("failed to scan account: %v", err)
-		}
-		accounts = append(accounts, acc)
+	if !slices.Equal(normalized, tags) {
+		return fmt.Errorf("%w: tags must be normalized", ErrInvalid)
 	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating accounts: %v", err)
+	if len(note) > MaxNoteLen {
+		return fmt.Errorf("%w: note longer than %d bytes", ErrInvalid, MaxNoteLen)
 	}
+	return nil
+}
 
-	fmt.Fprintln(os.Stderr, "Database: Listed accounts, count:", len(accounts))
-	return accounts, nil
+// accountLookup returns the condition on $1 that selects the account named by identifier: the
+// address if identifier is one, otherwise the alias. Both are compared ignoring case.
+func accountLookup(identifier string) string {
+	if common.IsHexAddress(identifier) {
+		return "lower(address) = lower($1)"
+	}
+	return "lower(alias) = lower($1)"
 }
 
-// GetAccount returns an account by alias or address.
-func GetAccount(identifier string) (*Account, error) {
-	fmt.Fprintln(os.Stderr, "Database: Starting GetAccount")
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
-	defer cancel()
-
-	var acc Account
-	err := db.QueryRowContext(ctx, `
-		SELECT address, alias, encrypted_key, salt, key_version
-		FROM accounts
-		WHERE address = $1 OR alias = $1
-	`, identifier).Scan(&acc.Address, &acc.Alias, &acc.EncryptedKey, &acc.Salt, &acc.KeyVersion)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("account not found: %s", identifier)
+// validateAccount checks the fields every stored account must have.
+func validateAccount(acc Account) error {
+	if !common.IsHexAddress(acc.Address) {
+		return fmt.Errorf("%w: invalid address %s", ErrInvalid, acc.Address)
 	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to get account: %v", err)
+	if err := ValidateAlias(acc.Alias); err != nil {
+		return err
 	}
-
-	fmt.Fprintln(os.Stderr, "Database: Account retrieved")
-	return &acc, nil
+	if err := validateMetadata(acc.Tags, acc.Note); err != nil {
+		return err
+	}
+	if acc.KDFTime == 0 || acc.KDFMemory == 0 || acc.KDFThreads == 0 {
+		return fmt.Errorf("%w: missing kdf parameters for account %s", ErrInvalid, acc.Address)
+	}
+	return nil
 }
 
-// RemoveAccount deletes an account by alias or address.
-func RemoveAccount(identifier string) error {
-	fmt.Fprintln(os.Stderr, "Database: Starting RemoveAccount")
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
-	defer cancel()
-
-	result, err := db.ExecContext(ctx, `
-		DELETE FROM accounts
-		WHERE address = $1 OR alias = $1
-	`, identifier)
-	if err != nil {
-		return fmt.Errorf("failed to delete account: %v", err)
+// validateSeedAccounts checks a seed and that every account is linked to it.
+func validateSeedAccounts(seed Seed, accounts []Account) error {
+	if seed.Fingerprint == "" || seed.KDFTime == 0 || seed.KDFMemory == 0 || seed.KDFThreads == 0 {
+		return fmt.Errorf("%w: seed is missing its fingerprint or kdf parameters", ErrInvalid)
+	}
+	for _, acc := range accounts {
+		if err := validateAccount(acc); err != nil {
+			return err
+		}
+		if acc.SeedFingerprint != seed.Fingerprint {
+			return fmt.Errorf("%w: account %s is not linked to seed %s", ErrInvalid, acc.Address, seed.Fingerprint)
+		}
 	}
+	return nil
+}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to check rows affected: %v", err)
+// validateTransfer checks the fields every stored transfer must have.
+func validateTransfer(t Transfer) error {
+	if t.ID == "" || t.Bridge == "" || t.Token == "" || t.FromChain == "" || t.ToChain == "" || t.Status == "" {
+		return fmt.Errorf("%w: transfer is missing its ID, route or status", ErrInvalid)
 	}
-	if rows == 0 {
-		return fmt.Errorf("account not found: %s", identifier)
+	if !common.IsHexAddress(t.Account) || !common.IsHexAddress(t.Recipient) {
+		return fmt.Errorf("%w: invalid account or recipient address for transfer %s", ErrInvalid, t.ID)
+	}
+	if t.Leg < 0 || (t.PlanID == "") != (t.Leg == 0) {
+		return fmt.Errorf("%w: transfer %s must have both a plan ID and a leg number, or neither", ErrInvalid, t.ID)
+	}
+	if !isDigits(t.Amount) || !isDigits(t.MinAmountOut) {
+		return fmt.Errorf("%w: amounts of transfer %s must be integers in base units", ErrInvalid, t.ID)
+	}
+	for _, hash := range append(slices.Clip(t.TxHashes), t.DestinationTxHash) {
+		if hash != "" && !isTxHash(hash) {
+			return fmt.Errorf("%w: invalid transaction hash %s for transfer %s", ErrInvalid, hash, t.ID)
+		}
 	}
-
-	fmt.Fprintln(os.Stderr, "Database: Account deleted")
 	return nil
-}
\ No newline at end of file
+}
+
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// isTxHash reports whether s is a 0x-prefixed 32-byte hex hash.
+func isTxHash(s string) bool {
+	return len(s) == 66 && strings.HasPrefix(s, "0x") && strings.Trim(s[2:], "0123456789abcdefABCDEF") == ""
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package database
+
+import (
+	"context"
+	"os"
+)
+
+// lockFile is a no-op where flock is unavailable; writes are still atomic renames.
+func lockFile(ctx context.Context, f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,678 @@
+package database
+
+import (
+	"cmp"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xilverfang/syncora/internal/core/crypto"
+	"github.com/xilverfang/syncora/internal/core/logging"
+)
+
+// vaultVersion is the format version written to the vault file. Version 1 vaults, which allowed
+// duplicate and address-like aliases, version 2 vaults, which had no transfers, and version 3
+// vaults, which were plain JSON, are upgraded when read and encrypted on their next write. The
+// version keeps older binaries from rewriting the vault.
+const vaultVersion = 4
+
+// FileStore keeps accounts, seeds and transfers in a single encrypted JSON vault file, for use
+// without a database server. The vault body is sealed with AES-256-GCM under a random vault key,
+// which is sealed under the vault passphrase; private keys and seeds inside it are additionally
+// sealed under their own passphrases. The file is created with 0600 permissions, in a directory
+// created 0700, and rewritten atomically while holding a lock, so concurrent commands do not lose
+// updates.
+type FileStore struct {
+	path       string
+	passphrase func(ctx context.Context, create bool) ([]byte, error)
+	kdf        crypto.KDFParams
+
+	mu     sync.Mutex
+	key    []byte    // the vault key, once unlocked or created
+	header vaultFile // how key is sealed in the file, without Data
+}
+
+// vault is the body of a FileStore.
+type vault struct {
+	Version   int        `json:"version"`
+	Accounts  []Account  `json:"accounts"`
+	Seeds     []Seed     `json:"seeds"`
+	Transfers []Transfer `json:"transfers,omitempty"`
+}
+
+// vaultFile is the on-disk format of a FileStore: the vault key sealed under the passphrase like a
+// private key, and the vault body sealed with the vault key.
+type vaultFile struct {
+	Version    int    `json:"version"`
+	Key        string `json:"key"`
+	Salt       string `json:"salt"`
+	KDFTime    uint32 `json:"kdf_time"`
+	KDFMemory  uint32 `json:"kdf_memory"`
+	KDFThreads uint8  `json:"kdf_threads"`
+	Data       []byte `json:"data"`
+}
+
+// Close zeroes the vault key; the vault file is only open while it is read or written.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	zeroBytes(s.key)
+	s.key = nil
+	return nil
+}
+
+// NewFileStore opens the vault file at path, which is created on first write. passphrase is
+// called when the vault is first unlocked, and with create set when a new vault, or one written
+// before vaults were encrypted, is first written; the store zeroes the passphrase it returns. A new
+// vault key is sealed with the KDF parameters params, or crypto.KDFInteractive if they are zero.
+// The file is checked but not unlocked, so opening the store asks for no passphrase.
+func NewFileStore(path string, passphrase func(ctx context.Context, create bool) ([]byte, error), params crypto.KDFParams) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("%w: failed to create directory for %s: %v", ErrUnavailable, path, err)
+	}
+	if params == (crypto.KDFParams{}) {
+		params = crypto.KDFInteractive
+	}
+	s := &FileStore{path: path, passphrase: passphrase, kdf: params}
+	if _, _, err := s.readFile(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	logger.Debug("using vault file", "path", path)
+	return s, nil
+}
+
+// readFile reads the vault file and decodes its header. It returns a nil header if the file does
+// not exist yet, and the file contents for vaults written before version 4, which are plain JSON.
+func (s *FileStore) readFile() (*vaultFile, []byte, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read vault: %v", err)
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to stat vault: %v", err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, nil, fmt.Errorf("vault permissions too open: %s, expected 0600", info.Mode().Perm())
+	}
+
+	var f vaultFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse vault %s: %v", s.path, err)
+	}
+	if f.Version < 1 || f.Version > vaultVersion {
+		return nil, nil, fmt.Errorf("unsupported vault version %d", f.Version)
+	}
+	if f.Version < vaultVersion {
+		return &f, data, nil
+	}
+	return &f, nil, nil
+}
+
+// read loads the vault, unlocking it if needed, and returns an empty one if the file does not
+// exist yet.
+func (s *FileStore) read(ctx context.Context) (*vault, error) {
+	f, plain, err := s.readFile()
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return &vault{Version: vaultVersion}, nil
+	}
+	if plain != nil {
+		return readPlainVault(s.path, plain)
+	}
+
+	key, err := s.unlock(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	body, err := crypto.OpenVault(key, f.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt vault %s: %w", s.path, err)
+	}
+	var v vault
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, fmt.Errorf("failed to parse vault %s: %v", s.path, err)
+	}
+	v.Version = vaultVersion
+	return &v, nil
+}
+
+// readPlainVault decodes a vault written before version 4 and upgrades it.
+func readPlainVault(path string, data []byte) (*vault, error) {
+	var v vault
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("failed to parse vault %s: %v", path, err)
+	}
+	if v.Version == 1 {
+		if err := upgradeVaultAliases(&v); err != nil {
+			return nil, fmt.Errorf("failed to upgrade vault %s: %v", path, err)
+		}
+	}
+	v.Version = vaultVersion
+	return &v, nil
+}
+
+// upgradeVaultAliases renames aliases in a version 1 vault the way migration 0002_unique_alias
+// does for SQL stores: invalid aliases get the default alias, and duplicates other than the
+// lowest address get the first eight hex digits of their address as a suffix, or all 40 where
+// that name is taken as well. It fails, listing the accounts, if aliases still collide.
+func upgradeVaultAliases(v *vault) error {
+	for i, acc := range v.Accounts {
+		if ValidateAlias(acc.Alias) != nil {
+			v.Accounts[i].Alias = DefaultAlias(acc.Address)
+		}
+	}
+	lowest := make(map[string]string)
+	for _, acc := range v.Accounts {
+		key := strings.ToLower(acc.Alias)
+		if address, ok := lowest[key]; !ok || acc.Address < address {
+			lowest[key] = acc.Address
+		}
+	}
+	kept := make(map[string]bool)
+	renames := make(map[int]string)
+	renamed := make(map[string]int)
+	for i, acc := range v.Accounts {
+		if lowest[strings.ToLower(acc.Alias)] == acc.Address {
+			kept[strings.ToLower(acc.Alias)] = true
+			continue
+		}
+		renames[i] = acc.Alias + "-" + strings.ToLower(acc.Address[2:10])
+		renamed[strings.ToLower(renames[i])]++
+	}
+	for i, alias := range renames {
+		if key := strings.ToLower(alias); kept[key] || renamed[key] > 1 {
+			renames[i] = v.Accounts[i].Alias + "-" + strings.ToLower(v.Accounts[i].Address[2:])
+		}
+	}
+	for i, alias := range renames {
+		v.Accounts[i].Alias = alias
+	}
+
+	byAlias := make(map[string][]string)
+	for _, acc := range v.Accounts {
+		key := strings.ToLower(acc.Alias)
+		byAlias[key] = append(byAlias[key], acc.Address+" ("+acc.Alias+")")
+	}
+	var conflicts []string
+	for _, accounts := range byAlias {
+		if len(accounts) > 1 {
+			conflicts = append(conflicts, accounts...)
+		}
+	}
+	if len(conflicts) > 0 {
+		slices.Sort(conflicts)
+		return fmt.Errorf("account aliases are not unique: %s; edit these aliases in the vault file", strings.Join(conflicts, ", "))
+	}
+	v.Version = 2
+	return nil
+}
+
+// unlock returns the vault key sealed in f, asking for the passphrase unless the key is already
+// known.
+func (s *FileStore) unlock(ctx context.Context, f *vaultFile) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key != nil && s.header.Key == f.Key && s.header.Salt == f.Salt {
+		return s.key, nil
+	}
+	if s.passphrase == nil {
+		return nil, fmt.Errorf("vault %s is encrypted and no passphrase was supplied", s.path)
+	}
+	salt, err := hex.DecodeString(f.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse vault %s: invalid salt", s.path)
+	}
+	passphrase, err := s.passphrase(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(passphrase)
+	params := crypto.KDFParams{Time: f.KDFTime, Memory: f.KDFMemory, Threads: f.KDFThreads}
+	key, err := crypto.OpenVaultKey(ctx, f.Key, passphrase, salt, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock vault: %w", err)
+	}
+
+	zeroBytes(s.key)
+	s.key = key
+	s.header = *f
+	s.header.Data = nil
+	return key, nil
+}
+
+// vaultKey returns the vault key and how it is sealed, creating a key sealed under a new
+// passphrase if the vault has none yet.
+func (s *FileStore) vaultKey(ctx context.Context) ([]byte, vaultFile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key != nil {
+		return s.key, s.header, nil
+	}
+	if s.passphrase == nil {
+		return nil, vaultFile{}, fmt.Errorf("no passphrase was supplied to encrypt vault %s", s.path)
+	}
+	passphrase, err := s.passphrase(ctx, true)
+	if err != nil {
+		return nil, vaultFile{}, err
+	}
+	defer zeroBytes(passphrase)
+	key, sealedKey, salt, err := crypto.NewVaultKey(ctx, passphrase, s.kdf)
+	if err != nil {
+		return nil, vaultFile{}, fmt.Errorf("failed to create vault key: %w", err)
+	}
+
+	s.key = key
+	s.header = vaultFile{
+		Version:    vaultVersion,
+		Key:        sealedKey,
+		Salt:       hex.EncodeToString(salt),
+		KDFTime:    s.kdf.Time,
+		KDFMemory:  s.kdf.Memory,
+		KDFThreads: s.kdf.Threads,
+	}
+	logger.Info("vault key created", "path", s.path)
+	return s.key, s.header, nil
+}
+
+// update applies fn to the vault under an exclusive lock and writes the result back atomically.
+// The vault key is obtained first, so that a passphrase prompt does not hold the lock.
+func (s *FileStore) update(ctx context.Context, fn func(v *vault) error) error {
+	f, _, err := s.readFile()
+	if err != nil {
+		return err
+	}
+	if f != nil && f.Version == vaultVersion {
+		_, err = s.unlock(ctx, f)
+	} else {
+		_, _, err = s.vaultKey(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	lock, err := os.OpenFile(s.path+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open vault lock: %v", err)
+	}
+	defer lock.Close()
+	if err := lockFile(ctx, lock); err != nil {
+		return fmt.Errorf("failed to lock vault: %v", err)
+	}
+	defer unlockFile(lock)
+
+	v, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(v); err != nil {
+		return err
+	}
+	return s.write(ctx, v)
+}
+
+// write seals v and replaces the vault file with it via a synced temporary file and rename.
+func (s *FileStore) write(ctx context.Context, v *vault) error {
+	key, file, err := s.vaultKey(ctx)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode vault: %v", err)
+	}
+	if file.Data, err = crypto.SealVault(key, body); err != nil {
+		return fmt.Errorf("failed to encrypt vault: %v", err)
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode vault: %v", err)
+	}
+
+	// CreateTemp creates the file with 0600 permissions.
+	f, err := os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary vault: %v", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write vault: %v", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync vault: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write vault: %v", err)
+	}
+	if err := os.Rename(f.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace vault: %v", err)
+	}
+	return nil
+}
+
+// checkAccountRecord enforces the constraints the SQL schemas place on an account row.
+func checkAccountRecord(v *vault, acc Account) error {
+	if err := validateAccount(acc); err != nil {
+		return err
+	}
+	if !isHex(acc.EncryptedKey) || !isHex(acc.Salt) {
+		return fmt.Errorf("%w: encrypted key and salt must be hex for account %s", ErrInvalid, acc.Address)
+	}
+	if acc.KeyVersion < 1 {
+		return fmt.Errorf("%w: key version for account %s", ErrInvalid, acc.Address)
+	}
+	if acc.SeedFingerprint != "" && findSeed(v, acc.SeedFingerprint) < 0 {
+		return fmt.Errorf("%w: seed %s not found for account %s", ErrInvalid, acc.SeedFingerprint, acc.Address)
+	}
+	return nil
+}
+
+// zeroBytes overwrites b with zeros.
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return s != "" && err == nil
+}
+
+func findAccount(v *vault, address string) int {
+	for i, acc := range v.Accounts {
+		if acc.Address == address {
+			return i
+		}
+	}
+	return -1
+}
+
+// lookupAccount returns the index of the account selected by identifier, matching the address if
+// identifier is one and the alias ignoring case otherwise.
+func lookupAccount(v *vault, identifier string) int {
+	for i, acc := range v.Accounts {
+		if common.IsHexAddress(identifier) {
+			if strings.EqualFold(acc.Address, identifier) {
+				return i
+			}
+		} else if strings.EqualFold(acc.Alias, identifier) {
+			return i
+		}
+	}
+	return -1
+}
+
+func findSeed(v *vault, fingerprint string) int {
+	for i, seed := range v.Seeds {
+		if seed.Fingerprint == fingerprint {
+			return i
+		}
+	}
+	return -1
+}
+
+// putAccount inserts an account or replaces the stored one with the same address.
+func putAccount(v *vault, acc Account) error {
+	if err := checkAccountRecord(v, acc); err != nil {
+		return err
+	}
+	if i := lookupAccount(v, acc.Alias); i >= 0 && v.Accounts[i].Address != acc.Address {
+		return fmt.Errorf("%w: %s", ErrAliasInUse, acc.Alias)
+	}
+	if i := findAccount(v, acc.Address); i >= 0 {
+		// Keep the metadata of the stored account, as the SQL stores do.
+		stored := v.Accounts[i]
+		acc.CreatedAt, acc.LastUsedAt, acc.Tags, acc.Note = stored.CreatedAt, stored.LastUsedAt, stored.Tags, stored.Note
+		v.Accounts[i] = acc
+	} else {
+		if acc.CreatedAt.IsZero() {
+			acc.CreatedAt = time.Now().UTC()
+		}
+		v.Accounts = append(v.Accounts, acc)
+	}
+	return nil
+}
+
+// SaveAccount stores an account with its encrypted private key, salt, key version, and KDF parameters.
+func (s *FileStore) SaveAccount(ctx context.Context, acc Account) error {
+	logger.Debug("saving account", logging.Address(acc.Address))
+	if err := s.update(ctx, func(v *vault) error { return putAccount(v, acc) }); err != nil {
+		return fmt.Errorf("failed to save account: %w", err)
+	}
+	logger.Info("account saved", "alias", acc.Alias, logging.Address(acc.Address))
+	return nil
+}
+
+// SaveSeedAccounts stores a seed and the accounts derived from it in a single write.
+func (s *FileStore) SaveSeedAccounts(ctx context.Context, seed Seed, accounts []Account) error {
+	logger.Debug("saving seed accounts", "count", len(accounts))
+	if err := validateSeedAccounts(seed, accounts); err != nil {
+		return err
+	}
+	if !isHex(seed.EncryptedSeed) || !isHex(seed.Salt) {
+		return fmt.Errorf("%w: encrypted seed and salt must be hex", ErrInvalid)
+	}
+
+	err := s.update(ctx, func(v *vault) error {
+		if i := findSeed(v, seed.Fingerprint); i >= 0 {
+			v.Seeds[i] = seed
+		} else {
+			v.Seeds = append(v.Seeds, seed)
+		}
+		for _, acc := range accounts {
+			if err := putAccount(v, acc); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save seed: %w", err)
+	}
+
+	logger.Info("seed and derived accounts saved", "count", len(accounts))
+	return nil
+}
+
+// UpdateAccountKey replaces an account's encrypted key, salt, key version, and KDF parameters
+// if the stored ciphertext still equals previousEncryptedKey.
+func (s *FileStore) UpdateAccountKey(ctx context.Context, acc Account, previousEncryptedKey string) error {
+	logger.Debug("updating account key", logging.Address(acc.Address))
+	err := s.update(ctx, func(v *vault) error {
+		i := findAccount(v, acc.Address)
+		if i < 0 || v.Accounts[i].EncryptedKey != previousEncryptedKey {
+			return fmt.Errorf("%w: %s", ErrConflict, acc.Address)
+		}
+		stored := v.Accounts[i]
+		stored.EncryptedKey = acc.EncryptedKey
+		stored.Salt = acc.Salt
+		stored.KeyVersion = acc.KeyVersion
+		stored.KDFTime = acc.KDFTime
+		stored.KDFMemory = acc.KDFMemory
+		stored.KDFThreads = acc.KDFThreads
+		if err := checkAccountRecord(v, stored); err != nil {
+			return err
+		}
+		v.Accounts[i] = stored
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info("account key updated", logging.Address(acc.Address))
+	return nil
+}
+
+// ListAccounts retrieves all stored accounts.
+func (s *FileStore) ListAccounts(ctx context.Context) ([]Account, error) {
+	logger.Debug("listing accounts")
+	v, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug("listed accounts", "count", len(v.Accounts))
+	return v.Accounts, nil
+}
+
+// GetAccount returns an account by address, or by alias ignoring case.
+func (s *FileStore) GetAccount(ctx context.Context, identifier string) (*Account, error) {
+	logger.Debug("getting account")
+	v, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	i := lookupAccount(v, identifier)
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, identifier)
+	}
+	logger.Debug("account retrieved", logging.Address(v.Accounts[i].Address))
+	return &v.Accounts[i], nil
+}
+
+// RemoveAccount deletes an account by address, or by alias ignoring case.
+func (s *FileStore) RemoveAccount(ctx context.Context, identifier string) error {
+	logger.Debug("removing account")
+	err := s.update(ctx, func(v *vault) error {
+		i := lookupAccount(v, identifier)
+		if i < 0 {
+			return fmt.Errorf("%w: %s", ErrNotFound, identifier)
+		}
+		v.Accounts = append(v.Accounts[:i], v.Accounts[i+1:]...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info("account deleted")
+	return nil
+}
+
+// RenameAccount changes the alias of the account selected by identifier.
+func (s *FileStore) RenameAccount(ctx context.Context, identifier, alias string) error {
+	logger.Debug("renaming account", "alias", alias)
+	err := s.update(ctx, func(v *vault) error {
+		i := lookupAccount(v, identifier)
+		if i < 0 {
+			return fmt.Errorf("%w: %s", ErrNotFound, identifier)
+		}
+		acc := v.Accounts[i]
+		acc.Alias = alias
+		return putAccount(v, acc)
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info("account renamed", "alias", alias)
+	return nil
+}
+
+// SetAccountMetadata replaces the tags and note of the account selected by identifier.
+func (s *FileStore) SetAccountMetadata(ctx context.Context, identifier string, tags []string, note string) error {
+	logger.Debug("updating account metadata")
+	if err := validateMetadata(tags, note); err != nil {
+		return err
+	}
+	err := s.update(ctx, func(v *vault) error {
+		i := lookupAccount(v, identifier)
+		if i < 0 {
+			return fmt.Errorf("%w: %s", ErrNotFound, identifier)
+		}
+		v.Accounts[i].Tags = tags
+		v.Accounts[i].Note = note
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info("account metadata updated")
+	return nil
+}
+
+// MarkAccountUsed sets an account's LastUsedAt.
+func (s *FileStore) MarkAccountUsed(ctx context.Context, address string, at time.Time) error {
+	return s.update(ctx, func(v *vault) error {
+		i := findAccount(v, address)
+		if i < 0 {
+			return fmt.Errorf("%w: %s", ErrNotFound, address)
+		}
+		v.Accounts[i].LastUsedAt = at.UTC()
+		return nil
+	})
+}
+
+// SaveTransfer inserts a transfer or replaces the stored one with the same ID, keeping its
+// CreatedAt.
+func (s *FileStore) SaveTransfer(ctx context.Context, t Transfer) error {
+	logger.Debug("saving transfer", "id", t.ID, "status", t.Status)
+	if err := validateTransfer(t); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	t.UpdatedAt = now
+	err := s.update(ctx, func(v *vault) error {
+		i := slices.IndexFunc(v.Transfers, func(stored Transfer) bool { return stored.ID == t.ID })
+		if i >= 0 {
+			t.CreatedAt = v.Transfers[i].CreatedAt
+			v.Transfers[i] = t
+			return nil
+		}
+		if t.CreatedAt.IsZero() {
+			t.CreatedAt = now
+		}
+		v.Transfers = append(v.Transfers, t)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save transfer: %w", err)
+	}
+	logger.Info("transfer saved", "id", t.ID, "status", t.Status)
+	return nil
+}
+
+// GetTransfer returns a transfer by ID.
+func (s *FileStore) GetTransfer(ctx context.Context, id string) (*Transfer, error) {
+	v, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(v.Transfers, func(t Transfer) bool { return t.ID == id })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTransferNotFound, id)
+	}
+	return &v.Transfers[i], nil
+}
+
+// ListTransfers returns the transfers sent from account, or all transfers, most recent first.
+func (s *FileStore) ListTransfers(ctx context.Context, account string) ([]Transfer, error) {
+	logger.Debug("listing transfers")
+	v, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	transfers := slices.DeleteFunc(v.Transfers, func(t Transfer) bool {
+		return account != "" && !strings.EqualFold(t.Account, account)
+	})
+	slices.SortStableFunc(transfers, func(a, b Transfer) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+	return transfers, nil
+}
@@ -0,0 +1,378 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// migrationFiles holds the numbered up/down migrations for each SQL dialect, named
+// migrations/<dialect>/NNNN_<name>.up.sql and NNNN_<name>.down.sql.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// ErrSchemaOutdated is returned by Open when the database has pending migrations and
+// Config.AutoMigrate is off.
+var ErrSchemaOutdated = errors.New("database schema is out of date")
+
+// ErrIrreversible is returned by Rollback for migrations that cannot be reverted.
+var ErrIrreversible = errors.New("migration cannot be reverted")
+
+// baselineVersion is the migration that creates, or adopts, the accounts and seeds tables. It is
+// never reverted, since that would delete every stored key.
+const baselineVersion = 1
+
+// Migration is a numbered schema change with its inverse.
+type Migration struct {
+	Version  int
+	Name     string
+	Checksum string // SHA-256 of the up script
+	// DropsData is set when reverting the migration drops tables or columns, and the data in them.
+	DropsData bool
+	up, down  string
+}
+
+// MigrationStatus describes a migration known to this binary, the database, or both.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Modified  bool // the applied checksum differs from the embedded script
+	Unknown   bool // applied to the database but not embedded in this binary
+	DropsData bool // reverting it drops tables or columns
+}
+
+// Migrator applies and reverts schema migrations.
+type Migrator interface {
+	// Migrate applies all pending migrations in order and returns them.
+	Migrate(ctx context.Context) ([]Migration, error)
+	// MigrationStatus reports every embedded and applied migration in version order.
+	MigrationStatus(ctx context.Context) ([]MigrationStatus, error)
+	// Rollback reverts the most recent steps migrations and returns them. The baseline migration
+	// is never reverted.
+	Rollback(ctx context.Context, steps int) ([]Migration, error)
+	// Close releases the database connection.
+	Close() error
+}
+
+// dialect holds what differs between the SQL backends.
+type dialect struct {
+	name string
+	// lock serializes migrations across processes and returns the function that releases it.
+	lock func(ctx context.Context, conn *sql.Conn) (func(), error)
+	// uniqueViolation reports whether err is a unique constraint violation.
+	uniqueViolation func(err error) bool
+}
+
+var migrationFileName = regexp.MustCompile(`^(\d{4})_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// dropsData matches the statements of down scripts that delete stored data.
+var dropsData = regexp.MustCompile(`(?i)\bDROP\s+(TABLE|COLUMN)\b`)
+
+// loadMigrations reads the embedded migrations for a dialect and checks that they are numbered
+// 1..n without gaps and that each has both an up and a down script.
+func loadMigrations(name string) ([]Migration, error) {
+	dir := path.Join("migrations", name)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %v", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		m := migrationFileName.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("unexpected migration file: %s", e.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		data, err := migrationFiles.ReadFile(path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %v", e.Name(), err)
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %04d has conflicting names: %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.up = string(data)
+			sum := sha256.Sum256(data)
+			mig.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			mig.down = string(data)
+			mig.DropsData = dropsData.MatchString(mig.down)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for i := 1; i <= len(byVersion); i++ {
+		mig, ok := byVersion[i]
+		if !ok {
+			return nil, fmt.Errorf("migration %04d is missing", i)
+		}
+		if mig.up == "" || mig.down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both up and down scripts", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	return migrations, nil
+}
+
+// appliedMigration is a row of schema_migrations.
+type appliedMigration struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// ensureMigrationsTable creates schema_migrations if it does not exist.
+func ensureMigrationsTable(ctx context.Context, ex execer) error {
+	_, err := ex.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %v", err)
+	}
+	return nil
+}
+
+// queryer is implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// appliedMigrations reads schema_migrations by version.
+func appliedMigrations(ctx context.Context, q queryer) (map[int]appliedMigration, error) {
+	rows, err := q.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %v", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var version int
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %v", err)
+		}
+		applied[version] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating schema_migrations: %v", err)
+	}
+	return applied, nil
+}
+
+// withMigrationLock runs fn on a dedicated connection while holding the dialect's migration lock.
+func (s *sqlStore) withMigrationLock(ctx context.Context, fn func(conn *sql.Conn, migrations []Migration) error) error {
+	migrations, err := loadMigrations(s.dialect.name)
+	if err != nil {
+		return err
+	}
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %v", err)
+	}
+	defer conn.Close()
+
+	unlock, err := s.dialect.lock(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %v", err)
+	}
+	defer unlock()
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn, migrations)
+}
+
+// Migrate applies pending migrations, each in its own transaction. Applied migrations whose
+// embedded script has changed are reported instead of being run again.
+func (s *sqlStore) Migrate(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := s.withMigrationLock(ctx, func(conn *sql.Conn, migrations []Migration) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			if a, ok := applied[m.Version]; ok {
+				if a.checksum != m.Checksum {
+					return fmt.Errorf("migration %04d_%s was modified after it was applied (checksum mismatch)", m.Version, m.Name)
+				}
+				continue
+			}
+
+			ran := false
+			if err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				// Another process may have applied it since the versions were read.
+				var n int
+				if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = $1`, m.Version).Scan(&n); err != nil || n > 0 {
+					return err
+				}
+				logger.Info("applying migration", "version", m.Version, "name", m.Name)
+				if _, err := tx.ExecContext(ctx, m.up); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES ($1, $2, $3, $4)`,
+					m.Version, m.Name, m.Checksum, time.Now().UTC())
+				ran = err == nil
+				return err
+			}); err != nil {
+				return fmt.Errorf("migration %04d_%s failed: %v", m.Version, m.Name, err)
+			}
+			if !ran {
+				continue
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Rollback reverts the most recent steps applied migrations, newest first.
+func (s *sqlStore) Rollback(ctx context.Context, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("rollback steps must be at least 1")
+	}
+
+	var done []Migration
+	err := s.withMigrationLock(ctx, func(conn *sql.Conn, migrations []Migration) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		versions := make([]int, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+		if steps > len(versions) {
+			return fmt.Errorf("cannot roll back %d migrations, only %d applied", steps, len(versions))
+		}
+		if slices.Contains(versions[:steps], baselineVersion) {
+			return fmt.Errorf("%w: %04d_%s holds the accounts and seeds tables; at most %d migrations can be rolled back",
+				ErrIrreversible, baselineVersion, applied[baselineVersion].name, len(versions)-1)
+		}
+
+		for _, v := range versions[:steps] {
+			if v > len(migrations) {
+				return fmt.Errorf("migration %04d_%s is not known to this version of syncora", v, applied[v].name)
+			}
+			m := migrations[v-1]
+
+			logger.Info("reverting migration", "version", m.Version, "name", m.Name)
+			if err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, m.down); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+				return err
+			}); err != nil {
+				return fmt.Errorf("rollback of %04d_%s failed: %v", m.Version, m.Name, err)
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// inTx runs fn in a transaction on conn and commits it if fn succeeds.
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MigrationStatus reports every embedded and applied migration in version order.
+func (s *sqlStore) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations(s.dialect.name)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureMigrationsTable(ctx, s.db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var status []MigrationStatus
+	for _, m := range migrations {
+		st := MigrationStatus{Version: m.Version, Name: m.Name, DropsData: m.DropsData}
+		if a, ok := applied[m.Version]; ok {
+			st.Applied = true
+			st.AppliedAt = a.appliedAt
+			st.Modified = a.checksum != m.Checksum
+			delete(applied, m.Version)
+		}
+		status = append(status, st)
+	}
+	for v, a := range applied {
+		status = append(status, MigrationStatus{Version: v, Name: a.name, Applied: true, AppliedAt: a.appliedAt, Unknown: true})
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Version < status[j].Version })
+	return status, nil
+}
+
+// checkSchema applies pending migrations if autoMigrate is set, and otherwise fails with
+// ErrSchemaOutdated while any are pending.
+func (s *sqlStore) checkSchema(ctx context.Context, autoMigrate bool) error {
+	if autoMigrate {
+		_, err := s.Migrate(ctx)
+		return err
+	}
+
+	status, err := s.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+	pending := 0
+	for _, st := range status {
+		if !st.Applied {
+			pending++
+		}
+		if st.Modified {
+			return fmt.Errorf("migration %04d_%s was modified after it was applied (checksum mismatch)", st.Version, st.Name)
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%w: %d pending migration(s); run 'syncora db migrate'", ErrSchemaOutdated, pending)
+	}
+	return nil
+}
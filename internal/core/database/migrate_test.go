@@ -0,0 +1,169 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	for _, dialect := range []string{BackendPostgres, BackendSQLite} {
+		migrations, err := loadMigrations(dialect)
+		if err != nil {
+			t.Fatalf("%s: %v", dialect, err)
+		}
+		if len(migrations) == 0 || migrations[0].Version != 1 || migrations[0].Checksum == "" {
+			t.Fatalf("%s: unexpected migrations %+v", dialect, migrations)
+		}
+		// The baseline's down script is never run and must not drop the keys.
+		if migrations[0].DropsData || migrations[1].DropsData || !migrations[3].DropsData {
+			t.Fatalf("%s: unexpected DropsData in %+v", dialect, migrations[:4])
+		}
+	}
+}
+
+func TestMigrateSQLite(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "syncora.db")
+
+	if _, err := NewSQLiteStore(ctx, path, false); !errors.Is(err, ErrSchemaOutdated) {
+		t.Fatalf("expected ErrSchemaOutdated, got %v", err)
+	}
+
+	m, err := connectSQLite(path)
+	if err != nil {
+		t.Fatalf("connectSQLite: %v", err)
+	}
+	defer m.Close()
+
+	all, err := loadMigrations(BackendSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := m.Migrate(ctx)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if len(applied) != len(all) {
+		t.Fatalf("applied %d migrations, want %d", len(applied), len(all))
+	}
+	if applied, err := m.Migrate(ctx); err != nil || len(applied) != 0 {
+		t.Fatalf("second Migrate applied %d: %v", len(applied), err)
+	}
+
+	s, err := NewSQLiteStore(ctx, path, false)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore after migrate: %v", err)
+	}
+	s.Close()
+
+	reverted, err := m.Rollback(ctx, 1)
+	if err != nil || len(reverted) != 1 || reverted[0].Version != len(all) {
+		t.Fatalf("Rollback: %+v, %v", reverted, err)
+	}
+	status, err := m.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatalf("MigrationStatus: %v", err)
+	}
+	if status[len(all)-1].Applied {
+		t.Fatalf("migration %d still applied after rollback", len(all))
+	}
+	if _, err := m.Rollback(ctx, len(all)); err == nil {
+		t.Fatal("expected rolling back more than applied to fail")
+	}
+	if _, err := m.Rollback(ctx, len(all)-1); !errors.Is(err, ErrIrreversible) {
+		t.Fatalf("expected ErrIrreversible for the baseline, got %v", err)
+	}
+	if status, err := m.MigrationStatus(ctx); err != nil || !status[0].Applied || !status[len(all)-2].Applied {
+		t.Fatalf("refused rollback reverted migrations: %+v, %v", status, err)
+	}
+
+	if _, err := m.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate after rollback: %v", err)
+	}
+	if _, err := m.db.ExecContext(ctx, `UPDATE schema_migrations SET checksum = 'tampered' WHERE version = 1`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Migrate(ctx); err == nil {
+		t.Fatal("expected checksum mismatch")
+	}
+	status, err = m.MigrationStatus(ctx)
+	if err != nil || !status[0].Modified {
+		t.Fatalf("expected migration 1 to be reported as modified: %+v, %v", status, err)
+	}
+}
+
+func TestMigrateUniqueAlias(t *testing.T) {
+	ctx := context.Background()
+	m := legacyAliasStore(t, testAccount(testAddress1, testAddress1), testAccount(testAddress2, "Hot"), testAccount(testSeed, "hot"),
+		testAccount(testAddress3, "hot-70997970"))
+	if _, err := m.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	// Hot-70997970 is taken, so the duplicate gets the whole address as its suffix.
+	want := map[string]string{
+		testAddress1: "account-f39fd6e5",
+		testAddress2: "Hot-70997970c51812dc3a010c7d01b50e0d17dc79c8",
+		testSeed:     "hot",
+		testAddress3: "hot-70997970",
+	}
+	for address, alias := range want {
+		acc, err := m.GetAccount(ctx, address)
+		if err != nil || acc.Alias != alias {
+			t.Errorf("account %s = %+v, %v, want alias %s", address, acc, err, alias)
+		}
+	}
+	if _, err := m.db.ExecContext(ctx, `UPDATE accounts SET alias = $1 WHERE alias = 'hot'`, testAddress1); err == nil {
+		t.Error("expected the valid_alias trigger to reject an address-like alias")
+	}
+}
+
+func TestMigrateUniqueAliasConflict(t *testing.T) {
+	ctx := context.Background()
+	m := legacyAliasStore(t, testAccount(testAddress2, "Hot"), testAccount(testSeed, "hot"),
+		testAccount(testAddress3, "hot-70997970"), testAccount(testAddress1, "hot-70997970c51812dc3a010c7d01b50e0d17dc79c8"))
+	_, err := m.Migrate(ctx)
+	if err == nil || !strings.Contains(err.Error(), "account aliases are not unique: "+testAddress2+" (Hot-70997970c51812dc3a010c7d01b50e0d17dc79c8), "+testAddress1) {
+		t.Fatalf("Migrate = %v, want the conflicting accounts listed", err)
+	}
+	// The failed migration leaves the rows as they were.
+	var alias string
+	if err := m.db.QueryRowContext(ctx, `SELECT alias FROM accounts WHERE address = $1`, testAddress2).Scan(&alias); err != nil || alias != "Hot" {
+		t.Errorf("account %s alias = %q, %v, want Hot", testAddress2, alias, err)
+	}
+}
+
+// legacyAliasStore returns a SQLite store migrated up to the baseline only, holding accounts the
+// old schema allowed.
+func legacyAliasStore(t *testing.T, accounts ...Account) *SQLiteStore {
+	t.Helper()
+	ctx := context.Background()
+	m, err := connectSQLite(filepath.Join(t.TempDir(), "syncora.db"))
+	if err != nil {
+		t.Fatalf("connectSQLite: %v", err)
+	}
+	t.Cleanup(func() { m.Close() })
+
+	all, err := loadMigrations(BackendSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if _, err := m.Rollback(ctx, len(all)-1); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	for _, acc := range accounts {
+		_, err := m.db.ExecContext(ctx, `
+			INSERT INTO accounts (address, alias, encrypted_key, salt, key_version, kdf_time, kdf_memory, kdf_threads)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, acc.Address, acc.Alias, acc.EncryptedKey, acc.Salt, acc.KeyVersion, acc.KDFTime, acc.KDFMemory, acc.KDFThreads)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
@@ -0,0 +1,41 @@
+-- Baseline schema. Written to be idempotent so databases created before versioned
+-- migrations, by init-db.sql or the old column-by-column upgrades, adopt it in place.
+
+CREATE TABLE IF NOT EXISTS seeds (
+    fingerprint TEXT PRIMARY KEY,
+    encrypted_seed TEXT NOT NULL,
+    salt TEXT NOT NULL,
+    key_version SMALLINT NOT NULL,
+    kdf_time INTEGER NOT NULL,
+    kdf_memory INTEGER NOT NULL,
+    kdf_threads SMALLINT NOT NULL,
+    CONSTRAINT valid_hex_encrypted_seed CHECK (encrypted_seed ~ '^[0-9a-fA-F]+$'),
+    CONSTRAINT valid_hex_seed_salt CHECK (salt ~ '^[0-9a-fA-F]+$')
+);
+
+CREATE TABLE IF NOT EXISTS accounts (
+    address TEXT PRIMARY KEY,
+    alias TEXT NOT NULL,
+    encrypted_key TEXT NOT NULL,
+    CONSTRAINT valid_hex_encrypted_key CHECK (encrypted_key ~ '^[0-9a-fA-F]+$')
+);
+
+-- Defaults match the values used before they were stored, so existing rows keep decrypting.
+ALTER TABLE accounts
+    ADD COLUMN IF NOT EXISTS salt TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS key_version SMALLINT NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS kdf_time INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS kdf_memory INTEGER NOT NULL DEFAULT 32768,
+    ADD COLUMN IF NOT EXISTS kdf_threads SMALLINT NOT NULL DEFAULT 4,
+    ADD COLUMN IF NOT EXISTS seed_fingerprint TEXT REFERENCES seeds (fingerprint),
+    ADD COLUMN IF NOT EXISTS derivation_path TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE accounts
+    DROP CONSTRAINT IF EXISTS valid_hex_salt,
+    DROP CONSTRAINT IF EXISTS valid_key_version,
+    DROP CONSTRAINT IF EXISTS valid_kdf_params;
+
+ALTER TABLE accounts
+    ADD CONSTRAINT valid_hex_salt CHECK (salt ~ '^[0-9a-fA-F]+$'),
+    ADD CONSTRAINT valid_key_version CHECK (key_version >= 1),
+    ADD CONSTRAINT valid_kdf_params CHECK (kdf_time >= 1 AND kdf_memory >= 8 AND kdf_threads >= 1);
@@ -0,0 +1,3 @@
+-- Renamed aliases are kept.
+ALTER TABLE accounts DROP CONSTRAINT valid_alias;
+DROP INDEX accounts_alias_key;
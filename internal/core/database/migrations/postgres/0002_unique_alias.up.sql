@@ -0,0 +1,54 @@
+-- Aliases are unique ignoring case and may not look like an address, so that an identifier
+-- selects at most one account. Existing rows are renamed to satisfy the new constraints:
+-- empty or address-like aliases (the old default was the address itself) become
+-- account-<first 8 hex digits of the address>, and duplicates other than the lowest address
+-- get the same digits as a suffix, or all 40 digits where that name is taken as well. If
+-- aliases still collide, the migration fails and lists the accounts to rename by hand.
+
+UPDATE accounts
+SET alias = 'account-' || lower(substr(address, 3, 8))
+WHERE alias = '' OR alias ~* '^(0x)?[0-9a-f]{40}$';
+
+CREATE TEMP TABLE alias_renames ON COMMIT DROP AS
+SELECT address, alias AS old_alias, alias || '-' || lower(substr(address, 3, 8)) AS alias
+FROM (
+    SELECT address, alias, row_number() OVER (PARTITION BY lower(alias) ORDER BY address) AS n
+    FROM accounts
+) ranked
+WHERE n > 1;
+
+UPDATE alias_renames r
+SET alias = r.old_alias || '-' || lower(substr(r.address, 3))
+WHERE EXISTS (
+    SELECT 1 FROM accounts a
+    WHERE lower(a.alias) = lower(r.alias) AND a.address NOT IN (SELECT address FROM alias_renames)
+) OR EXISTS (
+    SELECT 1 FROM alias_renames o
+    WHERE lower(o.alias) = lower(r.alias) AND o.address <> r.address
+);
+
+UPDATE accounts a
+SET alias = r.alias
+FROM alias_renames r
+WHERE r.address = a.address;
+
+DO $$
+DECLARE
+    conflicts text;
+BEGIN
+    SELECT string_agg(a.address || ' (' || a.alias || ')', ', ' ORDER BY lower(a.alias), a.address)
+    INTO conflicts
+    FROM accounts a
+    WHERE EXISTS (
+        SELECT 1 FROM accounts b
+        WHERE lower(b.alias) = lower(a.alias) AND b.address <> a.address
+    );
+    IF conflicts IS NOT NULL THEN
+        RAISE EXCEPTION 'account aliases are not unique: %; rename these accounts and migrate again', conflicts;
+    END IF;
+END $$;
+
+CREATE UNIQUE INDEX accounts_alias_key ON accounts (lower(alias));
+
+ALTER TABLE accounts
+    ADD CONSTRAINT valid_alias CHECK (alias <> '' AND alias !~* '^(0x)?[0-9a-f]{40}$');
@@ -0,0 +1,5 @@
+ALTER TABLE accounts
+    DROP COLUMN note,
+    DROP COLUMN tags,
+    DROP COLUMN last_used_at,
+    DROP COLUMN created_at;
@@ -0,0 +1,7 @@
+-- Account metadata. created_at stays NULL for accounts stored before it was recorded.
+-- tags holds normalized tags joined with commas.
+ALTER TABLE accounts
+    ADD COLUMN created_at TIMESTAMPTZ,
+    ADD COLUMN last_used_at TIMESTAMPTZ,
+    ADD COLUMN tags TEXT NOT NULL DEFAULT '',
+    ADD COLUMN note TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE transfers
+    DROP COLUMN leg,
+    DROP COLUMN plan_id;
@@ -0,0 +1,3 @@
+-- The baseline cannot be reverted: it adopts accounts and seeds tables that may predate
+-- migrations and hold every stored key, so dropping them would destroy the keys. Rollback
+-- refuses this version and never runs this script.
@@ -0,0 +1,31 @@
+-- Baseline schema, with the same constraints as PostgreSQL. IF NOT EXISTS lets databases
+-- created before versioned migrations adopt it in place.
+
+CREATE TABLE IF NOT EXISTS seeds (
+    fingerprint TEXT PRIMARY KEY,
+    encrypted_seed TEXT NOT NULL,
+    salt TEXT NOT NULL,
+    key_version INTEGER NOT NULL,
+    kdf_time INTEGER NOT NULL,
+    kdf_memory INTEGER NOT NULL,
+    kdf_threads INTEGER NOT NULL,
+    CONSTRAINT valid_hex_encrypted_seed CHECK (encrypted_seed <> '' AND encrypted_seed NOT GLOB '*[^0-9a-fA-F]*'),
+    CONSTRAINT valid_hex_seed_salt CHECK (salt <> '' AND salt NOT GLOB '*[^0-9a-fA-F]*')
+);
+
+CREATE TABLE IF NOT EXISTS accounts (
+    address TEXT PRIMARY KEY,
+    alias TEXT NOT NULL,
+    encrypted_key TEXT NOT NULL,
+    salt TEXT NOT NULL,
+    key_version INTEGER NOT NULL,
+    kdf_time INTEGER NOT NULL,
+    kdf_memory INTEGER NOT NULL,
+    kdf_threads INTEGER NOT NULL,
+    seed_fingerprint TEXT REFERENCES seeds (fingerprint),
+    derivation_path TEXT NOT NULL DEFAULT '',
+    CONSTRAINT valid_hex_encrypted_key CHECK (encrypted_key <> '' AND encrypted_key NOT GLOB '*[^0-9a-fA-F]*'),
+    CONSTRAINT valid_hex_salt CHECK (salt <> '' AND salt NOT GLOB '*[^0-9a-fA-F]*'),
+    CONSTRAINT valid_key_version CHECK (key_version >= 1),
+    CONSTRAINT valid_kdf_params CHECK (kdf_time >= 1 AND kdf_memory >= 8 AND kdf_threads >= 1)
+);
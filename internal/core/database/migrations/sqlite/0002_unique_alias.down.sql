@@ -0,0 +1,4 @@
+-- Renamed aliases are kept.
+DROP TRIGGER accounts_valid_alias_update;
+DROP TRIGGER accounts_valid_alias_insert;
+DROP INDEX accounts_alias_key;
@@ -0,0 +1,76 @@
+-- Aliases are unique ignoring case and may not look like an address, so that an identifier
+-- selects at most one account. Existing rows are renamed as in the PostgreSQL migration, which
+-- fails the same way if aliases still collide.
+-- SQLite cannot add a CHECK constraint to an existing table, so valid_alias is enforced by
+-- triggers instead.
+
+UPDATE accounts
+SET alias = 'account-' || lower(substr(address, 3, 8))
+WHERE alias = ''
+    OR (length(alias) = 42 AND lower(substr(alias, 1, 2)) = '0x' AND substr(alias, 3) NOT GLOB '*[^0-9a-fA-F]*')
+    OR (length(alias) = 40 AND alias NOT GLOB '*[^0-9a-fA-F]*');
+
+CREATE TEMP TABLE alias_renames AS
+SELECT address, alias AS old_alias, alias || '-' || lower(substr(address, 3, 8)) AS alias
+FROM (
+    SELECT address, alias, row_number() OVER (PARTITION BY lower(alias) ORDER BY address) AS n
+    FROM accounts
+)
+WHERE n > 1;
+
+UPDATE alias_renames
+SET alias = old_alias || '-' || lower(substr(address, 3))
+WHERE EXISTS (
+    SELECT 1 FROM accounts a
+    WHERE lower(a.alias) = lower(alias_renames.alias) AND a.address NOT IN (SELECT address FROM alias_renames)
+) OR EXISTS (
+    SELECT 1 FROM alias_renames o
+    WHERE lower(o.alias) = lower(alias_renames.alias) AND o.address <> alias_renames.address
+);
+
+UPDATE accounts
+SET alias = r.alias
+FROM alias_renames r
+WHERE r.address = accounts.address;
+
+-- SQLite has no procedural blocks; inserting the conflict list into a table whose trigger raises
+-- fails the migration with that list.
+CREATE TEMP TABLE alias_conflicts (accounts TEXT NOT NULL);
+
+CREATE TEMP TRIGGER alias_conflicts_abort BEFORE INSERT ON alias_conflicts
+BEGIN
+    SELECT RAISE(ABORT, 'account aliases are not unique: ' || NEW.accounts || '; rename these accounts and migrate again');
+END;
+
+INSERT INTO alias_conflicts
+SELECT group_concat(address || ' (' || alias || ')', ', ')
+FROM (
+    SELECT address, alias FROM accounts a
+    WHERE EXISTS (
+        SELECT 1 FROM accounts b
+        WHERE lower(b.alias) = lower(a.alias) AND b.address <> a.address
+    )
+    ORDER BY lower(alias), address
+)
+HAVING count(*) > 0;
+
+DROP TABLE alias_conflicts;
+DROP TABLE alias_renames;
+
+CREATE UNIQUE INDEX accounts_alias_key ON accounts (lower(alias));
+
+CREATE TRIGGER accounts_valid_alias_insert BEFORE INSERT ON accounts
+WHEN NEW.alias = ''
+    OR (length(NEW.alias) = 42 AND lower(substr(NEW.alias, 1, 2)) = '0x' AND substr(NEW.alias, 3) NOT GLOB '*[^0-9a-fA-F]*')
+    OR (length(NEW.alias) = 40 AND NEW.alias NOT GLOB '*[^0-9a-fA-F]*')
+BEGIN
+    SELECT RAISE(ABORT, 'CHECK constraint failed: valid_alias');
+END;
+
+CREATE TRIGGER accounts_valid_alias_update BEFORE UPDATE OF alias ON accounts
+WHEN NEW.alias = ''
+    OR (length(NEW.alias) = 42 AND lower(substr(NEW.alias, 1, 2)) = '0x' AND substr(NEW.alias, 3) NOT GLOB '*[^0-9a-fA-F]*')
+    OR (length(NEW.alias) = 40 AND NEW.alias NOT GLOB '*[^0-9a-fA-F]*')
+BEGIN
+    SELECT RAISE(ABORT, 'CHECK constraint failed: valid_alias');
+END;
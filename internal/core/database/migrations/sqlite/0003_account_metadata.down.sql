@@ -0,0 +1,4 @@
+ALTER TABLE accounts DROP COLUMN note;
+ALTER TABLE accounts DROP COLUMN tags;
+ALTER TABLE accounts DROP COLUMN last_used_at;
+ALTER TABLE accounts DROP COLUMN created_at;
@@ -0,0 +1,6 @@
+-- Account metadata. created_at stays NULL for accounts stored before it was recorded.
+-- tags holds normalized tags joined with commas.
+ALTER TABLE accounts ADD COLUMN created_at TIMESTAMP;
+ALTER TABLE accounts ADD COLUMN last_used_at TIMESTAMP;
+ALTER TABLE accounts ADD COLUMN tags TEXT NOT NULL DEFAULT '';
+ALTER TABLE accounts ADD COLUMN note TEXT NOT NULL DEFAULT '';
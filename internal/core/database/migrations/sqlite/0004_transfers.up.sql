@@ -0,0 +1,25 @@
+-- Bridge transfers sent from stored accounts. Amounts are decimal strings in base units, since
+-- token amounts overflow 64-bit integers. tx_hashes holds the source chain transactions joined with
+-- commas; data holds adapter-specific values as a JSON object. account is not a foreign key so the
+-- history outlives removed accounts.
+CREATE TABLE transfers (
+    id TEXT PRIMARY KEY,
+    account TEXT NOT NULL,
+    bridge TEXT NOT NULL,
+    token TEXT NOT NULL,
+    from_chain TEXT NOT NULL,
+    to_chain TEXT NOT NULL,
+    amount TEXT NOT NULL,
+    min_amount_out TEXT NOT NULL,
+    recipient TEXT NOT NULL,
+    tx_hashes TEXT NOT NULL DEFAULT '',
+    destination_tx_hash TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL,
+    data TEXT NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    CONSTRAINT valid_amount CHECK (amount <> '' AND amount NOT GLOB '*[^0-9]*'),
+    CONSTRAINT valid_min_amount_out CHECK (min_amount_out <> '' AND min_amount_out NOT GLOB '*[^0-9]*')
+);
+
+CREATE INDEX transfers_account_created_at ON transfers (account, created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE transfers DROP COLUMN leg;
+ALTER TABLE transfers DROP COLUMN plan_id;
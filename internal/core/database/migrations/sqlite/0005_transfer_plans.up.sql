@@ -0,0 +1,4 @@
+-- Multi-leg transfers. The legs of one plan share plan_id and are numbered from 1 in leg; transfers
+-- sent directly have an empty plan_id and leg 0.
+ALTER TABLE transfers ADD COLUMN plan_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE transfers ADD COLUMN leg INTEGER NOT NULL DEFAULT 0;
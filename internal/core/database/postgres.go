@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// postgresMigrationLock is the pg_advisory_lock key held while migrating, so that concurrent
+// syncora processes do not apply the same migration twice.
+const postgresMigrationLock = 0x73796e636f7261 // "syncora"
+
+// PostgresStore stores accounts in PostgreSQL.
+type PostgresStore struct {
+	sqlStore
+}
+
+// NewPostgresStore connects to PostgreSQL and checks that the schema is up to date, applying
+// pending migrations first if autoMigrate is set.
+func NewPostgresStore(ctx context.Context, connStr string, autoMigrate bool) (*PostgresStore, error) {
+	s, err := connectPostgres(ctx, connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	if err := s.checkSchema(ctx, autoMigrate); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	// Enable audit logging
+	_, err = s.db.ExecContext(ctx, `CREATE EXTENSION IF NOT EXISTS pgaudit`)
+	if err != nil {
+		logger.Warn("failed to enable pgaudit", "err", err)
+	}
+
+	logger.Debug("database initialized", "backend", BackendPostgres)
+	return s, nil
+}
+
+// connectPostgres opens and pings a PostgreSQL connection pool without touching the schema.
+func connectPostgres(ctx context.Context, connStr string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to open database: %v", ErrUnavailable, err)
+	}
+
+	// Configure connection pool
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(time.Hour)
+
+	// Test connection
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w: failed to ping database: %v", ErrUnavailable, err)
+	}
+
+	return &PostgresStore{sqlStore{db: db, dialect: dialect{name: BackendPostgres, lock: postgresLock, uniqueViolation: postgresUniqueViolation}}}, nil
+}
+
+// postgresLock takes a session-level advisory lock on conn and returns its release.
+func postgresLock(ctx context.Context, conn *sql.Conn) (func(), error) {
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, postgresMigrationLock); err != nil {
+		return nil, err
+	}
+	return func() {
+		// Use a fresh context so the lock is released even if ctx has expired.
+		ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+		defer cancel()
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, postgresMigrationLock); err != nil {
+			logger.Warn("failed to release migration lock", "err", err)
+		}
+	}, nil
+}
+
+// postgresUniqueViolation reports whether err is a unique_violation (SQLSTATE 23505).
+func postgresUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,447 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xilverfang/syncora/internal/core/logging"
+)
+
+// sqlStore implements AccountStore on a database/sql connection. The queries are shared by
+// PostgreSQL and SQLite, which both accept $N placeholders and ON CONFLICT upserts.
+type sqlStore struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// Close closes the database connection.
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
+
+// accountColumns lists the accounts columns in the order scanned by scanAccount.
+const accountColumns = `address, alias, encrypted_key, salt, key_version, kdf_time, kdf_memory, kdf_threads,
+	COALESCE(seed_fingerprint, ''), derivation_path, created_at, last_used_at, tags, note`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanAccount reads a row selected with accountColumns.
+func scanAccount(row rowScanner, acc *Account) error {
+	var createdAt, lastUsedAt sql.NullTime
+	var tags string
+	err := row.Scan(&acc.Address, &acc.Alias, &acc.EncryptedKey, &acc.Salt, &acc.KeyVersion, &acc.KDFTime, &acc.KDFMemory, &acc.KDFThreads,
+		&acc.SeedFingerprint, &acc.DerivationPath, &createdAt, &lastUsedAt, &tags, &acc.Note)
+	if err != nil {
+		return err
+	}
+	acc.CreatedAt = createdAt.Time
+	acc.LastUsedAt = lastUsedAt.Time
+	acc.Tags = splitTags(tags)
+	return nil
+}
+
+// joinTags and splitTags convert between normalized tags and the comma-separated tags column.
+func joinTags(tags []string) string {
+	return strings.Join(tags, ",")
+}
+
+func splitTags(tags string) []string {
+	if tags == "" {
+		return nil
+	}
+	return strings.Split(tags, ",")
+}
+
+// nullTime maps the zero time to NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t.UTC(), Valid: !t.IsZero()}
+}
+
+// execer is implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// SaveAccount stores an account with its encrypted private key, salt, key version, and KDF parameters.
+func (s *sqlStore) SaveAccount(ctx context.Context, acc Account) error {
+	logger.Debug("saving account", logging.Address(acc.Address))
+	if err := validateAccount(acc); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	if err := s.upsertAccount(ctx, s.db, acc); err != nil {
+		return err
+	}
+
+	logger.Info("account saved", "alias", acc.Alias, logging.Address(acc.Address))
+	return nil
+}
+
+// SaveSeedAccounts stores a seed and the accounts derived from it in a single transaction.
+func (s *sqlStore) SaveSeedAccounts(ctx context.Context, seed Seed, accounts []Account) error {
+	logger.Debug("saving seed accounts", "count", len(accounts))
+	if err := validateSeedAccounts(seed, accounts); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO seeds (fingerprint, encrypted_seed, salt, key_version, kdf_time, kdf_memory, kdf_threads)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (fingerprint) DO UPDATE
+		SET encrypted_seed = $2, salt = $3, key_version = $4,
+			kdf_time = $5, kdf_memory = $6, kdf_threads = $7
+	`, seed.Fingerprint, seed.EncryptedSeed, seed.Salt, seed.KeyVersion, seed.KDFTime, seed.KDFMemory, seed.KDFThreads)
+	if err != nil {
+		return fmt.Errorf("failed to save seed: %v", err)
+	}
+
+	for _, acc := range accounts {
+		if err := s.upsertAccount(ctx, tx, acc); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	logger.Info("seed and derived accounts saved", "count", len(accounts))
+	return nil
+}
+
+// upsertAccount inserts an account or replaces the stored one with the same address.
+func (s *sqlStore) upsertAccount(ctx context.Context, ex execer, acc Account) error {
+	var seedFingerprint sql.NullString
+	if acc.SeedFingerprint != "" {
+		seedFingerprint = sql.NullString{String: acc.SeedFingerprint, Valid: true}
+	}
+
+	createdAt := acc.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	// Metadata columns are only written for new accounts.
+	_, err := ex.ExecContext(ctx, `
+		INSERT INTO accounts (address, alias, encrypted_key, salt, key_version, kdf_time, kdf_memory, kdf_threads,
+			seed_fingerprint, derivation_path, created_at, last_used_at, tags, note)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (address) DO UPDATE
+		SET alias = $2, encrypted_key = $3, salt = $4, key_version = $5,
+			kdf_time = $6, kdf_memory = $7, kdf_threads = $8,
+			seed_fingerprint = $9, derivation_path = $10
+	`, acc.Address, acc.Alias, acc.EncryptedKey, acc.Salt, acc.KeyVersion, acc.KDFTime, acc.KDFMemory, acc.KDFThreads,
+		seedFingerprint, acc.DerivationPath, nullTime(createdAt), nullTime(acc.LastUsedAt), joinTags(acc.Tags), acc.Note)
+	if s.dialect.uniqueViolation(err) {
+		return fmt.Errorf("%w: %s", ErrAliasInUse, acc.Alias)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save account: %v", err)
+	}
+	return nil
+}
+
+// UpdateAccountKey replaces an account's encrypted key, salt, key version, and KDF parameters.
+// The update only applies if the stored ciphertext still equals previousEncryptedKey, so a
+// concurrent change is reported instead of being overwritten.
+func (s *sqlStore) UpdateAccountKey(ctx context.Context, acc Account, previousEncryptedKey string) error {
+	logger.Debug("updating account key", logging.Address(acc.Address))
+	if err := validateAccount(acc); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE accounts
+		SET encrypted_key = $2, salt = $3, key_version = $4,
+			kdf_time = $5, kdf_memory = $6, kdf_threads = $7
+		WHERE address = $1 AND encrypted_key = $8
+	`, acc.Address, acc.EncryptedKey, acc.Salt, acc.KeyVersion, acc.KDFTime, acc.KDFMemory, acc.KDFThreads, previousEncryptedKey)
+	if err != nil {
+		return fmt.Errorf("failed to update account key: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: %s", ErrConflict, acc.Address)
+	}
+
+	logger.Info("account key updated", logging.Address(acc.Address))
+	return nil
+}
+
+// ListAccounts retrieves all stored accounts.
+func (s *sqlStore) ListAccounts(ctx context.Context) ([]Account, error) {
+	logger.Debug("listing accounts")
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+accountColumns+` FROM accounts`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accounts: %v", err)
+	}
+	defer rows.Close()
+
+	var accounts []Account
+	for rows.Next() {
+		var acc Account
+		if err := scanAccount(rows, &acc); err != nil {
+			return nil, fmt.Errorf("failed to scan account: %v", err)
+		}
+		accounts = append(accounts, acc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating accounts: %v", err)
+	}
+
+	logger.Debug("listed accounts", "count", len(accounts))
+	return accounts, nil
+}
+
+// GetAccount returns an account by address, or by alias ignoring case.
+func (s *sqlStore) GetAccount(ctx context.Context, identifier string) (*Account, error) {
+	logger.Debug("getting account")
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	where := accountLookup(identifier)
+	var acc Account
+	err := scanAccount(s.db.QueryRowContext(ctx, `SELECT `+accountColumns+` FROM accounts WHERE `+where, identifier), &acc)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, identifier)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %v", err)
+	}
+
+	logger.Debug("account retrieved", logging.Address(acc.Address))
+	return &acc, nil
+}
+
+// RemoveAccount deletes an account by address, or by alias ignoring case.
+func (s *sqlStore) RemoveAccount(ctx context.Context, identifier string) error {
+	logger.Debug("removing account")
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	where := accountLookup(identifier)
+	result, err := s.db.ExecContext(ctx, `DELETE FROM accounts WHERE `+where, identifier)
+	if err != nil {
+		return fmt.Errorf("failed to delete account: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, identifier)
+	}
+
+	logger.Info("account deleted")
+	return nil
+}
+
+// RenameAccount changes the alias of the account selected by identifier.
+func (s *sqlStore) RenameAccount(ctx context.Context, identifier, alias string) error {
+	logger.Debug("renaming account", "alias", alias)
+	if err := ValidateAlias(alias); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	where := accountLookup(identifier)
+	result, err := s.db.ExecContext(ctx, `UPDATE accounts SET alias = $2 WHERE `+where, identifier, alias)
+	if s.dialect.uniqueViolation(err) {
+		return fmt.Errorf("%w: %s", ErrAliasInUse, alias)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rename account: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, identifier)
+	}
+
+	logger.Info("account renamed", "alias", alias)
+	return nil
+}
+
+// SetAccountMetadata replaces the tags and note of the account selected by identifier.
+func (s *sqlStore) SetAccountMetadata(ctx context.Context, identifier string, tags []string, note string) error {
+	logger.Debug("updating account metadata")
+	if err := validateMetadata(tags, note); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	where := accountLookup(identifier)
+	result, err := s.db.ExecContext(ctx, `UPDATE accounts SET tags = $2, note = $3 WHERE `+where, identifier, joinTags(tags), note)
+	if err != nil {
+		return fmt.Errorf("failed to update account metadata: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, identifier)
+	}
+
+	logger.Info("account metadata updated")
+	return nil
+}
+
+// MarkAccountUsed sets an account's last_used_at.
+func (s *sqlStore) MarkAccountUsed(ctx context.Context, address string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `UPDATE accounts SET last_used_at = $2 WHERE address = $1`, address, nullTime(at))
+	if err != nil {
+		return fmt.Errorf("failed to mark account used: %v", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, address)
+	}
+	return nil
+}
+
+// transferColumns lists the transfers columns in the order scanned by scanTransfer.
+const transferColumns = `id, account, bridge, token, from_chain, to_chain, amount, min_amount_out, recipient,
+	tx_hashes, destination_tx_hash, status, plan_id, leg, data, created_at, updated_at`
+
+// scanTransfer reads a row selected with transferColumns.
+func scanTransfer(row rowScanner, t *Transfer) error {
+	var txHashes, data string
+	err := row.Scan(&t.ID, &t.Account, &t.Bridge, &t.Token, &t.FromChain, &t.ToChain, &t.Amount, &t.MinAmountOut, &t.Recipient,
+		&txHashes, &t.DestinationTxHash, &t.Status, &t.PlanID, &t.Leg, &data, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	t.TxHashes = splitTags(txHashes)
+	if err := json.Unmarshal([]byte(data), &t.Data); err != nil {
+		return fmt.Errorf("invalid data of transfer %s: %v", t.ID, err)
+	}
+	return nil
+}
+
+// SaveTransfer inserts a transfer or replaces the stored one with the same ID, keeping its
+// created_at.
+func (s *sqlStore) SaveTransfer(ctx context.Context, t Transfer) error {
+	logger.Debug("saving transfer", "id", t.ID, "status", t.Status)
+	if err := validateTransfer(t); err != nil {
+		return err
+	}
+	if t.Data == nil {
+		t.Data = map[string]string{}
+	}
+	data, err := json.Marshal(t.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode transfer data: %v", err)
+	}
+	now := time.Now().UTC()
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = now
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO transfers (id, account, bridge, token, from_chain, to_chain, amount, min_amount_out, recipient,
+			tx_hashes, destination_tx_hash, status, plan_id, leg, data, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		ON CONFLICT (id) DO UPDATE
+		SET account = $2, bridge = $3, token = $4, from_chain = $5, to_chain = $6, amount = $7,
+			min_amount_out = $8, recipient = $9, tx_hashes = $10, destination_tx_hash = $11,
+			status = $12, plan_id = $13, leg = $14, data = $15, updated_at = $17
+	`, t.ID, t.Account, t.Bridge, t.Token, t.FromChain, t.ToChain, t.Amount, t.MinAmountOut, t.Recipient,
+		joinTags(t.TxHashes), t.DestinationTxHash, t.Status, t.PlanID, t.Leg, string(data), t.CreatedAt.UTC(), now)
+	if err != nil {
+		return fmt.Errorf("failed to save transfer: %v", err)
+	}
+
+	logger.Info("transfer saved", "id", t.ID, "status", t.Status)
+	return nil
+}
+
+// GetTransfer returns a transfer by ID.
+func (s *sqlStore) GetTransfer(ctx context.Context, id string) (*Transfer, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var t Transfer
+	err := scanTransfer(s.db.QueryRowContext(ctx, `SELECT `+transferColumns+` FROM transfers WHERE id = $1`, id), &t)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrTransferNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transfer: %v", err)
+	}
+	return &t, nil
+}
+
+// ListTransfers returns the transfers sent from account, or all transfers, most recent first.
+func (s *sqlStore) ListTransfers(ctx context.Context, account string) ([]Transfer, error) {
+	logger.Debug("listing transfers")
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+transferColumns+` FROM transfers
+		WHERE $1 = '' OR lower(account) = lower($1) ORDER BY created_at DESC, id`, account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transfers: %v", err)
+	}
+	defer rows.Close()
+
+	var transfers []Transfer
+	for rows.Next() {
+		var t Transfer
+		if err := scanTransfer(rows, &t); err != nil {
+			return nil, fmt.Errorf("failed to scan transfer: %v", err)
+		}
+		transfers = append(transfers, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transfers: %v", err)
+	}
+	return transfers, nil
+}
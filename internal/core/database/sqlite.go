@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQLiteStore stores accounts in an embedded SQLite database file, for use without a database server.
+type SQLiteStore struct {
+	sqlStore
+}
+
+// NewSQLiteStore opens or creates the SQLite database at path with 0600 permissions and checks
+// that the schema is up to date, applying pending migrations first if autoMigrate is set.
+func NewSQLiteStore(ctx context.Context, path string, autoMigrate bool) (*SQLiteStore, error) {
+	s, err := connectSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	if err := s.checkSchema(ctx, autoMigrate); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	logger.Debug("database initialized", "backend", BackendSQLite)
+	return s, nil
+}
+
+// connectSQLite opens or creates the database file without touching the schema.
+func connectSQLite(path string) (*SQLiteStore, error) {
+	if err := createPrivateFile(path); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	dsn := (&url.URL{
+		Scheme:   "file",
+		Path:     path,
+		RawQuery: "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_txlock=immediate",
+	}).String()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to open database: %v", ErrUnavailable, err)
+	}
+	// SQLite allows a single writer; serialize access through one connection.
+	db.SetMaxOpenConns(1)
+
+	return &SQLiteStore{sqlStore{db: db, dialect: dialect{name: BackendSQLite, lock: sqliteLock, uniqueViolation: sqliteUniqueViolation}}}, nil
+}
+
+// sqliteLock is a no-op: the single connection and immediate transactions already serialize
+// migrations, and each migration re-checks schema_migrations inside its transaction.
+func sqliteLock(ctx context.Context, conn *sql.Conn) (func(), error) {
+	return func() {}, nil
+}
+
+// sqliteUniqueViolation reports whether err is a SQLITE_CONSTRAINT_UNIQUE error.
+func sqliteUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+// createPrivateFile creates path with 0600 permissions, and its directory with 0700, if they do not exist.
+func createPrivateFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %v", path, err)
+	}
+	f, err := os.OpenFile(path, os.O_RDONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	f.Close()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %v", path, err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("%s permissions too open: %s, expected 0600", path, info.Mode().Perm())
+	}
+	return nil
+}
@@ -0,0 +1,421 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xilverfang/syncora/internal/core/crypto"
+)
+
+const (
+	testAddress1 = "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+	testAddress2 = "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"
+	testSeed     = "0x3Fd2F9aC5e0b0C1F0EeF7E0aA3f6A4bE1d53C1a4"
+	testAddress3 = "0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC"
+)
+
+func testAccount(address, alias string) Account {
+	return Account{
+		Alias:        alias,
+		Address:      address,
+		EncryptedKey: "aabbcc",
+		Salt:         "0011",
+		KeyVersion:   2,
+		KDFTime:      1,
+		KDFMemory:    8192,
+		KDFThreads:   1,
+		CreatedAt:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func TestStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) AccountStore{
+		"sqlite": func(t *testing.T) AccountStore {
+			s, err := NewSQLiteStore(context.Background(), filepath.Join(t.TempDir(), "syncora.db"), true)
+			if err != nil {
+				t.Fatalf("NewSQLiteStore: %v", err)
+			}
+			return s
+		},
+		"file": func(t *testing.T) AccountStore {
+			s, err := NewFileStore(filepath.Join(t.TempDir(), "accounts"), vaultPassphrase("vault pass"), crypto.KDFTest)
+			if err != nil {
+				t.Fatalf("NewFileStore: %v", err)
+			}
+			return s
+		},
+	}
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			testStore(t, open(t))
+		})
+		t.Run(name+"/transfers", func(t *testing.T) {
+			testTransfers(t, open(t))
+		})
+	}
+}
+
+func testTransfers(t *testing.T, s AccountStore) {
+	ctx := context.Background()
+	defer s.Close()
+
+	if _, err := s.GetTransfer(ctx, "missing"); !errors.Is(err, ErrTransferNotFound) {
+		t.Fatalf("expected ErrTransferNotFound, got %v", err)
+	}
+
+	first := Transfer{
+		ID: "a1", Account: testAddress1, Bridge: "base-bridge", Token: "ETH", FromChain: "ethereum", ToChain: "base",
+		Amount: "1500000000000000000", MinAmountOut: "1500000000000000000", Recipient: testAddress2,
+		Status: "pending", CreatedAt: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if err := s.SaveTransfer(ctx, first); err != nil {
+		t.Fatalf("SaveTransfer: %v", err)
+	}
+	bad := first
+	bad.Amount = "1.5"
+	if err := s.SaveTransfer(ctx, bad); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid for a decimal amount, got %v", err)
+	}
+
+	// Updates keep CreatedAt.
+	hash := "0x" + strings.Repeat("ab", 32)
+	first.TxHashes = []string{hash}
+	first.Status = "in_flight"
+	first.Data = map[string]string{"l2_tx_hash": hash}
+	first.CreatedAt = time.Time{}
+	if err := s.SaveTransfer(ctx, first); err != nil {
+		t.Fatalf("SaveTransfer update: %v", err)
+	}
+	got, err := s.GetTransfer(ctx, "a1")
+	if err != nil || got.Status != "in_flight" || !reflect.DeepEqual(got.TxHashes, first.TxHashes) || got.Data["l2_tx_hash"] != hash {
+		t.Fatalf("GetTransfer = %+v, %v", got, err)
+	}
+	if !got.CreatedAt.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) || got.UpdatedAt.IsZero() {
+		t.Fatalf("CreatedAt = %v, UpdatedAt = %v; want the original creation time", got.CreatedAt, got.UpdatedAt)
+	}
+
+	second := first
+	second.ID, second.Account, second.CreatedAt = "b2", testAddress2, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	second.PlanID, second.Leg = "p1", 2
+	if err := s.SaveTransfer(ctx, second); err != nil {
+		t.Fatalf("SaveTransfer: %v", err)
+	}
+	if got, err := s.GetTransfer(ctx, "b2"); err != nil || got.PlanID != "p1" || got.Leg != 2 {
+		t.Fatalf("GetTransfer(leg) = %+v, %v", got, err)
+	}
+	if all, err := s.ListTransfers(ctx, ""); err != nil || len(all) != 2 || all[0].ID != "b2" {
+		t.Fatalf("ListTransfers = %+v, %v; want the most recent first", all, err)
+	}
+	if mine, err := s.ListTransfers(ctx, strings.ToLower(testAddress1)); err != nil || len(mine) != 1 || mine[0].ID != "a1" {
+		t.Fatalf("ListTransfers(account) = %+v, %v", mine, err)
+	}
+}
+
+func testStore(t *testing.T, s AccountStore) {
+	ctx := context.Background()
+	defer s.Close()
+
+	if _, err := s.GetAccount(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	acc := testAccount(testAddress1, "hot")
+	if err := s.SaveAccount(ctx, acc); err != nil {
+		t.Fatalf("SaveAccount: %v", err)
+	}
+	bad := testAccount(testAddress2, "bad")
+	bad.EncryptedKey = "not hex"
+	if err := s.SaveAccount(ctx, bad); err == nil {
+		t.Fatal("expected error for non-hex key")
+	}
+
+	got, err := s.GetAccount(ctx, "hot")
+	if err != nil {
+		t.Fatalf("GetAccount: %v", err)
+	}
+	if !got.CreatedAt.Equal(acc.CreatedAt) {
+		t.Fatalf("CreatedAt = %v, want %v", got.CreatedAt, acc.CreatedAt)
+	}
+	got.CreatedAt = acc.CreatedAt
+	if !reflect.DeepEqual(*got, acc) {
+		t.Fatalf("GetAccount = %+v, want %+v", *got, acc)
+	}
+
+	// Metadata survives re-saving the account.
+	if err := s.SetAccountMetadata(ctx, "hot", []string{"Treasury"}, ""); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid for unnormalized tags, got %v", err)
+	}
+	if err := s.SetAccountMetadata(ctx, "hot", []string{"hot", "treasury"}, "cold storage backup"); err != nil {
+		t.Fatalf("SetAccountMetadata: %v", err)
+	}
+	usedAt := time.Date(2026, 2, 3, 4, 5, 6, 0, time.UTC)
+	if err := s.MarkAccountUsed(ctx, testAddress1, usedAt); err != nil {
+		t.Fatalf("MarkAccountUsed: %v", err)
+	}
+	if err := s.SaveAccount(ctx, acc); err != nil {
+		t.Fatalf("SaveAccount: %v", err)
+	}
+	got, err = s.GetAccount(ctx, "hot")
+	if err != nil || !reflect.DeepEqual(got.Tags, []string{"hot", "treasury"}) || got.Note != "cold storage backup" || !got.LastUsedAt.Equal(usedAt) {
+		t.Fatalf("metadata not kept: %+v, %v", got, err)
+	}
+
+	// Compare-and-swap key updates.
+	updated := acc
+	updated.EncryptedKey = "ddeeff"
+	if err := s.UpdateAccountKey(ctx, updated, "000000"); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict for stale ciphertext, got %v", err)
+	}
+	if err := s.UpdateAccountKey(ctx, updated, acc.EncryptedKey); err != nil {
+		t.Fatalf("UpdateAccountKey: %v", err)
+	}
+	if got, _ := s.GetAccount(ctx, testAddress1); got == nil || got.EncryptedKey != "ddeeff" {
+		t.Fatalf("key not updated: %+v", got)
+	}
+
+	// Derived accounts must reference a stored seed.
+	derived := testAccount(testAddress2, "derived")
+	derived.SeedFingerprint = testSeed
+	derived.DerivationPath = "m/44'/60'/0'/0/1"
+	if err := s.SaveAccount(ctx, derived); err == nil {
+		t.Fatal("expected error for account referencing a missing seed")
+	}
+	seed := Seed{Fingerprint: testSeed, EncryptedSeed: "abcdef", Salt: "0011", KeyVersion: 2, KDFTime: 1, KDFMemory: 8192, KDFThreads: 1}
+	if err := s.SaveSeedAccounts(ctx, seed, []Account{derived}); err != nil {
+		t.Fatalf("SaveSeedAccounts: %v", err)
+	}
+
+	accounts, err := s.ListAccounts(ctx)
+	if err != nil || len(accounts) != 2 {
+		t.Fatalf("ListAccounts = %v, %v", accounts, err)
+	}
+
+	// Aliases are unique ignoring case and never look like an address.
+	if err := s.SaveAccount(ctx, testAccount(testAddress2, "HOT")); !errors.Is(err, ErrAliasInUse) {
+		t.Fatalf("expected ErrAliasInUse for a duplicate alias, got %v", err)
+	}
+	if err := s.SaveAccount(ctx, testAccount(testAddress2, testAddress1)); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid for an address-like alias, got %v", err)
+	}
+	if err := s.RenameAccount(ctx, "Derived", "hot"); !errors.Is(err, ErrAliasInUse) {
+		t.Fatalf("expected ErrAliasInUse renaming to a taken alias, got %v", err)
+	}
+	if err := s.RenameAccount(ctx, strings.ToLower(testAddress2), "Cold"); err != nil {
+		t.Fatalf("RenameAccount: %v", err)
+	}
+	if got, err := s.GetAccount(ctx, "cold"); err != nil || got.Address != testAddress2 {
+		t.Fatalf("GetAccount after rename = %+v, %v", got, err)
+	}
+	if err := s.RenameAccount(ctx, "derived", "other"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound renaming a missing account, got %v", err)
+	}
+
+	if err := s.RemoveAccount(ctx, "COLD"); err != nil {
+		t.Fatalf("RemoveAccount: %v", err)
+	}
+	if err := s.RemoveAccount(ctx, "cold"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound removing a missing account, got %v", err)
+	}
+	if accounts, _ := s.ListAccounts(ctx); len(accounts) != 1 {
+		t.Fatalf("expected 1 account after removal, got %d", len(accounts))
+	}
+}
+
+func TestVaultUpgrade(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts")
+	v1 := `{"version": 1, "accounts": [
+		{"alias": "` + testAddress1 + `", "address": "` + testAddress1 + `", "encrypted_key": "aa", "salt": "00", "key_version": 2, "kdf_time": 1, "kdf_memory": 8192, "kdf_threads": 1},
+		{"alias": "Hot", "address": "` + testAddress2 + `", "encrypted_key": "aa", "salt": "00", "key_version": 2, "kdf_time": 1, "kdf_memory": 8192, "kdf_threads": 1},
+		{"alias": "hot", "address": "` + testSeed + `", "encrypted_key": "aa", "salt": "00", "key_version": 2, "kdf_time": 1, "kdf_memory": 8192, "kdf_threads": 1},
+		{"alias": "hot-70997970", "address": "` + testAddress3 + `", "encrypted_key": "aa", "salt": "00", "key_version": 2, "kdf_time": 1, "kdf_memory": 8192, "kdf_threads": 1}
+	], "seeds": []}`
+	if err := os.WriteFile(path, []byte(v1), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Plain vaults are read without a passphrase.
+	s, err := NewFileStore(path, nil, crypto.KDFTest)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	ctx := context.Background()
+	accounts, err := s.ListAccounts(ctx)
+	if err != nil {
+		t.Fatalf("ListAccounts: %v", err)
+	}
+	// Hot-70997970 is taken, so the duplicate gets the whole address as its suffix.
+	want := []string{"account-f39fd6e5", "Hot-70997970c51812dc3a010c7d01b50e0d17dc79c8", "hot", "hot-70997970"}
+	for i, acc := range accounts {
+		if acc.Alias != want[i] {
+			t.Errorf("alias %d = %s, want %s", i, acc.Alias, want[i])
+		}
+	}
+	if err := s.SetAccountMetadata(ctx, "hot", []string{"cold"}, ""); err == nil {
+		t.Fatal("wrote the vault without a passphrase to encrypt it")
+	}
+
+	// The first write encrypts the vault under a new passphrase.
+	var created []bool
+	s, err = NewFileStore(path, func(ctx context.Context, create bool) ([]byte, error) {
+		created = append(created, create)
+		return []byte("vault pass"), nil
+	}, crypto.KDFTest)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	if err := s.SetAccountMetadata(ctx, "hot", []string{"cold"}, ""); err != nil {
+		t.Fatalf("SetAccountMetadata: %v", err)
+	}
+	if !reflect.DeepEqual(created, []bool{true}) {
+		t.Errorf("passphrase requests %v, want one for a new vault", created)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hot-70997970") || !strings.Contains(string(data), `"version": 4`) {
+		t.Errorf("vault not encrypted: %s", data)
+	}
+	accounts, err = openVault(t, path, "vault pass").ListAccounts(ctx)
+	if err != nil || len(accounts) != 4 || accounts[1].Alias != want[1] {
+		t.Errorf("ListAccounts after encryption = %+v, %v", accounts, err)
+	}
+}
+
+func TestVaultUpgradeConflict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts")
+	v1 := `{"version": 1, "accounts": [
+		{"alias": "Hot", "address": "` + testAddress2 + `", "encrypted_key": "aa", "salt": "00", "key_version": 2, "kdf_time": 1, "kdf_memory": 8192, "kdf_threads": 1},
+		{"alias": "hot", "address": "` + testSeed + `", "encrypted_key": "aa", "salt": "00", "key_version": 2, "kdf_time": 1, "kdf_memory": 8192, "kdf_threads": 1},
+		{"alias": "hot-70997970", "address": "` + testAddress3 + `", "encrypted_key": "aa", "salt": "00", "key_version": 2, "kdf_time": 1, "kdf_memory": 8192, "kdf_threads": 1},
+		{"alias": "hot-70997970c51812dc3a010c7d01b50e0d17dc79c8", "address": "` + testAddress1 + `", "encrypted_key": "aa", "salt": "00", "key_version": 2, "kdf_time": 1, "kdf_memory": 8192, "kdf_threads": 1}
+	], "seeds": []}`
+	if err := os.WriteFile(path, []byte(v1), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewFileStore(path, nil, crypto.KDFTest)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	_, err = s.ListAccounts(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "account aliases are not unique: "+testAddress2+" (Hot-70997970c51812dc3a010c7d01b50e0d17dc79c8), "+testAddress1) {
+		t.Fatalf("ListAccounts = %v, want the conflicting accounts listed", err)
+	}
+}
+
+// vaultPassphrase returns a VaultPassphrase function that always returns passphrase.
+func vaultPassphrase(passphrase string) func(context.Context, bool) ([]byte, error) {
+	return func(ctx context.Context, create bool) ([]byte, error) {
+		return []byte(passphrase), nil
+	}
+}
+
+func openVault(t *testing.T, path, passphrase string) *FileStore {
+	t.Helper()
+	s, err := NewFileStore(path, vaultPassphrase(passphrase), crypto.KDFTest)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestVaultEncryption(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "accounts")
+	s := openVault(t, path, "vault pass")
+	acc := testAccount(testAddress1, "savings")
+	acc.Note = "treasury"
+	if err := s.SaveAccount(ctx, acc); err != nil {
+		t.Fatalf("SaveAccount: %v", err)
+	}
+	if err := s.SaveTransfer(ctx, Transfer{ID: "t1", Account: testAddress1, Bridge: "base-bridge", Token: "ETH",
+		FromChain: "ethereum", ToChain: "base", Amount: "1", MinAmountOut: "1", Recipient: testAddress2, Status: "pending"}); err != nil {
+		t.Fatalf("SaveTransfer: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, plain := range []string{"savings", "treasury", "aabbcc", testAddress1, testAddress2, "base-bridge"} {
+		if strings.Contains(string(data), plain) {
+			t.Errorf("vault file contains %q", plain)
+		}
+	}
+
+	// Only the first read of each store unlocks the vault.
+	var unlocks int
+	other, err := NewFileStore(path, func(ctx context.Context, create bool) ([]byte, error) {
+		unlocks++
+		if create {
+			t.Error("asked for a new passphrase for an existing vault")
+		}
+		return []byte("vault pass"), nil
+	}, crypto.KDFTest)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	if unlocks != 0 {
+		t.Fatal("NewFileStore unlocked the vault")
+	}
+	if got, err := other.GetAccount(ctx, "savings"); err != nil || got.Note != "treasury" {
+		t.Fatalf("GetAccount = %+v, %v", got, err)
+	}
+	if err := other.RenameAccount(ctx, "savings", "reserve"); err != nil {
+		t.Fatalf("RenameAccount: %v", err)
+	}
+	if transfers, err := other.ListTransfers(ctx, ""); err != nil || len(transfers) != 1 {
+		t.Fatalf("ListTransfers = %+v, %v", transfers, err)
+	}
+	if unlocks != 1 {
+		t.Errorf("vault unlocked %d times, want 1", unlocks)
+	}
+	if _, err := s.GetAccount(ctx, "reserve"); err != nil {
+		t.Errorf("the first store cannot read the other's write: %v", err)
+	}
+
+	if _, err := openVault(t, path, "wrong pass").ListAccounts(ctx); !errors.Is(err, crypto.ErrAuthentication) {
+		t.Errorf("ListAccounts with the wrong passphrase: %v", err)
+	}
+	locked, err := NewFileStore(path, nil, crypto.KDFTest)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	if _, err := locked.ListAccounts(ctx); err == nil {
+		t.Error("read an encrypted vault without a passphrase")
+	}
+
+	data[len(data)/2] ^= 1
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openVault(t, path, "vault pass").ListAccounts(ctx); err == nil {
+		t.Error("read a modified vault")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := Open(ctx, Config{Path: filepath.Join(t.TempDir(), "accounts")})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+	if _, ok := s.(*FileStore); !ok {
+		t.Fatalf("expected the vault file by default, got %T", s)
+	}
+
+	for _, cfg := range []Config{{Backend: BackendPostgres}, {Backend: "bogus"}} {
+		if _, err := Open(ctx, cfg); !errors.Is(err, ErrUnavailable) {
+			t.Errorf("Open(%+v): expected ErrUnavailable, got %v", cfg, err)
+		}
+	}
+}
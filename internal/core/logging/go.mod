@@ -0,0 +1,3 @@
+module github.com/xilverfang/syncora/internal/core/logging
+
+go 1.24.4
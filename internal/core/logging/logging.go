@@ -0,0 +1,81 @@
+// Package logging builds the structured logger shared by the CLI and the core packages and
+// provides helpers that keep addresses and secrets out of log output.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Log formats accepted by New.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Redacted replaces the value of any attribute that holds a secret.
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys whose values are always redacted, whatever the caller logs.
+var sensitiveKeys = map[string]bool{
+	"passphrase":    true,
+	"password":      true,
+	"private_key":   true,
+	"encrypted_key": true,
+	"mnemonic":      true,
+	"seed":          true,
+	"secret":        true,
+}
+
+// New returns a logger writing to w at the given level in text or JSON format. Attributes named
+// after secrets are redacted before they are written.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level: %s (expected debug, info, warn or error)", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl, ReplaceAttr: redact}
+	switch strings.ToLower(format) {
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format: %s (expected %s or %s)", format, FormatText, FormatJSON)
+	}
+}
+
+// Discard returns a logger that drops everything. It is the default in the core packages.
+func Discard() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// redact is a slog ReplaceAttr function that hides the values of sensitive attributes.
+func redact(groups []string, a slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, Redacted)
+	}
+	return a
+}
+
+// Address returns an "address" attribute with the address shortened to its first bytes, enough
+// to tell accounts apart without recording them in full.
+func Address(address string) slog.Attr {
+	return slog.String("address", RedactAddress(address))
+}
+
+// RedactAddress shortens an address to its 0x prefix and first four bytes.
+func RedactAddress(address string) string {
+	if len(address) <= 10 {
+		return address
+	}
+	return address[:10] + "..."
+}
+
+// Secret returns an attribute that records that a secret was involved without its value.
+func Secret(key string) slog.Attr {
+	return slog.String(key, Redacted)
+}
@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "info", FormatJSON)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	logger.Debug("hidden")
+	logger.Info("unlocked", Address("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"), "passphrase", "hunter22", Secret("mnemonic"))
+	if strings.Contains(buf.String(), "hidden") {
+		t.Fatalf("debug record written at info level: %s", buf.String())
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("invalid JSON log line %q: %v", buf.String(), err)
+	}
+	want := map[string]string{"msg": "unlocked", "address": "0xf39Fd6e5...", "passphrase": Redacted, "mnemonic": Redacted}
+	for k, v := range want {
+		if record[k] != v {
+			t.Errorf("%s = %v, want %s", k, record[k], v)
+		}
+	}
+
+	if _, err := New(&buf, "loud", FormatText); err == nil {
+		t.Error("expected error for unknown level")
+	}
+	if _, err := New(&buf, "info", "xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -0,0 +1,12 @@
+module github.com/xilverfang/syncora/internal/core/registry
+
+go 1.24.4
+
+replace github.com/xilverfang/syncora/internal/core/amount => ../amount
+
+replace github.com/xilverfang/syncora/shared => ../../../shared
+
+require (
+	github.com/xilverfang/syncora/internal/core/amount v0.0.0-00010101000000-000000000000
+	github.com/xilverfang/syncora/shared v0.0.0-00010101000000-000000000000
+)
@@ -0,0 +1,537 @@
+// Package registry loads the chains, tokens and bridge routes that Syncora knows about from
+// chains.json, tokens.json and bridges.json, by default the copies embedded from shared/config,
+// and validates them against the JSON Schemas in shared/schemas.
+package registry
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"math/big"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xilverfang/syncora/internal/core/amount"
+	"github.com/xilverfang/syncora/shared"
+)
+
+// Registry files, relative to the directory they are loaded from.
+const (
+	ChainsFile  = "chains.json"
+	TokensFile  = "tokens.json"
+	BridgesFile = "bridges.json"
+)
+
+// ErrNotFound is returned when a chain, token or bridge is not in the registry.
+var ErrNotFound = errors.New("not in the registry")
+
+// Chain is an EVM network that tokens can be bridged to and from.
+type Chain struct {
+	Name           string   `json:"name"`
+	DisplayName    string   `json:"display_name"`
+	Aliases        []string `json:"aliases"`
+	ChainID        uint64   `json:"chain_id"`
+	NativeCurrency Currency `json:"native_currency"`
+	Explorer       string   `json:"explorer"`
+	RPC            []string `json:"rpc"`
+}
+
+// Currency is a chain's native currency.
+type Currency struct {
+	Symbol   string `json:"symbol"`
+	Decimals uint8  `json:"decimals"`
+}
+
+// Token is an asset that exists on one or more chains.
+type Token struct {
+	Symbol      string                `json:"symbol"`
+	Name        string                `json:"name"`
+	Decimals    uint8                 `json:"decimals"`
+	Deployments map[string]Deployment `json:"deployments"` // by chain name
+	PriceFeed   *PriceFeed            `json:"price_feed,omitempty"`
+}
+
+// Deployment is a token on one chain: either the chain's native currency or an ERC-20 contract.
+type Deployment struct {
+	Address  string `json:"address,omitempty"`
+	Native   bool   `json:"native,omitempty"`
+	Decimals uint8  `json:"decimals,omitempty"` // only when it differs from the token's
+}
+
+// PriceFeed is a Chainlink aggregator reporting a token's price in USD.
+type PriceFeed struct {
+	Chain   string `json:"chain"`
+	Address string `json:"address"`
+}
+
+// On returns the token's deployment on chain, with Decimals filled in.
+func (t *Token) On(chain string) (Deployment, bool) {
+	d, ok := t.Deployments[chain]
+	if ok && d.Decimals == 0 {
+		d.Decimals = t.Decimals
+	}
+	return d, ok
+}
+
+// ParseAmount converts an amount typed by a user, such as "1.5", "1.5e3" or "max", to base units
+// of the token on chain, using the deployment's decimals.
+func (t *Token) ParseAmount(chain, s string) (amount.Amount, error) {
+	d, ok := t.On(chain)
+	if !ok {
+		return amount.Amount{}, fmt.Errorf("token %s on %s: %w", t.Symbol, chain, ErrNotFound)
+	}
+	a, err := amount.Parse(s, d.Decimals)
+	if err != nil {
+		return amount.Amount{}, fmt.Errorf("%s: %w", t.Symbol, err)
+	}
+	return a, nil
+}
+
+// FormatAmount writes v base units of the token on chain as an exact decimal string with the
+// symbol, e.g. "1.5 USDC".
+func (t *Token) FormatAmount(chain string, v *big.Int) string {
+	d, _ := t.On(chain)
+	return amount.Format(v, cmp.Or(d.Decimals, t.Decimals)) + " " + t.Symbol
+}
+
+// Bridge is a bridge service and the routes it supports.
+type Bridge struct {
+	Name        string       `json:"name"`
+	DisplayName string       `json:"display_name"`
+	Website     string       `json:"website"`
+	Routes      []RouteGroup `json:"routes"`
+
+	// Contracts holds the addresses of the contracts the bridge's adapter calls, by chain name and
+	// then contract name, e.g. "standard_bridge".
+	Contracts map[string]map[string]string `json:"contracts,omitempty"`
+}
+
+// Contract returns the address of a contract of the bridge on chain.
+func (b *Bridge) Contract(chain, name string) (string, bool) {
+	address, ok := b.Contracts[chain][name]
+	return address, ok
+}
+
+// RouteGroup describes routes compactly: every token from every chain in From to every other
+// chain in To, for the tokens deployed on both.
+type RouteGroup struct {
+	From       []string `json:"from"`
+	To         []string `json:"to"`
+	Tokens     []string `json:"tokens"`
+	FeeBPS     uint32   `json:"fee_bps"`     // indicative fee in basis points of the amount
+	ETASeconds uint32   `json:"eta_seconds"` // indicative time until funds arrive
+}
+
+// BridgeRoute is a single token transfer from one chain to another through a bridge. Fee and ETA
+// are indicative; bridge adapters quote the actual values.
+type BridgeRoute struct {
+	Bridge string        `json:"bridge"`
+	Token  string        `json:"token"`
+	From   string        `json:"from"`
+	To     string        `json:"to"`
+	FeeBPS uint32        `json:"fee_bps"`
+	ETA    time.Duration `json:"-"`
+}
+
+// RouteFilter selects routes. Empty fields match everything.
+type RouteFilter struct {
+	Bridge string
+	Token  string
+	From   string
+	To     string
+}
+
+// Registry holds the loaded chains, tokens and bridges and the routes expanded from them.
+type Registry struct {
+	files   []string
+	chains  []Chain
+	tokens  []Token
+	bridges []Bridge
+	routes  []BridgeRoute
+
+	chainIndex map[string]int // by name, alias and chain ID
+	tokenIndex map[string]int // by upper-case symbol
+}
+
+// Source is a directory of registry files.
+type Source struct {
+	Name string // shown in problems, e.g. the directory path
+	FS   fs.FS
+}
+
+// Embedded returns the registry files embedded from shared/config.
+func Embedded() Source {
+	fsys, err := fs.Sub(shared.Config, "config")
+	if err != nil {
+		panic(err) // the directory is embedded at build time
+	}
+	return Source{Name: "shared/config", FS: fsys}
+}
+
+// Dir returns the registry files in a directory, such as the user's overrides.
+func Dir(path string) Source {
+	return Source{Name: path, FS: os.DirFS(path)}
+}
+
+// Problem is a schema violation or inconsistency in a registry file.
+type Problem struct {
+	File    string `json:"file"`
+	Path    string `json:"path,omitempty"` // JSON Pointer to the offending value
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	if p.Path == "" {
+		return p.File + ": " + p.Message
+	}
+	return p.File + ": " + p.Path + ": " + p.Message
+}
+
+// ValidationError lists every problem found while loading the registry.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid registry:")
+	for _, p := range e.Problems {
+		b.WriteString("\n  " + p.String())
+	}
+	return b.String()
+}
+
+// schemas returns the compiled JSON Schemas of the registry files by file name.
+var schemas = sync.OnceValues(func() (map[string]*schema, error) {
+	compiled := make(map[string]*schema)
+	for _, name := range []string{ChainsFile, TokensFile, BridgesFile} {
+		data, err := fs.ReadFile(shared.Schemas, "schemas/"+name)
+		if err != nil {
+			return nil, err
+		}
+		s, err := compileSchema(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema for %s: %v", name, err)
+		}
+		compiled[name] = s
+	}
+	return compiled, nil
+})
+
+// Default loads the registry embedded from shared/config.
+func Default() (*Registry, error) {
+	return Load(Embedded())
+}
+
+// Load reads chains.json, tokens.json and bridges.json from the sources, validates each file
+// against its JSON Schema in shared/schemas, and checks that the result is consistent: names are
+// unique, and tokens and routes only refer to known chains and tokens. The first source must hold
+// all three files. Later sources may hold any of them; their entries replace those with the same
+// chain name, token symbol or bridge name, and add the others. Every problem found is returned in
+// a *ValidationError.
+func Load(sources ...Source) (*Registry, error) {
+	compiled, err := schemas()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Registry{
+		chainIndex: make(map[string]int),
+		tokenIndex: make(map[string]int),
+	}
+	var problems []Problem
+	for i, src := range sources {
+		for _, name := range []string{ChainsFile, TokensFile, BridgesFile} {
+			file := path.Join(src.Name, name)
+			data, err := fs.ReadFile(src.FS, name)
+			if errors.Is(err, fs.ErrNotExist) && i > 0 {
+				continue
+			}
+			if err != nil {
+				problems = append(problems, Problem{File: file, Message: err.Error()})
+				continue
+			}
+			report := func(ptr, msg string) {
+				problems = append(problems, Problem{File: file, Path: cmp.Or(ptr, "/"), Message: msg})
+			}
+			if r.decode(data, name, compiled[name], report) {
+				r.files = append(r.files, file)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	problems = slices.Concat(r.indexChains(), r.indexTokens(), r.expandRoutes())
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return r, nil
+}
+
+// decode validates a registry file against its schema and merges its entries into r. It reports
+// whether the file was valid.
+func (r *Registry) decode(data []byte, name string, s *schema, report func(ptr, msg string)) bool {
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		report("", fmt.Sprintf("invalid JSON: %v", err))
+		return false
+	}
+	valid := true
+	s.validate(doc, "", func(ptr, msg string) {
+		valid = false
+		report(ptr, msg)
+	})
+	if !valid {
+		return false
+	}
+
+	var err error
+	switch name {
+	case ChainsFile:
+		var f struct {
+			Chains []Chain `json:"chains"`
+		}
+		if err = json.Unmarshal(data, &f); err == nil {
+			r.chains, err = merge(r.chains, f.Chains, "chain", func(c Chain) string { return c.Name })
+		}
+	case TokensFile:
+		var f struct {
+			Tokens []Token `json:"tokens"`
+		}
+		if err = json.Unmarshal(data, &f); err == nil {
+			r.tokens, err = merge(r.tokens, f.Tokens, "token", func(t Token) string { return t.Symbol })
+		}
+	case BridgesFile:
+		var f struct {
+			Bridges []Bridge `json:"bridges"`
+		}
+		if err = json.Unmarshal(data, &f); err == nil {
+			r.bridges, err = merge(r.bridges, f.Bridges, "bridge", func(b Bridge) string { return b.Name })
+		}
+	}
+	if err != nil {
+		report("", err.Error())
+		return false
+	}
+	return true
+}
+
+// merge replaces the entries of base that have the same key as an entry of override and appends
+// the other entries of override, whose keys must be unique.
+func merge[T any](base, override []T, kind string, key func(T) string) ([]T, error) {
+	seen := make(map[string]bool)
+	for _, o := range override {
+		k := key(o)
+		if seen[k] {
+			return nil, fmt.Errorf("duplicate %s %s", kind, k)
+		}
+		seen[k] = true
+		i := slices.IndexFunc(base, func(b T) bool { return key(b) == k })
+		if i >= 0 {
+			base[i] = o
+		} else {
+			base = append(base, o)
+		}
+	}
+	return base, nil
+}
+
+func (r *Registry) indexChains() []Problem {
+	var problems []Problem
+	for i, c := range r.chains {
+		keys := append([]string{c.Name, strconv.FormatUint(c.ChainID, 10)}, c.Aliases...)
+		for _, key := range keys {
+			key = strings.ToLower(key)
+			if j, dup := r.chainIndex[key]; dup {
+				problems = append(problems, Problem{File: ChainsFile, Message: fmt.Sprintf("chains %s and %s both use the name, alias or chain ID %s", r.chains[j].Name, c.Name, key)})
+				continue
+			}
+			r.chainIndex[key] = i
+		}
+	}
+	return problems
+}
+
+// isChain reports whether name is the name of a chain, rather than an alias or chain ID, which
+// the registry files do not use to refer to chains.
+func (r *Registry) isChain(name string) bool {
+	i, ok := r.chainIndex[name]
+	return ok && r.chains[i].Name == name
+}
+
+func (r *Registry) indexTokens() []Problem {
+	var problems []Problem
+	for i, t := range r.tokens {
+		r.tokenIndex[t.Symbol] = i // unique after merging
+		for _, chain := range slices.Sorted(maps.Keys(t.Deployments)) {
+			d := t.Deployments[chain]
+			if !r.isChain(chain) {
+				problems = append(problems, Problem{File: TokensFile, Message: fmt.Sprintf("token %s: unknown chain %s", t.Symbol, chain)})
+			}
+			if d.Native == (d.Address != "") {
+				problems = append(problems, Problem{File: TokensFile, Message: fmt.Sprintf("token %s on %s: set either address or native", t.Symbol, chain)})
+			}
+		}
+		if t.PriceFeed != nil && !r.isChain(t.PriceFeed.Chain) {
+			problems = append(problems, Problem{File: TokensFile, Message: fmt.Sprintf("token %s: price feed on unknown chain %s", t.Symbol, t.PriceFeed.Chain)})
+		}
+	}
+	return problems
+}
+
+// expandRoutes turns the route groups of each bridge into individual routes.
+func (r *Registry) expandRoutes() []Problem {
+	var problems []Problem
+	for _, b := range r.bridges {
+		for _, chain := range slices.Sorted(maps.Keys(b.Contracts)) {
+			if !r.isChain(chain) {
+				problems = append(problems, Problem{File: BridgesFile, Message: fmt.Sprintf("bridge %s: contracts on unknown chain %s", b.Name, chain)})
+			}
+		}
+		for _, g := range b.Routes {
+			valid := true
+			for _, chain := range slices.Concat(g.From, g.To) {
+				if !r.isChain(chain) {
+					problems = append(problems, Problem{File: BridgesFile, Message: fmt.Sprintf("bridge %s: unknown chain %s", b.Name, chain)})
+					valid = false
+				}
+			}
+			for _, symbol := range g.Tokens {
+				if _, ok := r.tokenIndex[symbol]; !ok {
+					problems = append(problems, Problem{File: BridgesFile, Message: fmt.Sprintf("bridge %s: unknown token %s", b.Name, symbol)})
+					valid = false
+				}
+			}
+			if !valid {
+				continue
+			}
+			for _, symbol := range g.Tokens {
+				t := &r.tokens[r.tokenIndex[symbol]]
+				for _, from := range g.From {
+					for _, to := range g.To {
+						if from == to {
+							continue
+						}
+						_, onFrom := t.Deployments[from]
+						_, onTo := t.Deployments[to]
+						if !onFrom || !onTo {
+							continue
+						}
+						r.routes = append(r.routes, BridgeRoute{
+							Bridge: b.Name,
+							Token:  symbol,
+							From:   from,
+							To:     to,
+							FeeBPS: g.FeeBPS,
+							ETA:    time.Duration(g.ETASeconds) * time.Second,
+						})
+					}
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// Files returns the registry files that were loaded, in order.
+func (r *Registry) Files() []string {
+	return slices.Clone(r.files)
+}
+
+// Chains returns all chains in registry order.
+func (r *Registry) Chains() []Chain {
+	return slices.Clone(r.chains)
+}
+
+// Chain returns the chain with the given name, alias or chain ID, ignoring case.
+func (r *Registry) Chain(name string) (*Chain, error) {
+	i, ok := r.chainIndex[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("chain %s: %w", name, ErrNotFound)
+	}
+	c := r.chains[i]
+	return &c, nil
+}
+
+// Tokens returns all tokens in registry order.
+func (r *Registry) Tokens() []Token {
+	return slices.Clone(r.tokens)
+}
+
+// Token returns the token with the given symbol, ignoring case.
+func (r *Registry) Token(symbol string) (*Token, error) {
+	i, ok := r.tokenIndex[strings.ToUpper(symbol)]
+	if !ok {
+		return nil, fmt.Errorf("token %s: %w", symbol, ErrNotFound)
+	}
+	t := r.tokens[i]
+	return &t, nil
+}
+
+// Bridges returns all bridges in registry order.
+func (r *Registry) Bridges() []Bridge {
+	return slices.Clone(r.bridges)
+}
+
+// Bridge returns the bridge with the given name.
+func (r *Registry) Bridge(name string) (*Bridge, error) {
+	for _, b := range r.bridges {
+		if b.Name == strings.ToLower(name) {
+			return &b, nil
+		}
+	}
+	return nil, fmt.Errorf("bridge %s: %w", name, ErrNotFound)
+}
+
+// Routes returns the routes matching f, in registry order. The chains and token in f are
+// resolved first, so aliases work and unknown names fail with ErrNotFound.
+func (r *Registry) Routes(f RouteFilter) ([]BridgeRoute, error) {
+	if f.Bridge != "" {
+		b, err := r.Bridge(f.Bridge)
+		if err != nil {
+			return nil, err
+		}
+		f.Bridge = b.Name
+	}
+	if f.Token != "" {
+		t, err := r.Token(f.Token)
+		if err != nil {
+			return nil, err
+		}
+		f.Token = t.Symbol
+	}
+	for _, chain := range []*string{&f.From, &f.To} {
+		if *chain == "" {
+			continue
+		}
+		c, err := r.Chain(*chain)
+		if err != nil {
+			return nil, err
+		}
+		*chain = c.Name
+	}
+
+	var routes []BridgeRoute
+	for _, route := range r.routes {
+		if (f.Bridge == "" || route.Bridge == f.Bridge) &&
+			(f.Token == "" || route.Token == f.Token) &&
+			(f.From == "" || route.From == f.From) &&
+			(f.To == "" || route.To == f.To) {
+			routes = append(routes, route)
+		}
+	}
+	return routes, nil
+}
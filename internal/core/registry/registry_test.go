@@ -0,0 +1,140 @@
+package registry
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDefault(t *testing.T) {
+	r, err := Default()
+	if err != nil {
+		t.Fatalf("Default: %v", err)
+	}
+
+	c, err := r.Chain("Mainnet")
+	if err != nil || c.Name != "ethereum" || c.ChainID != 1 {
+		t.Fatalf("Chain(Mainnet) = %+v, %v", c, err)
+	}
+	if c, err := r.Chain("42161"); err != nil || c.Name != "arbitrum" {
+		t.Fatalf("Chain(42161) = %+v, %v", c, err)
+	}
+	if _, err := r.Chain("solana"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for an unknown chain, got %v", err)
+	}
+
+	usdc, err := r.Token("usdc")
+	if err != nil {
+		t.Fatalf("Token(usdc): %v", err)
+	}
+	if d, ok := usdc.On("base"); !ok || d.Decimals != 6 || d.Native {
+		t.Fatalf("USDC on base = %+v, %v", d, ok)
+	}
+
+	a, err := usdc.ParseAmount("base", "1.5")
+	if err != nil || a.Int().Int64() != 1_500_000 {
+		t.Fatalf("ParseAmount(1.5 USDC) = %v, %v", a.Int(), err)
+	}
+	if got := usdc.FormatAmount("base", a.Int()); got != "1.5 USDC" {
+		t.Errorf("FormatAmount = %s, want 1.5 USDC", got)
+	}
+	if _, err := usdc.ParseAmount("base", "0.0000001"); err == nil {
+		t.Error("expected error for more decimal places than USDC has")
+	}
+
+	routes, err := r.Routes(RouteFilter{Token: "USDC", From: "mainnet"})
+	if err != nil || len(routes) == 0 {
+		t.Fatalf("Routes = %v, %v", routes, err)
+	}
+	for _, route := range routes {
+		if route.Token != "USDC" || route.From != "ethereum" || route.To == "ethereum" {
+			t.Errorf("unexpected route %+v", route)
+		}
+	}
+	base, err := r.Bridge("base-bridge")
+	if err != nil {
+		t.Fatalf("Bridge(base-bridge): %v", err)
+	}
+	if _, ok := base.Contract("ethereum", "standard_bridge"); !ok {
+		t.Errorf("base-bridge has no standard_bridge contract on ethereum")
+	}
+
+	// ETH is not deployed on Polygon, so no route may lead there.
+	if routes, _ := r.Routes(RouteFilter{Token: "ETH", To: "polygon"}); len(routes) != 0 {
+		t.Errorf("expected no ETH routes to polygon, got %v", routes)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	const eth = `"native_currency": {"symbol": "ETH", "decimals": 18}`
+	const chains = `{"chains": [{"name": "ethereum", "chain_id": 1, "aliases": ["mainnet"], ` + eth + `}, {"name": "base", "chain_id": 8453, ` + eth + `}]}`
+	const tokens = `{"tokens": [{"symbol": "ETH", "decimals": 18, "deployments": {"ethereum": {"native": true}, "base": {"native": true}}}]}`
+	tests := []struct {
+		name, file, data, want string
+	}{
+		{"duplicate alias", ChainsFile, `{"chains": [{"name": "ethereum", "chain_id": 1, ` + eth + `}, {"name": "mainnet", "chain_id": 2, "aliases": ["ethereum"], ` + eth + `}]}`, "both use the name"},
+		{"duplicate token", TokensFile, `{"tokens": [{"symbol": "ETH", "decimals": 18, "deployments": {}}, {"symbol": "ETH", "decimals": 18, "deployments": {}}]}`, "duplicate token ETH"},
+		{"unknown deployment chain", TokensFile, `{"tokens": [{"symbol": "ETH", "decimals": 18, "deployments": {"solana": {"native": true}}}]}`, "unknown chain solana"},
+		{"price feed chain", TokensFile, `{"tokens": [{"symbol": "ETH", "decimals": 18, "deployments": {"ethereum": {"native": true}}, "price_feed": {"chain": "solana", "address": "0x1234567890123456789012345678901234567890"}}]}`, "price feed on unknown chain solana"},
+		{"bad address", TokensFile, `{"tokens": [{"symbol": "USDC", "decimals": 6, "deployments": {"base": {"address": "0x1234"}}}]}`, "/tokens/0/deployments/base/address"},
+		{"alias in route", BridgesFile, `{"bridges": [{"name": "hop", "routes": [{"from": ["mainnet"], "to": ["base"], "tokens": ["ETH"]}]}]}`, "unknown chain mainnet"},
+		{"contracts chain", BridgesFile, `{"bridges": [{"name": "hop", "routes": [], "contracts": {"solana": {"router": "0x1234567890123456789012345678901234567890"}}}]}`, "contracts on unknown chain solana"},
+		{"unknown token", BridgesFile, `{"bridges": [{"name": "hop", "routes": [{"from": ["ethereum"], "to": ["base"], "tokens": ["DAI"]}]}]}`, "unknown token DAI"},
+		{"fee", BridgesFile, `{"bridges": [{"name": "hop", "routes": [{"from": ["ethereum"], "to": ["base"], "tokens": ["ETH"], "fee_bps": 10001}]}]}`, "must be at most 10000"},
+		{"unknown property", ChainsFile, `{"chains": [{"name": "ethereum", "chain_id": 1, "chainId": 1}]}`, `unexpected property "chainId"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				ChainsFile:  {Data: []byte(chains)},
+				TokensFile:  {Data: []byte(tokens)},
+				BridgesFile: {Data: []byte(`{"bridges": []}`)},
+			}
+			fsys[tt.file] = &fstest.MapFile{Data: []byte(tt.data)}
+			_, err := Load(Source{Name: "test", FS: fsys})
+			var verr *ValidationError
+			if !errors.As(err, &verr) || len(verr.Problems) == 0 {
+				t.Fatalf("Load: expected a *ValidationError, got %v", err)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), tt.file) {
+				t.Fatalf("Load: expected error containing %q in %s, got %v", tt.want, tt.file, err)
+			}
+		})
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	overrides := Source{Name: "overrides", FS: fstest.MapFS{
+		ChainsFile: {Data: []byte(`{"chains": [
+			{"name": "ethereum", "chain_id": 1, "native_currency": {"symbol": "ETH", "decimals": 18}, "rpc": ["https://rpc.example.com"]},
+			{"name": "zksync", "chain_id": 324, "native_currency": {"symbol": "ETH", "decimals": 18}}
+		]}`)},
+	}}
+	r, err := Load(Embedded(), overrides)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if c, err := r.Chain("ethereum"); err != nil || len(c.RPC) != 1 || c.RPC[0] != "https://rpc.example.com" {
+		t.Errorf("Chain(ethereum) = %+v, %v; want the override", c, err)
+	}
+	if _, err := r.Chain("mainnet"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the overridden chain to drop its aliases, got %v", err)
+	}
+	if _, err := r.Chain("324"); err != nil {
+		t.Errorf("Chain(324): %v", err)
+	}
+	if files := r.Files(); len(files) != 4 || files[3] != "overrides/chains.json" {
+		t.Errorf("Files = %v", files)
+	}
+
+	// Overrides are validated like the embedded files, and every problem is reported.
+	overrides.FS = fstest.MapFS{
+		TokensFile: {Data: []byte(`{"tokens": [{"symbol": "usdc", "decimals": 99, "deployments": {}}]}`)},
+	}
+	_, err = Load(Embedded(), overrides)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Problems) != 2 || verr.Problems[0].File != "overrides/tokens.json" {
+		t.Fatalf("Load: expected two problems in overrides/tokens.json, got %v", err)
+	}
+}
@@ -0,0 +1,305 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// schema is a JSON Schema restricted to the keywords used by shared/schemas. Compiling a schema
+// with any other keyword fails, so that no constraint is silently ignored.
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Defs                 map[string]*schema `json:"$defs"`
+	Type                 typeList           `json:"type"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *additional        `json:"additionalProperties"`
+	PropertyNames        *schema            `json:"propertyNames"`
+	Items                *schema            `json:"items"`
+	MinItems             *int               `json:"minItems"`
+	UniqueItems          bool               `json:"uniqueItems"`
+	Enum                 []any              `json:"enum"`
+	MinLength            *int               `json:"minLength"`
+	Pattern              string             `json:"pattern"`
+	Format               string             `json:"format"`
+	Minimum              *json.Number       `json:"minimum"`
+	Maximum              *json.Number       `json:"maximum"`
+
+	// Annotations, which do not affect validation.
+	Schema      string `json:"$schema"`
+	ID          string `json:"$id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+
+	pattern *regexp.Regexp
+	root    *schema
+}
+
+// typeList is the "type" keyword, a single type name or a list of them.
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = typeList{name}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// additional is the "additionalProperties" keyword: false, true or a schema for the values.
+type additional struct {
+	allowed bool
+	schema  *schema
+}
+
+func (a *additional) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.allowed); err == nil {
+		return nil
+	}
+	a.allowed = true
+	return decodeStrict(data, &a.schema)
+}
+
+// compileSchema parses a schema document and resolves its patterns.
+func compileSchema(data []byte) (*schema, error) {
+	var s schema
+	if err := decodeStrict(data, &s); err != nil {
+		return nil, err
+	}
+	if err := s.compile(&s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// decodeStrict decodes JSON, failing on unknown fields and keeping numbers exact.
+func decodeStrict(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func (s *schema) compile(root *schema) error {
+	s.root = root
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	if s.Format != "" && s.Format != "uri" {
+		return fmt.Errorf("unsupported format %q", s.Format)
+	}
+	if s.Ref != "" {
+		if _, err := s.resolve(); err != nil {
+			return err
+		}
+	}
+	children := []*schema{s.PropertyNames, s.Items}
+	if s.AdditionalProperties != nil {
+		children = append(children, s.AdditionalProperties.schema)
+	}
+	for _, sub := range s.Defs {
+		children = append(children, sub)
+	}
+	for _, sub := range s.Properties {
+		children = append(children, sub)
+	}
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+		if err := child.compile(root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve follows a "#/$defs/<name>" reference.
+func (s *schema) resolve() (*schema, error) {
+	name, ok := strings.CutPrefix(s.Ref, "#/$defs/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported $ref %q", s.Ref)
+	}
+	def, ok := s.root.Defs[name]
+	if !ok {
+		return nil, fmt.Errorf("unresolved $ref %q", s.Ref)
+	}
+	return def, nil
+}
+
+// validate checks v, decoded with UseNumber, against s and reports each violation with the JSON
+// Pointer of the offending value.
+func (s *schema) validate(v any, path string, report func(path, msg string)) {
+	if s.Ref != "" {
+		def, _ := s.resolve() // checked by compile
+		def.validate(v, path, report)
+		return
+	}
+
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return hasType(v, t) }) {
+		report(path, fmt.Sprintf("expected %s, got %s", strings.Join(s.Type, " or "), typeName(v)))
+		return
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return reflect.DeepEqual(e, v) }) {
+		report(path, fmt.Sprintf("must be one of %v", s.Enum))
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		s.validateObject(v, path, report)
+	case []any:
+		s.validateArray(v, path, report)
+	case string:
+		s.validateString(v, path, report)
+	case json.Number:
+		s.validateNumber(v, path, report)
+	}
+}
+
+func (s *schema) validateObject(obj map[string]any, path string, report func(path, msg string)) {
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			report(path, fmt.Sprintf("missing required property %q", name))
+		}
+	}
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		child := path + "/" + escapePointer(name)
+		if s.PropertyNames != nil {
+			s.PropertyNames.validate(name, child, report)
+		}
+		if prop, ok := s.Properties[name]; ok {
+			prop.validate(obj[name], child, report)
+			continue
+		}
+		if a := s.AdditionalProperties; a != nil {
+			switch {
+			case !a.allowed:
+				report(path, fmt.Sprintf("unexpected property %q", name))
+			case a.schema != nil:
+				a.schema.validate(obj[name], child, report)
+			}
+		}
+	}
+}
+
+func (s *schema) validateArray(items []any, path string, report func(path, msg string)) {
+	if s.MinItems != nil && len(items) < *s.MinItems {
+		report(path, fmt.Sprintf("must have at least %d items", *s.MinItems))
+	}
+	seen := make(map[string]int)
+	for i, item := range items {
+		child := path + "/" + strconv.Itoa(i)
+		if s.Items != nil {
+			s.Items.validate(item, child, report)
+		}
+		if s.UniqueItems {
+			key, _ := json.Marshal(item)
+			if j, dup := seen[string(key)]; dup {
+				report(child, fmt.Sprintf("duplicate of item %d", j))
+			}
+			seen[string(key)] = i
+		}
+	}
+}
+
+func (s *schema) validateString(str, path string, report func(path, msg string)) {
+	if s.MinLength != nil && len([]rune(str)) < *s.MinLength {
+		report(path, fmt.Sprintf("must be at least %d characters", *s.MinLength))
+	}
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		report(path, fmt.Sprintf("%q does not match %s", str, s.Pattern))
+	}
+	if s.Format == "uri" {
+		if u, err := url.Parse(str); err != nil || u.Scheme == "" || u.Host == "" {
+			report(path, fmt.Sprintf("%q is not an absolute URI", str))
+		}
+	}
+}
+
+func (s *schema) validateNumber(n json.Number, path string, report func(path, msg string)) {
+	value, _ := new(big.Rat).SetString(n.String())
+	if s.Minimum != nil {
+		if min, _ := new(big.Rat).SetString(s.Minimum.String()); value.Cmp(min) < 0 {
+			report(path, fmt.Sprintf("must be at least %s", s.Minimum))
+		}
+	}
+	if s.Maximum != nil {
+		if max, _ := new(big.Rat).SetString(s.Maximum.String()); value.Cmp(max) > 0 {
+			report(path, fmt.Sprintf("must be at most %s", s.Maximum))
+		}
+	}
+}
+
+func hasType(v any, t string) bool {
+	switch t {
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		r, ok := new(big.Rat).SetString(n.String())
+		return ok && r.IsInt()
+	}
+	return false
+}
+
+func typeName(v any) string {
+	switch v := v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	case json.Number:
+		if hasType(v, "integer") {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// escapePointer escapes a property name for use in a JSON Pointer.
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,3 @@
+module github.com/xilverfang/syncora/shared
+
+go 1.24.4
@@ -0,0 +1,16 @@
+// Package shared embeds the configuration shared by the Go CLI and the TypeScript services, so
+// that the binary carries the chain, token and bridge registries it was built with and the JSON
+// Schemas they are validated against.
+package shared
+
+import "embed"
+
+// Config holds config/chains.json, config/tokens.json and config/bridges.json.
+//
+//go:embed config/*.json
+var Config embed.FS
+
+// Schemas holds the JSON Schemas of the registry files, under the same names in schemas/.
+//
+//go:embed schemas/chains.json schemas/tokens.json schemas/bridges.json
+var Schemas embed.FS